@@ -0,0 +1,180 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package forwarder
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+
+	vxlanmech "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/drain"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/geneve"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/admin"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	defaultOrphanGracePeriod = 15 * time.Minute
+	defaultTunnelPort        = 4789
+)
+
+// Option is an option pattern for NewServer
+type Option func(o *serverOptions)
+
+type serverOptions struct {
+	orphanGracePeriod    time.Duration
+	remoteMechanisms     []string
+	tunnelPort           uint16
+	genevePort           uint16
+	geneveOptions        []nlhandle.GeneveOption
+	vxlanChecksumOffload bool
+	vxlanIPsec           bool
+	vlanParent           string
+	childParent          string
+	stateDir             string
+	refresh              bool
+	adminRegistry        *admin.Registry
+	drainer              *drain.Drainer
+	dialOptions          []grpc.DialOption
+}
+
+func newServerOptions(options []Option) *serverOptions {
+	o := &serverOptions{
+		orphanGracePeriod:    defaultOrphanGracePeriod,
+		remoteMechanisms:     []string{vxlanmech.MECHANISM},
+		tunnelPort:           defaultTunnelPort,
+		genevePort:           geneve.DefaultPort,
+		vxlanChecksumOffload: true,
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+	return o
+}
+
+// WithOrphanGracePeriod sets the time the interfaces left behind by a previous forwarder instance are kept
+// waiting for their connection to be refreshed before they are deleted
+func WithOrphanGracePeriod(gracePeriod time.Duration) Option {
+	return func(o *serverOptions) {
+		o.orphanGracePeriod = gracePeriod
+	}
+}
+
+// WithRemoteMechanisms sets the enabled remote mechanisms, in order of preference. Defaults to VXLAN only, the other
+// ones are opt-in so that the mechanisms advertised by a forwarder do not change on upgrade.
+func WithRemoteMechanisms(mechanisms ...string) Option {
+	return func(o *serverOptions) {
+		o.remoteMechanisms = mechanisms
+	}
+}
+
+// WithTunnelPort sets the UDP port of the vxlan tunnels, which is both advertised to the remote forwarders and
+// listened on by the vxlan links. Defaults to 4789.
+func WithTunnelPort(port uint16) Option {
+	return func(o *serverOptions) {
+		o.tunnelPort = port
+	}
+}
+
+// WithGenevePort sets the UDP port of the geneve tunnels, which is both advertised to the remote forwarders and
+// listened on by the geneve links. It must be the same on all the nodes. Defaults to 6081.
+func WithGenevePort(port uint16) Option {
+	return func(o *serverOptions) {
+		o.genevePort = port
+	}
+}
+
+// WithGeneveOptions sets the TLV options the forwarder sets in the geneve header of the packets it sends, see
+// geneve.ParseOptions. Defaults to none.
+func WithGeneveOptions(options []nlhandle.GeneveOption) Option {
+	return func(o *serverOptions) {
+		o.geneveOptions = options
+	}
+}
+
+// WithVXLANChecksumOffload sets whether the tx checksum offload features of the vxlan links stay enabled.
+// Defaults to true.
+func WithVXLANChecksumOffload(enabled bool) Option {
+	return func(o *serverOptions) {
+		o.vxlanChecksumOffload = enabled
+	}
+}
+
+// WithVXLANIPsec enables the IPsec protection of the vxlan tunnels
+func WithVXLANIPsec(enabled bool) Option {
+	return func(o *serverOptions) {
+		o.vxlanIPsec = enabled
+	}
+}
+
+// WithStateDir enables persisting the state of the cross connects to dir, so that they can be torn down by a Close
+// received after a forwarder restart, and their links are taken over instead of being created again. The wireguard
+// and ipsec keys of the forwarder are kept there as well. dir is meant to be on a hostPath volume.
+func WithStateDir(dir string) Option {
+	return func(o *serverOptions) {
+		o.stateDir = dir
+	}
+}
+
+// WithRefresh makes the forwarder refresh the outgoing connections on its own, before the tokens it issued for them
+// expire, instead of relying on the refreshes of the incoming connections
+func WithRefresh(enabled bool) Option {
+	return func(o *serverOptions) {
+		o.refresh = enabled
+	}
+}
+
+// WithAdminRegistry sets the registry the active cross connects are added to for the admin API
+func WithAdminRegistry(registry *admin.Registry) Option {
+	return func(o *serverOptions) {
+		o.adminRegistry = registry
+	}
+}
+
+// WithVLANParent sets the interface of the forwarder network namespace the vlan sub-interfaces are created on, and
+// enables the VLAN mechanism. It is disabled if not set.
+func WithVLANParent(parent string) Option {
+	return func(o *serverOptions) {
+		o.vlanParent = parent
+	}
+}
+
+// WithChildParent sets the interface of the forwarder network namespace the macvlan and ipvlan children of the
+// local connections selecting them are created on
+func WithChildParent(parent string) Option {
+	return func(o *serverOptions) {
+		o.childParent = parent
+	}
+}
+
+// WithDrainer sets the Drainer switching the forwarder to reject the Requests for new connections
+func WithDrainer(drainer *drain.Drainer) Option {
+	return func(o *serverOptions) {
+		o.drainer = drainer
+	}
+}
+
+// WithDialOptions sets the options used to dial the registry and the next endpoints
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *serverOptions) {
+		o.dialOptions = dialOptions
+	}
+}
@@ -3,8 +3,10 @@ package forwarder
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -22,17 +24,36 @@ import (
 	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
 	registryclient "github.com/networkservicemesh/sdk/pkg/registry/chains/client"
 	registryrecvfd "github.com/networkservicemesh/sdk/pkg/registry/common/recvfd"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
 	"github.com/networkservicemesh/sdk/pkg/tools/token"
 
 	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/connectioncontextkernel"
 
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/drain"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/geneve"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/gre"
 	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/recvfd"
 	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/sendfd"
 	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/veth"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/vlan"
 	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/vxlan"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/wireguard"
 	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/xconnect"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/connstate"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/fs"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/ipsec"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/linkstats"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/mtu"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/orphans"
+)
 
-	"google.golang.org/grpc"
+const (
+	netNSRescanInterval = 10 * time.Second
+	// wireguardKeyFile is the file of the state directory the wireguard private key is kept in
+	wireguardKeyFile = "wireguard.key"
+	// ipsecKeyFile is the file of the state directory the ipsec private key is kept in
+	ipsecKeyFile = "ipsec.key"
 )
 
 // Kernel endpoint server to cross-connect network service client pods to the endpoint pod
@@ -43,7 +64,16 @@ type kernelXconnectNSServer struct {
 func newEndpoint(ctx context.Context, name string,
 	authzServer networkservice.NetworkServiceServer, authzMonitorServer networkservice.MonitorConnectionServer,
 	tokenGenerator token.GeneratorFunc, clientURL *url.URL, tunnelIpStr string, dialTimeout time.Duration,
-	clientDialOptions ...grpc.DialOption) (endpoint.Endpoint, error) {
+	options ...Option) (endpoint.Endpoint, error) {
+	opts := newServerOptions(options)
+	if opts.tunnelPort == 0 {
+		return nil, errors.New("tunnel port must not be 0")
+	}
+	if opts.genevePort == 0 {
+		return nil, errors.New("geneve port must not be 0")
+	}
+	clientDialOptions := opts.dialOptions
+
 	nseClient := registryclient.NewNetworkServiceEndpointRegistryClient(ctx,
 		registryclient.WithClientURL(clientURL),
 		registryclient.WithNSEAdditionalFunctionality(registryrecvfd.NewNetworkServiceEndpointRegistryClient()),
@@ -53,42 +83,135 @@ func newEndpoint(ctx context.Context, name string,
 		registryclient.WithClientURL(clientURL),
 		registryclient.WithDialOptions(clientDialOptions...))
 
-	tunnelIP, err := parseTunnelIPCIDR(tunnelIpStr)
+	tunnelIP, altTunnelIP, err := parseTunnelIPs(tunnelIpStr)
 	if err != nil {
 		return nil, err
 	}
 
+	var stateStore *connstate.Store
+	if opts.stateDir != "" {
+		if stateStore, err = connstate.NewStore(opts.stateDir); err != nil {
+			return nil, err
+		}
+	}
+
+	var wireguardTunnel *wireguard.Tunnel
+	if remoteMechanismEnabled(opts.remoteMechanisms, wireguard.MECHANISM) {
+		tunnelOptions := []wireguard.Option{wireguard.WithStateStore(stateStore)}
+		if opts.stateDir != "" {
+			tunnelOptions = append(tunnelOptions, wireguard.WithKeyFile(filepath.Join(opts.stateDir, wireguardKeyFile)))
+		}
+		if wireguardTunnel, err = wireguard.NewTunnel(tunnelIP, tunnelOptions...); err != nil {
+			return nil, err
+		}
+	}
+	nl := nlhandle.WithMetrics(nlhandle.NewProvider())
+	vxlanOptions := []vxlan.Option{
+		vxlan.WithAltTunnelIP(altTunnelIP),
+		vxlan.WithTunnelPort(opts.tunnelPort),
+		vxlan.WithChecksumOffload(opts.vxlanChecksumOffload),
+	}
+	if opts.vxlanIPsec {
+		var ipsecOptions []ipsec.Option
+		if opts.stateDir != "" {
+			ipsecOptions = append(ipsecOptions, ipsec.WithKeyFile(filepath.Join(opts.stateDir, ipsecKeyFile)))
+		}
+		ipsecManager, err := ipsec.NewManager(ipsecOptions...)
+		if err != nil {
+			return nil, err
+		}
+		vxlanOptions = append(vxlanOptions, vxlan.WithIPsec(ipsecManager))
+
+		// The SAs installed by the previous forwarder instance are removed with the last of its connections
+		records, err := stateStore.List()
+		if err != nil {
+			return nil, err
+		}
+		if err = vxlan.RestoreIPsec(nl, records, vxlanOptions...); err != nil {
+			return nil, err
+		}
+	}
+	remoteServers, remoteClients, err := newRemoteMechanisms(tunnelIP, wireguardTunnel, vxlanOptions, opts.genevePort, opts.geneveOptions, opts.remoteMechanisms)
+	if err != nil {
+		return nil, err
+	}
+	mechanismServers := map[string]networkservice.NetworkServiceServer{
+		kernelmech.MECHANISM: veth.NewServer(),
+	}
+	for mechanism, server := range remoteServers {
+		mechanismServers[mechanism] = server
+	}
+
+	// The remote mechanism clients sit between the local mechanism clients and filtermechanisms
+	clientFunctionality := []networkservice.NetworkServiceClient{
+		mechanismtranslation.NewClient(),
+		xconnect.NewClient(),
+		veth.NewClient(),
+	}
+	clientFunctionality = append(clientFunctionality, remoteClients...)
+	// The VLAN mechanism is only ever selected by the endpoints serving a VLAN, it comes last
+	if opts.vlanParent != "" {
+		clientFunctionality = append(clientFunctionality, vlan.NewClient())
+	}
+	clientFunctionality = append(clientFunctionality,
+		filtermechanisms.NewClient(),
+		recvfd.NewClient(),
+		sendfd.NewClient(),
+	)
+
 	rv := &kernelXconnectNSServer{}
 
+	// Keep the index of the network namespaces the inode URLs of the Requests are resolved with warm
+	fs.DefaultNetNSResolver().Run(ctx, netNSRescanInterval)
+
+	// Scan for the interfaces left behind by a previous forwarder instance before any Request is served
+	orphanReconciler := orphans.NewReconciler(ctx,
+		orphans.WithNetlinkProvider(nl),
+		orphans.WithGracePeriod(opts.orphanGracePeriod),
+	)
+
+	// The MTU of the created links is derived from the one of the interface the tunnel traffic goes through
+	underlayMTU, err := mtu.Discover(nl, tunnelIP)
+	if err != nil {
+		log.FromContext(ctx).Warnf("failed to discover the MTU of the tunnel interface, assuming %d: %v", mtu.DefaultUnderlay, err)
+		underlayMTU = mtu.DefaultUnderlay
+	}
+	log.FromContext(ctx).Infof("underlay MTU: %d", underlayMTU)
+
+	clientOptions := []client.Option{
+		client.WithName(name),
+		client.WithDialOptions(clientDialOptions...),
+		client.WithDialTimeout(dialTimeout),
+		client.WithAdditionalFunctionality(clientFunctionality...),
+	}
+	// By default the outgoing connections are only refreshed by the refreshes of the incoming ones, and both expire
+	// through the timeout element of the endpoint chain when these stop
+	if !opts.refresh {
+		clientOptions = append(clientOptions, client.WithoutRefresh())
+	}
+
 	additionalFunctionality := []networkservice.NetworkServiceServer{
+		drain.NewServer(opts.drainer),
 		metadata.NewServer(),
 		recvfd.NewServer(),
 		sendfd.NewServer(),
 		discover.NewServer(nsClient, nseClient),
 		roundrobin.NewServer(),
 		connectioncontextkernel.NewServer(),
-		xconnect.NewServer(),
-		mechanisms.NewServer(map[string]networkservice.NetworkServiceServer{
-			kernelmech.MECHANISM: veth.NewServer(),
-			vxlanmech.MECHANISM:  vxlan.NewServer(tunnelIP),
-		}),
-		connect.NewServer(
-			client.NewClient(ctx,
-				client.WithoutRefresh(),
-				client.WithName(name),
-				client.WithDialOptions(clientDialOptions...),
-				client.WithDialTimeout(dialTimeout),
-				client.WithAdditionalFunctionality(
-					mechanismtranslation.NewClient(),
-					xconnect.NewClient(),
-					veth.NewClient(),
-					vxlan.NewClient(tunnelIP),
-					filtermechanisms.NewClient(),
-					recvfd.NewClient(),
-					sendfd.NewClient(),
-				),
-			),
+		xconnect.NewServer(ctx,
+			xconnect.WithNetlinkProvider(nl),
+			xconnect.WithOrphanReconciler(orphanReconciler),
+			xconnect.WithStatsCollector(linkstats.NewCollector(ctx, linkstats.WithNetlinkProvider(nl))),
+			xconnect.WithWireguardTunnel(wireguardTunnel),
+			xconnect.WithVXLANOptions(vxlanOptions...),
+			xconnect.WithVLANParent(opts.vlanParent),
+			xconnect.WithChildParent(opts.childParent),
+			xconnect.WithUnderlayMTU(underlayMTU),
+			xconnect.WithStateStore(stateStore),
+			xconnect.WithAdminRegistry(opts.adminRegistry),
 		),
+		mechanisms.NewServer(mechanismServers),
+		connect.NewServer(client.NewClient(ctx, clientOptions...)),
 	}
 
 	rv.Endpoint = endpoint.NewServer(ctx, tokenGenerator,
@@ -100,6 +223,74 @@ func newEndpoint(ctx context.Context, name string,
 	return rv, nil
 }
 
+// newRemoteMechanisms returns the servers of the enabled remote mechanisms and their clients, which add the
+// mechanism preferences in the order of names.
+func newRemoteMechanisms(tunnelIP net.IP, wireguardTunnel *wireguard.Tunnel, vxlanOptions []vxlan.Option, genevePort uint16, geneveOptions []nlhandle.GeneveOption, names []string) (map[string]networkservice.NetworkServiceServer, []networkservice.NetworkServiceClient, error) {
+	servers := make(map[string]networkservice.NetworkServiceServer)
+	var clients []networkservice.NetworkServiceClient
+	for _, name := range names {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if _, ok := servers[name]; ok {
+			continue
+		}
+		switch name {
+		case vxlanmech.MECHANISM:
+			servers[name] = vxlan.NewServer(tunnelIP, vxlanOptions...)
+			clients = append(clients, vxlan.NewClient(tunnelIP, vxlanOptions...))
+		case geneve.MECHANISM:
+			servers[name] = geneve.NewServer(tunnelIP, genevePort, geneveOptions...)
+			clients = append(clients, geneve.NewClient(tunnelIP, genevePort, geneveOptions...))
+		case gre.MECHANISM:
+			servers[name] = gre.NewServer(tunnelIP)
+			clients = append(clients, gre.NewClient(tunnelIP))
+		case gre.TAPMECHANISM:
+			servers[name] = gre.NewServer(tunnelIP)
+			clients = append(clients, gre.NewTapClient(tunnelIP))
+		case wireguard.MECHANISM:
+			servers[name] = wireguard.NewServer(wireguardTunnel)
+			clients = append(clients, wireguard.NewClient(wireguardTunnel))
+		default:
+			return nil, nil, fmt.Errorf("unsupported remote mechanism: %s", name)
+		}
+	}
+	if len(servers) == 0 {
+		return nil, nil, errors.New("at least one remote mechanism must be enabled")
+	}
+	return servers, clients, nil
+}
+
+// remoteMechanismEnabled reports whether the remote mechanism is one of names
+func remoteMechanismEnabled(names []string, mechanism string) bool {
+	for _, name := range names {
+		if strings.ToUpper(strings.TrimSpace(name)) == mechanism {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTunnelIPs parses the tunnel IP of the forwarder, or the comma separated IPv4 and IPv6 tunnel IPs of a
+// dual-stack node. The first one is the tunnel IP advertised by default.
+func parseTunnelIPs(tunnelIPsStr string) (tunnelIP, altTunnelIP net.IP, err error) {
+	parts := strings.Split(tunnelIPsStr, ",")
+	if len(parts) > 2 {
+		return nil, nil, errors.New("at most an IPv4 and an IPv6 tunnel IP can be set")
+	}
+	if tunnelIP, err = parseTunnelIPCIDR(strings.TrimSpace(parts[0])); err != nil {
+		return nil, nil, err
+	}
+	if len(parts) == 1 {
+		return tunnelIP, nil, nil
+	}
+	if altTunnelIP, err = parseTunnelIPCIDR(strings.TrimSpace(parts[1])); err != nil {
+		return nil, nil, err
+	}
+	if (tunnelIP.To4() == nil) == (altTunnelIP.To4() == nil) {
+		return nil, nil, errors.New("the two tunnel IPs must be of different address families")
+	}
+	return tunnelIP, altTunnelIP, nil
+}
+
 func parseTunnelIPCIDR(tunnelIPStr string) (net.IP, error) {
 	var egressTunnelIP net.IP
 	var err error
@@ -114,8 +305,9 @@ func parseTunnelIPCIDR(tunnelIPStr string) (net.IP, error) {
 	return egressTunnelIP, err
 }
 
+// NewServer returns the kernel forwarder endpoint
 func NewServer(ctx context.Context, name string, authzServer networkservice.NetworkServiceServer,
 	authzMonitorServer networkservice.MonitorConnectionServer, tokenGenerator token.GeneratorFunc,
-	clientURL *url.URL, tunnelIpStr string, dialTimeout time.Duration, clientDialOptions ...grpc.DialOption) (endpoint.Endpoint, error) {
-	return newEndpoint(ctx, name, authzServer, authzMonitorServer, tokenGenerator, clientURL, tunnelIpStr, dialTimeout, clientDialOptions...)
+	clientURL *url.URL, tunnelIpStr string, dialTimeout time.Duration, options ...Option) (endpoint.Endpoint, error) {
+	return newEndpoint(ctx, name, authzServer, authzMonitorServer, tokenGenerator, clientURL, tunnelIpStr, dialTimeout, options...)
 }
@@ -0,0 +1,119 @@
+// Package drain provides a chain element rejecting the new connections once the forwarder is draining, while the
+// existing ones keep being refreshed and closed as usual.
+package drain
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Drainer tracks the connections served by the forwarder and switches it to draining
+type Drainer struct {
+	mu       sync.Mutex
+	conns    map[string]struct{}
+	draining bool
+	idle     chan struct{}
+}
+
+// NewDrainer returns a Drainer that is not draining
+func NewDrainer() *Drainer {
+	return &Drainer{
+		conns: make(map[string]struct{}),
+		idle:  make(chan struct{}),
+	}
+}
+
+// Start switches to draining: from now on the Requests for new connections fail with codes.Unavailable, so that the
+// network service manager retries them with another forwarder.
+func (d *Drainer) Start(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining {
+		return
+	}
+	d.draining = true
+	log.FromContext(ctx).WithField("drain", "Start").Infof("draining %d connections", len(d.conns))
+	d.closeIdleLocked()
+}
+
+// Draining reports whether the forwarder is draining
+func (d *Drainer) Draining() bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.draining
+}
+
+// Idle returns a channel closed once the forwarder is draining and all of its connections are closed
+func (d *Drainer) Idle() <-chan struct{} {
+	return d.idle
+}
+
+func (d *Drainer) closeIdleLocked() {
+	if !d.draining || len(d.conns) > 0 {
+		return
+	}
+	select {
+	case <-d.idle:
+	default:
+		close(d.idle)
+	}
+}
+
+type drainServer struct {
+	drainer *Drainer
+}
+
+// NewServer returns a chain element rejecting the Requests for new connections once drainer is draining
+func NewServer(drainer *Drainer) networkservice.NetworkServiceServer {
+	return &drainServer{drainer: drainer}
+}
+
+func (s *drainServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if s.drainer == nil {
+		return next.Server(ctx).Request(ctx, request)
+	}
+	connID := request.GetConnection().GetId()
+
+	s.drainer.mu.Lock()
+	_, known := s.drainer.conns[connID]
+	draining := s.drainer.draining
+	s.drainer.mu.Unlock()
+	if draining && !known {
+		return nil, status.Errorf(codes.Unavailable, "forwarder is draining, rejected new connection %s", connID)
+	}
+
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	s.drainer.mu.Lock()
+	s.drainer.conns[conn.GetId()] = struct{}{}
+	s.drainer.mu.Unlock()
+
+	return conn, nil
+}
+
+func (s *drainServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	if s.drainer != nil {
+		s.drainer.mu.Lock()
+		delete(s.drainer.conns, conn.GetId())
+		s.drainer.closeIdleLocked()
+		s.drainer.mu.Unlock()
+	}
+	return next.Server(ctx).Close(ctx, conn)
+}
@@ -0,0 +1,95 @@
+package drain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func request(id string) *networkservice.NetworkServiceRequest {
+	return &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: id}}
+}
+
+func idle(d *Drainer) bool {
+	select {
+	case <-d.Idle():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestServerDraining(t *testing.T) {
+	ctx := context.Background()
+	d := NewDrainer()
+	server := NewServer(d)
+
+	for _, id := range []string{"conn-1", "conn-2"} {
+		if _, err := server.Request(ctx, request(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.Start(ctx)
+	if !d.Draining() {
+		t.Fatal("not draining after Start")
+	}
+
+	for _, tc := range []struct {
+		id   string
+		want codes.Code
+	}{
+		{id: "conn-1", want: codes.OK},
+		{id: "conn-2", want: codes.OK},
+		{id: "conn-3", want: codes.Unavailable},
+	} {
+		t.Run(tc.id, func(t *testing.T) {
+			_, err := server.Request(ctx, request(tc.id))
+			if got := status.Code(err); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// Idle is closed once the last tracked connection is closed, the rejected one was never tracked
+	if idle(d) {
+		t.Fatal("idle with open connections")
+	}
+	if _, err := server.Close(ctx, &networkservice.Connection{Id: "conn-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if idle(d) {
+		t.Fatal("idle with an open connection")
+	}
+	if _, err := server.Close(ctx, &networkservice.Connection{Id: "conn-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if !idle(d) {
+		t.Fatal("not idle after the last connection was closed")
+	}
+}
+
+func TestStartWithoutConnections(t *testing.T) {
+	d := NewDrainer()
+	if d.Draining() || idle(d) {
+		t.Fatal("draining before Start")
+	}
+	d.Start(context.Background())
+	d.Start(context.Background())
+	if !idle(d) {
+		t.Fatal("not idle after Start without connections")
+	}
+}
+
+func TestNilDrainer(t *testing.T) {
+	var d *Drainer
+	d.Start(context.Background())
+	if d.Draining() {
+		t.Fatal("nil drainer is draining")
+	}
+	if _, err := NewServer(nil).Request(context.Background(), request("conn-1")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package geneve
+
+import (
+	"context"
+	"net"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/payload"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+type geneveClient struct {
+}
+
+// NewClient - returns a new client for the geneve remote mechanism, whose links listen on tunnelPort and send their
+// packets with the TLV options
+func NewClient(tunnelIP net.IP, tunnelPort uint16, options ...nlhandle.GeneveOption) networkservice.NetworkServiceClient {
+	return chain.NewNetworkServiceClient(
+		&geneveClient{},
+		newEndpointClient(tunnelIP, tunnelPort, options),
+	)
+}
+
+func (g *geneveClient) Request(ctx context.Context, request *networkservice.NetworkServiceRequest, opts ...grpc.CallOption) (*networkservice.Connection, error) {
+	if request.GetConnection().GetPayload() != payload.Ethernet {
+		return next.Client(ctx).Request(ctx, request, opts...)
+	}
+
+	mechanism := &networkservice.Mechanism{
+		Cls:        cls.REMOTE,
+		Type:       MECHANISM,
+		Parameters: make(map[string]string),
+	}
+	request.MechanismPreferences = append(request.MechanismPreferences, mechanism)
+
+	return next.Client(ctx).Request(ctx, request, opts...)
+}
+
+func (g *geneveClient) Close(ctx context.Context, conn *networkservice.Connection, opts ...grpc.CallOption) (*empty.Empty, error) {
+	return next.Client(ctx).Close(ctx, conn, opts...)
+}
@@ -0,0 +1,321 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package geneve
+
+import (
+	"context"
+	"net"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+// Create creates the geneve link for the geneve mechanism of the conn and moves it to the target network namespace.
+// All netlink operations are performed through the handles returned by nl.
+func Create(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, outgoing bool) error {
+	logger := log.FromContext(ctx).WithField("geneve", "Intf create")
+	mechanism := ToMechanism(conn.GetMechanism())
+	if mechanism == nil {
+		return nil
+	}
+	if mechanism.SrcIP() == nil {
+		return errors.Errorf("geneve SrcIP not provided")
+	}
+	if mechanism.DstIP() == nil {
+		return errors.Errorf("geneve DstIP not provided")
+	}
+	if mechanism.VNI() == 0 {
+		return errors.Errorf("geneve VNI not provided")
+	}
+	ifaceName := mechanism.GetParameters()[kernel.InterfaceNameKey]
+	if ifaceName == "" {
+		return errors.Errorf("geneve interface name not provided")
+	}
+	netNsURL := mechanism.GetParameters()[kernel.NetNSURL]
+	if netNsURL == "" {
+		return errors.Errorf("geneve inode URL not provided")
+	}
+
+	// The remote end of the tunnel is the source of the mechanism for incoming connections and its destination
+	// for the outgoing ones, see vxlan.Create.
+	var remoteIP net.IP
+	var remotePort uint16
+	if outgoing {
+		remoteIP, remotePort = mechanism.DstIP(), mechanism.DstPort()
+	} else {
+		remoteIP, remotePort = mechanism.SrcIP(), mechanism.SrcPort()
+	}
+	if remotePort == 0 {
+		remotePort = DefaultPort
+	}
+	// The link listens on the port it sends to, the tunnel only works if both forwarders use the same one
+	if srcPort, dstPort := mechanism.SrcPort(), mechanism.DstPort(); srcPort != 0 && dstPort != 0 && srcPort != dstPort {
+		return errors.Errorf("geneve ports %d and %d differ, the forwarders must use the same geneve port", srcPort, dstPort)
+	}
+	vni := mechanism.VNI()
+	localIP, options, err := local(mechanism, outgoing)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("netnsurl: %v: iface: %v: remoteIP: %s: port: %v: vni: %v", netNsURL, ifaceName, remoteIP.String(), remotePort, vni)
+
+	handle, err := nl.FromURL(netNsURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Close()
+
+	hostHandle, err := nl.Host()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer hostHandle.Close()
+
+	if len(options) > 0 {
+		return createExternal(ctx, handle, hostHandle, conn, outgoing, ifaceName, netNsURL, &tunnel{
+			local:   localIP,
+			remote:  remoteIP,
+			port:    remotePort,
+			vni:     vni,
+			options: options,
+		})
+	}
+
+	// Treat the Request as redundant if the link created by the forwarder is still present in the target namespace
+	if _, ok := link.Load(ctx, outgoing); ok {
+		if _, err = handle.LinkByName(ifaceName); err == nil {
+			return nil
+		}
+	}
+
+	// After a restart, take over the link created for the connection by the previous forwarder instance if it still
+	// tunnels to the same remote with the same VNI, so that the traffic is not interrupted
+	if prevLink, err := handle.LinkByName(ifaceName); err == nil && adoptable(ctx, prevLink, netNsURL, remoteIP, remotePort, vni) {
+		link.Adopt(ctx, outgoing, prevLink)
+		return nil
+	}
+
+	// The forwarder is not aware of any other interface with that name in the target namespace, it is a stale one
+	if prevLink, err := handle.LinkByName(ifaceName); err == nil {
+		if err = handle.LinkDel(prevLink); err != nil {
+			return errors.WithStack(err)
+		}
+		metrics.StaleLinkDeletions.Inc("geneve")
+		log.FromContext(ctx).
+			WithField("link.Name", prevLink.Attrs().Name).
+			WithField("netlink", "LinkDel").Debug("completed")
+	}
+
+	// Create the geneve link in the host network namespace, so that its UDP socket is bound there, and move it to
+	// the target namespace afterwards.
+	fwdNsIfaceName := linuxIfaceName(conn.GetId())
+	if err = hostHandle.LinkAdd(newGeneve(fwdNsIfaceName, remoteIP, remotePort, vni, int(conn.GetContext().GetMTU()))); err != nil {
+		return errors.Wrapf(err, "failed to create GENEVE interface")
+	}
+	log.FromContext(ctx).WithField("link.Name", fwdNsIfaceName).WithField("netlink", "LinkAdd geneve").Debug("completed")
+
+	l, err := hostHandle.LinkByName(fwdNsIfaceName)
+	if err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", fwdNsIfaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkByName").Debug("error")
+		return errors.WithStack(err)
+	}
+
+	if err = hostHandle.LinkSetNs(l, netNsURL); err != nil {
+		return errors.Wrapf(err, "unable to change to netns")
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "LinkSetNsFd").Debug("completed")
+
+	if l, err = handle.LinkByName(fwdNsIfaceName); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err = handle.LinkSetName(l, ifaceName); err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("link.NewName", ifaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkSetName").Debug("error")
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("link.NewName", ifaceName).
+		WithField("netlink", "LinkSetName").Debug("completed")
+
+	// Set link alias to the name of the network service client pod requesting for this link.
+	if linkAlias := link.Alias(conn.GetLabels()["podName"]); linkAlias != "" {
+		if err = handle.LinkSetAlias(l, linkAlias); err != nil {
+			return errors.WithStack(err)
+		}
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("alias", linkAlias).
+			WithField("netlink", "LinkSetAlias").Debug("completed")
+	}
+
+	if err = handle.LinkSetUp(l); err != nil {
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "LinkSetUp").Debug("completed")
+
+	link.Store(ctx, outgoing, l)
+
+	return nil
+}
+
+// Delete deletes the geneve link for the geneve mechanism of the conn from the target network namespace
+func Delete(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, outgoing bool) error {
+	mechanism := ToMechanism(conn.GetMechanism())
+	if mechanism == nil {
+		return nil
+	}
+	ifaceName := mechanism.GetParameters()[kernel.InterfaceNameKey]
+	if ifaceName == "" {
+		return errors.Errorf("geneve interface name not provided")
+	}
+	netNsURL := mechanism.GetParameters()[kernel.NetNSURL]
+	if netNsURL == "" {
+		return errors.Errorf("geneve inode URL not provided")
+	}
+
+	handle, err := nl.FromURL(netNsURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Close()
+
+	if _, options, err := local(mechanism, outgoing); err == nil && len(options) > 0 {
+		hostHandle, err := nl.Host()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer hostHandle.Close()
+
+		remoteIP, remotePort := mechanism.SrcIP(), mechanism.SrcPort()
+		if outgoing {
+			remoteIP, remotePort = mechanism.DstIP(), mechanism.DstPort()
+		}
+		if remotePort == 0 {
+			remotePort = DefaultPort
+		}
+		if err = deleteExternal(ctx, hostHandle, remoteIP, remotePort, mechanism.VNI()); err != nil {
+			return err
+		}
+	}
+
+	l, err := handle.LinkByName(ifaceName)
+	if err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", ifaceName).
+			WithField("netlink", "LinkByName").Debug("NotFound")
+		link.Delete(ctx, outgoing)
+		return nil
+	}
+
+	if err = handle.LinkDel(l); err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", ifaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkDel").Debug("error")
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", ifaceName).
+		WithField("netlink", "LinkDel").Info("completed")
+	link.Delete(ctx, outgoing)
+
+	return nil
+}
+
+func linuxIfaceName(ifaceName string) string {
+	if len(ifaceName) <= kernel.LinuxIfMaxLength {
+		return ifaceName
+	}
+	return ifaceName[:kernel.LinuxIfMaxLength]
+}
+
+// Overhead returns the encapsulation overhead of the geneve links for the mechanism, which depends on the address
+// family of the underlay and on the TLV options of both ends. It is 0 for other mechanism types.
+func Overhead(m *networkservice.Mechanism) int {
+	mechanism := ToMechanism(m)
+	if mechanism == nil {
+		return 0
+	}
+	// Both ends subtract the longest options, so that the connection has the same MTU on both of them
+	srcOptions, _ := mechanism.SrcOptions()
+	dstOptions, _ := mechanism.DstOptions()
+	optionsOverhead := optionsLen(srcOptions)
+	if dstLen := optionsLen(dstOptions); dstLen > optionsOverhead {
+		optionsOverhead = dstLen
+	}
+	if mechanism.SrcIP().To4() != nil {
+		return overheadIPv4 + optionsOverhead
+	}
+	return overheadIPv6 + optionsOverhead
+}
+
+// local returns the local address of the tunnel and the TLV options of the packets sent by the forwarder, the ones
+// of the source of the mechanism for outgoing connections and of its destination for the incoming ones
+func local(mechanism *Mechanism, outgoing bool) (net.IP, []nlhandle.GeneveOption, error) {
+	if outgoing {
+		options, err := mechanism.SrcOptions()
+		return mechanism.SrcIP(), options, errors.Wrapf(err, "invalid %s parameter", SrcTLVOptions)
+	}
+	options, err := mechanism.DstOptions()
+	return mechanism.DstIP(), options, errors.Wrapf(err, "invalid %s parameter", DstTLVOptions)
+}
+
+// adoptable reports whether l is the geneve link created by the forwarder for the connection to remoteIP and
+// remotePort with the given VNI
+func adoptable(ctx context.Context, l netlink.Link, netNSURL string, remoteIP net.IP, remotePort uint16, vni uint32) bool {
+	geneveLink, ok := l.(*netlink.Geneve)
+	return ok && link.Owned(ctx, l, netNSURL) &&
+		geneveLink.ID == vni &&
+		geneveLink.Remote.Equal(remoteIP) &&
+		geneveLink.Dport == remotePort
+}
+
+// newGeneve returns the geneve link to create. A zero mtu leaves it to the kernel.
+func newGeneve(ifaceName string, remoteIP net.IP, port uint16, vni uint32, mtu int) *netlink.Geneve {
+	return &netlink.Geneve{
+		LinkAttrs: netlink.LinkAttrs{
+			Name:  ifaceName,
+			Group: link.Group,
+			MTU:   mtu,
+		},
+		ID:     vni,
+		Remote: remoteIP,
+		Dport:  port,
+	}
+}
@@ -0,0 +1,313 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package geneve
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const netNSURL = "inode://4/1001"
+
+type ctxServer struct {
+	ctx context.Context
+}
+
+func (s *ctxServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	s.ctx = ctx
+	return request.GetConnection(), nil
+}
+
+func (s *ctxServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+// metadataContext returns a context carrying the per connection metadata of connID, as the elements following the
+// metadata server in a chain get it
+func metadataContext(t *testing.T, connID string) context.Context {
+	t.Helper()
+	s := &ctxServer{}
+	request := &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: connID}}
+	if _, err := chain.NewNetworkServiceServer(metadata.NewServer(), s).Request(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	return s.ctx
+}
+
+func newConn(connID, ifaceName, srcIP, dstIP string, vni uint32) *networkservice.Connection {
+	m := &networkservice.Mechanism{
+		Cls:  cls.REMOTE,
+		Type: MECHANISM,
+		Parameters: map[string]string{
+			kernel.InterfaceNameKey: ifaceName,
+			kernel.NetNSURL:         netNSURL,
+		},
+	}
+	mechanism := ToMechanism(m)
+	mechanism.SetSrcIP(net.ParseIP(srcIP)).SetSrcPort(DefaultPort).SetDstIP(net.ParseIP(dstIP)).SetDstPort(DefaultPort)
+	mechanism.SetVNI(vni)
+	return &networkservice.Connection{
+		Id:        connID,
+		Mechanism: m,
+		Context:   &networkservice.ConnectionContext{MTU: 1400},
+	}
+}
+
+func TestCreate(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		srcIP, dstIP string
+		outgoing     bool
+	}{
+		{name: "outgoing", srcIP: "10.0.0.1", dstIP: "10.0.0.2", outgoing: true},
+		{name: "incoming", srcIP: "10.0.0.2", dstIP: "10.0.0.1"},
+		{name: "IPv6", srcIP: "fd00::1", dstIP: "fd00::2", outgoing: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := nlhandle.NewFake()
+			fake.AddNamespace(netNSURL)
+			ctx := metadataContext(t, "conn-1")
+			conn := newConn("conn-1", "nsm-1", tc.srcIP, tc.dstIP, 42)
+
+			if err := Create(ctx, fake, conn, tc.outgoing); err != nil {
+				t.Fatal(err)
+			}
+			handle, err := fake.FromURL(netNSURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer handle.Close()
+			l, err := handle.LinkByName("nsm-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The remote end of the tunnel is the source of the mechanism of the incoming connections
+			remoteIP := net.ParseIP(tc.dstIP)
+			if !tc.outgoing {
+				remoteIP = net.ParseIP(tc.srcIP)
+			}
+			geneveLink, ok := l.(*netlink.Geneve)
+			if !ok || geneveLink.ID != 42 || !geneveLink.Remote.Equal(remoteIP) || geneveLink.Dport != DefaultPort || geneveLink.FlowBased {
+				t.Errorf("got link %+v, want a geneve link to %s:%d with VNI 42", l, remoteIP, DefaultPort)
+			}
+			if l.Attrs().MTU != 1400 {
+				t.Errorf("got MTU %d, want 1400", l.Attrs().MTU)
+			}
+
+			// The refresh keeps the link, the Close deletes it
+			if err = Create(ctx, fake, conn, tc.outgoing); err != nil {
+				t.Fatal(err)
+			}
+			if refreshed, err := handle.LinkByName("nsm-1"); err != nil || refreshed.Attrs().Index != l.Attrs().Index {
+				t.Errorf("the link was not kept by the refresh: %v", err)
+			}
+			if err = Delete(ctx, fake, conn, tc.outgoing); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = handle.LinkByName("nsm-1"); err == nil {
+				t.Error("the link was not deleted")
+			}
+			if _, ok := link.Load(ctx, tc.outgoing); ok {
+				t.Error("the link is still cached")
+			}
+		})
+	}
+}
+
+func TestCreatePortMismatch(t *testing.T) {
+	fake := nlhandle.NewFake()
+	fake.AddNamespace(netNSURL)
+	conn := newConn("conn-1", "nsm-1", "10.0.0.1", "10.0.0.2", 42)
+	ToMechanism(conn.GetMechanism()).SetDstPort(DefaultPort + 1)
+
+	if err := Create(metadataContext(t, "conn-1"), fake, conn, true); err == nil {
+		t.Error("got no error for different geneve ports")
+	}
+	handle, err := fake.FromURL(netNSURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	if _, err = handle.LinkByName("nsm-1"); err == nil {
+		t.Error("the link was created")
+	}
+}
+
+func TestCreateExternal(t *testing.T) {
+	fake := nlhandle.NewFake()
+	fake.AddNamespace(netNSURL)
+	options := "0102:03:0a0b0c0d"
+
+	// Two connections to the same remote, the second one incoming, share the external link
+	conns := []*networkservice.Connection{
+		newConn("conn-1", "nsm-1", "10.0.0.1", "10.0.0.2", 42),
+		newConn("conn-2", "nsm-2", "10.0.0.2", "10.0.0.1", 43),
+	}
+	conns[0].GetMechanism().GetParameters()[SrcTLVOptions] = options
+	conns[1].GetMechanism().GetParameters()[DstTLVOptions] = options
+	ctxs := []context.Context{metadataContext(t, "conn-1"), metadataContext(t, "conn-2")}
+	outgoing := []bool{true, false}
+
+	hostHandle, err := fake.Host()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hostHandle.Close()
+	handle, err := fake.FromURL(netNSURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+
+	for i, conn := range conns {
+		if err = Create(ctxs[i], fake, conn, outgoing[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	external, err := hostHandle.LinkByName("nsm-gnv-6081")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if geneveLink, ok := external.(*netlink.Geneve); !ok || !geneveLink.FlowBased || geneveLink.Dport != DefaultPort {
+		t.Errorf("got external link %+v, want a flow based geneve link on port %d", external, DefaultPort)
+	}
+	_, decaps, err := fake.Qdiscs("", "nsm-gnv-6081")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decaps) != 2 {
+		t.Errorf("got %d filters on the external link, want 2", len(decaps))
+	}
+
+	for i, conn := range conns {
+		podLink, err := handle.LinkByName(conn.GetMechanism().GetParameters()[kernel.InterfaceNameKey])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if podLink.Type() != "veth" || podLink.Attrs().MTU != 1400 {
+			t.Errorf("got pod link %+v, want a veth with MTU 1400", podLink)
+		}
+		hostLink, err := hostHandle.LinkByName(vethName(conn.GetId()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		local, remote := net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")
+		vni := ToMechanism(conn.GetMechanism()).VNI()
+
+		_, filters, err := fake.Qdiscs("", hostLink.Attrs().Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(filters) != 1 {
+			t.Fatalf("got %d filters on the forwarder end of the veth pair, want 1", len(filters))
+		}
+		encap, ok := filters[0].(*nlhandle.GeneveEncap)
+		if !ok || encap.Redirect != external.Attrs().Index || encap.ID != vni || !encap.Src.Equal(local) || !encap.Dst.Equal(remote) ||
+			encap.Port != DefaultPort || formatOptions(encap.Options) != options {
+			t.Errorf("got filter %+v, want the frames encapsulated with VNI %d and the options to %s", filters[0], vni, remote)
+		}
+
+		var decap *netlink.Flower
+		for _, filter := range decaps {
+			if filter.Attrs().Handle == uint32(hostLink.Attrs().Index) {
+				decap, _ = filter.(*netlink.Flower)
+			}
+		}
+		if decap == nil || decap.EncKeyId != vni || !decap.EncSrcIP.Equal(remote) || !decap.EncDestIP.Equal(local) || len(decap.Actions) != 2 {
+			t.Fatalf("got filter %+v, want the frames from %s with VNI %d redirected", decap, remote, vni)
+		}
+		if mirred, ok := decap.Actions[1].(*netlink.MirredAction); !ok || mirred.Ifindex != hostLink.Attrs().Index {
+			t.Errorf("got action %+v, want the frames redirected to %s", decap.Actions[1], hostLink.Attrs().Name)
+		}
+
+		// The refresh keeps the veth pair and replaces the filters
+		if err = Create(ctxs[i], fake, conn, outgoing[i]); err != nil {
+			t.Fatal(err)
+		}
+		if refreshed, err := handle.LinkByName(podLink.Attrs().Name); err != nil || refreshed.Attrs().Index != podLink.Attrs().Index {
+			t.Errorf("the veth pair was not kept by the refresh: %v", err)
+		}
+		if _, decaps, _ = fake.Qdiscs("", "nsm-gnv-6081"); len(decaps) != 2 {
+			t.Errorf("got %d filters on the external link after the refresh, want 2", len(decaps))
+		}
+	}
+
+	// The external link is deleted along with the last connection
+	if err = Delete(ctxs[0], fake, conns[0], outgoing[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = hostHandle.LinkByName(vethName("conn-1")); err == nil {
+		t.Error("the veth pair of the closed connection was not deleted")
+	}
+	if _, decaps, err = fake.Qdiscs("", "nsm-gnv-6081"); err != nil || len(decaps) != 1 {
+		t.Errorf("got %d filters on the external link, want 1: %v", len(decaps), err)
+	}
+	if err = Delete(ctxs[1], fake, conns[1], outgoing[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = hostHandle.LinkByName("nsm-gnv-6081"); err == nil {
+		t.Error("the external link was not deleted with the last connection")
+	}
+}
+
+func TestOverhead(t *testing.T) {
+	withOptions := func(m *networkservice.Mechanism, src, dst string) *networkservice.Mechanism {
+		m.GetParameters()[SrcTLVOptions] = src
+		m.GetParameters()[DstTLVOptions] = dst
+		return m
+	}
+	for _, tc := range []struct {
+		name      string
+		mechanism *networkservice.Mechanism
+		want      int
+	}{
+		{name: "IPv4", mechanism: newConn("conn-1", "nsm-1", "10.0.0.1", "10.0.0.2", 42).GetMechanism(), want: 50},
+		{name: "IPv6", mechanism: newConn("conn-1", "nsm-1", "fd00::1", "fd00::2", 42).GetMechanism(), want: 70},
+		{
+			name:      "longest source options",
+			mechanism: withOptions(newConn("conn-1", "nsm-1", "10.0.0.1", "10.0.0.2", 42).GetMechanism(), "0102:03:0a0b0c0d0a0b0c0d", "0102:03:"),
+			want:      62,
+		},
+		{
+			name:      "longest destination options",
+			mechanism: withOptions(newConn("conn-1", "nsm-1", "fd00::1", "fd00::2", 42).GetMechanism(), "", "0102:03:0a0b0c0d"),
+			want:      78,
+		},
+		{name: "other mechanism", mechanism: &networkservice.Mechanism{Type: "VXLAN"}, want: 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Overhead(tc.mechanism); got != tc.want {
+				t.Errorf("got overhead %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
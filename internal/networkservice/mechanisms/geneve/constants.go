@@ -0,0 +1,57 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package geneve implements the GENEVE remote mechanism. It mirrors the vxlan mechanism: the client and server
+// elements negotiate the tunnel endpoints and the VNI through the mechanism parameters, and Create builds the
+// geneve link the xconnect server moves into the pod network namespace.
+//
+// The geneve links send to and listen on the same UDP port, so all the forwarders using the mechanism must be
+// configured with the same port, which defaults to 6081. The links have no local address, the source address of the
+// tunnel is the one of the route to the remote.
+//
+// The forwarders may be configured with TLV options to set in the geneve header of the packets they send, which the
+// kernel only does for the external (flow based) geneve links driven by tc or lwtunnel rules. The options of both
+// ends are exchanged through the mechanism parameters, so that both of them subtract the longest from the MTU.
+// A forwarder with options connects the pods with a veth pair instead of a geneve link of their own: tc filters on
+// the forwarder end of the pair encapsulate the frames with the options and redirect them to the external link of
+// the forwarder, and tc filters on the external link redirect the frames received from the remote back to the pair.
+// The kernel allows a single external link per port, and no other geneve link on that port, so all the
+// connections of a forwarder with options use it; changing the options of a forwarder requires its connections to
+// be closed first.
+package geneve
+
+const (
+	// MECHANISM string
+	MECHANISM = "GENEVE"
+	// DefaultPort is the IANA assigned geneve port
+	DefaultPort = 6081
+	// SrcTLVOptions is the mechanism parameter holding the TLV options of the packets sent by the source forwarder,
+	// in the format of ParseOptions
+	SrcTLVOptions = "src_geneve_options"
+	// DstTLVOptions is the mechanism parameter holding the TLV options of the packets sent by the destination
+	// forwarder
+	DstTLVOptions = "dst_geneve_options"
+
+	// The VNIs are 24 bit long
+	vniBits = 24
+
+	// The encapsulation overhead (outer Ethernet, IP, UDP and Geneve headers without options) subtracted from the
+	// underlay MTU, per underlay address family
+	overheadIPv4 = 50
+	overheadIPv6 = 70
+)
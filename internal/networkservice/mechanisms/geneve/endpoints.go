@@ -0,0 +1,108 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package geneve
+
+import (
+	"context"
+	"net"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+// The client sets the source tunnel endpoint and TLV options and the server sets the destination ones, before the
+// VNI is allocated by tunnelkey.
+
+type endpointClient struct {
+	tunnelIP   net.IP
+	tunnelPort uint16
+	options    []nlhandle.GeneveOption
+}
+
+func newEndpointClient(tunnelIP net.IP, tunnelPort uint16, options []nlhandle.GeneveOption) networkservice.NetworkServiceClient {
+	return &endpointClient{
+		tunnelIP:   tunnelIP,
+		tunnelPort: tunnelPort,
+		options:    options,
+	}
+}
+
+func (e *endpointClient) Request(ctx context.Context, request *networkservice.NetworkServiceRequest, opts ...grpc.CallOption) (*networkservice.Connection, error) {
+	for _, m := range request.GetMechanismPreferences() {
+		if mech := ToMechanism(m); mech != nil {
+			mech.SetSrcIP(e.tunnelIP)
+			mech.SetSrcPort(e.tunnelPort)
+			mech.SetSrcOptions(e.options)
+
+			log.FromContext(ctx).
+				WithField("geneveEndpointClient", "request").
+				WithField("mechSrcIp", mech.SrcIP()).
+				WithField("mechSrcPort", mech.SrcPort()).
+				Debugf("set mechanism src")
+		}
+	}
+	// The mechanism of a refreshed connection is the one selected by the first Request, keep its options current
+	if mech := ToMechanism(request.GetConnection().GetMechanism()); mech != nil {
+		mech.SetSrcOptions(e.options)
+	}
+	return next.Client(ctx).Request(ctx, request, opts...)
+}
+
+func (e *endpointClient) Close(ctx context.Context, conn *networkservice.Connection, opts ...grpc.CallOption) (*empty.Empty, error) {
+	return next.Client(ctx).Close(ctx, conn, opts...)
+}
+
+type endpointServer struct {
+	tunnelIP   net.IP
+	tunnelPort uint16
+	options    []nlhandle.GeneveOption
+}
+
+func newEndpointServer(tunnelIP net.IP, tunnelPort uint16, options []nlhandle.GeneveOption) networkservice.NetworkServiceServer {
+	return &endpointServer{
+		tunnelIP:   tunnelIP,
+		tunnelPort: tunnelPort,
+		options:    options,
+	}
+}
+
+func (e *endpointServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if mechanism := ToMechanism(request.GetConnection().GetMechanism()); mechanism != nil {
+		mechanism.SetDstIP(e.tunnelIP)
+		mechanism.SetDstPort(e.tunnelPort)
+		mechanism.SetDstOptions(e.options)
+
+		log.FromContext(ctx).
+			WithField("geneveEndpointServer", "request").
+			WithField("mechanism.DstIP", mechanism.DstIP()).
+			WithField("mechanism.DstPort", mechanism.DstPort()).
+			Debugf("set mechanism dst")
+	}
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (e *endpointServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
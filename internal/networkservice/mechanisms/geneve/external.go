@@ -0,0 +1,322 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package geneve
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	// The filters of the connections are the only ones on the ingress of the forwarder ends of the veth pairs and
+	// of the external link, those of the external link are told apart by their handle
+	filterPriority = 1
+)
+
+// ingressHandle is the handle of the ingress qdiscs the filters are attached to
+var ingressHandle = netlink.MakeHandle(0xffff, 0)
+
+// externalMu serializes the changes to the external links, which are shared by all the connections
+var externalMu sync.Mutex
+
+// tunnel is the geneve tunnel of a connection, as seen from the forwarder
+type tunnel struct {
+	local   net.IP
+	remote  net.IP
+	port    uint16
+	vni     uint32
+	options []nlhandle.GeneveOption
+}
+
+// externalName returns the name of the external geneve link listening on port
+func externalName(port uint16) string {
+	return fmt.Sprintf("nsm-gnv-%d", port)
+}
+
+// vethName returns the name of the forwarder end of the veth pair of the connection
+func vethName(connID string) string {
+	return linuxIfaceName("gnv-" + connID)
+}
+
+// createExternal connects the pod to the remote through the external geneve link, with a veth pair whose forwarder
+// end is left in the host network namespace. The link and the filters are created if missing, and the filters
+// replaced otherwise, so that they follow the changes of the remote and of the options.
+func createExternal(ctx context.Context, handle, hostHandle nlhandle.Handle, conn *networkservice.Connection, outgoing bool, ifaceName, netNsURL string, t *tunnel) error {
+	hostName := vethName(conn.GetId())
+
+	podLink, podErr := handle.LinkByName(ifaceName)
+	hostLink, hostErr := hostHandle.LinkByName(hostName)
+	_, stored := link.Load(ctx, outgoing)
+	switch {
+	case podErr == nil && hostErr == nil && stored:
+		// A refresh, only the filters are replaced
+	case podErr == nil && hostErr == nil && link.Owned(ctx, podLink, netNsURL):
+		// After a restart, take over the veth pair created for the connection by the previous forwarder instance
+		link.Adopt(ctx, outgoing, podLink)
+	default:
+		var err error
+		if hostLink, err = createVeth(ctx, handle, hostHandle, conn, outgoing, ifaceName, netNsURL, hostName); err != nil {
+			return err
+		}
+	}
+
+	externalMu.Lock()
+	defer externalMu.Unlock()
+
+	external, err := externalLink(ctx, hostHandle, t.port)
+	if err != nil {
+		return err
+	}
+
+	if err = hostHandle.QdiscReplace(newIngress(hostLink)); err != nil {
+		return errors.Wrapf(err, "failed to add the ingress qdisc to %s", hostName)
+	}
+	if err = hostHandle.FilterReplace(&nlhandle.GeneveEncap{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: hostLink.Attrs().Index,
+			Parent:    ingressHandle,
+			Priority:  filterPriority,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		Redirect: external.Attrs().Index,
+		ID:       t.vni,
+		Src:      t.local,
+		Dst:      t.remote,
+		Port:     t.port,
+		Options:  t.options,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to encapsulate the frames sent on %s", hostName)
+	}
+
+	// Replace the filter of the previous veth pair of the connection, if it was created again
+	decaps, err := decapFilters(hostHandle, external, t.remote, t.vni)
+	if err != nil {
+		return err
+	}
+	for _, decap := range decaps {
+		if decap.Attrs().Handle == uint32(hostLink.Attrs().Index) {
+			continue
+		}
+		if err = hostHandle.FilterDel(decap); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err = hostHandle.FilterReplace(&netlink.Flower{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: external.Attrs().Index,
+			Parent:    ingressHandle,
+			Priority:  filterPriority,
+			Handle:    uint32(hostLink.Attrs().Index),
+			Protocol:  unix.ETH_P_ALL,
+		},
+		EncSrcIP:    t.remote,
+		EncDestIP:   t.local,
+		EncDestPort: t.port,
+		EncKeyId:    t.vni,
+		Actions: []netlink.Action{
+			&netlink.TunnelKeyAction{
+				ActionAttrs: netlink.ActionAttrs{Action: netlink.TC_ACT_PIPE},
+				Action:      netlink.TCA_TUNNEL_KEY_UNSET,
+			},
+			netlink.NewMirredAction(hostLink.Attrs().Index),
+		},
+	}); err != nil {
+		return errors.Wrapf(err, "failed to redirect the frames received from %s to %s", t.remote, hostName)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", hostName).
+		WithField("external", external.Attrs().Name).
+		WithField("netlink", "FilterReplace").Debug("completed")
+	return nil
+}
+
+// createVeth creates the veth pair of the connection, deleting the stale links with the same names, and moves its
+// pod end to the target network namespace
+func createVeth(ctx context.Context, handle, hostHandle nlhandle.Handle, conn *networkservice.Connection, outgoing bool, ifaceName, netNsURL, hostName string) (netlink.Link, error) {
+	for _, stale := range []struct {
+		handle nlhandle.Handle
+		name   string
+	}{{handle, ifaceName}, {hostHandle, hostName}} {
+		prevLink, err := stale.handle.LinkByName(stale.name)
+		if err != nil {
+			continue
+		}
+		if err = stale.handle.LinkDel(prevLink); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		metrics.StaleLinkDeletions.Inc("geneve")
+		log.FromContext(ctx).
+			WithField("link.Name", stale.name).
+			WithField("netlink", "LinkDel").Debug("completed")
+	}
+
+	// Only the pod end is marked as created by the forwarder, the forwarder end goes away with it
+	podName := linuxIfaceName(conn.GetId())
+	if err := hostHandle.LinkAdd(&netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{
+			Name:  podName,
+			Group: link.Group,
+			MTU:   int(conn.GetContext().GetMTU()),
+		},
+		PeerName: hostName,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the GENEVE veth pair")
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", podName).
+		WithField("link.PeerName", hostName).
+		WithField("netlink", "LinkAdd veth").Debug("completed")
+
+	hostLink, err := hostHandle.LinkByName(hostName)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = hostHandle.LinkSetUp(hostLink); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	l, err := hostHandle.LinkByName(podName)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = hostHandle.LinkSetNs(l, netNsURL); err != nil {
+		return nil, errors.Wrapf(err, "unable to change to netns")
+	}
+	if l, err = handle.LinkByName(podName); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = handle.LinkSetName(l, ifaceName); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if linkAlias := link.Alias(conn.GetLabels()["podName"]); linkAlias != "" {
+		if err = handle.LinkSetAlias(l, linkAlias); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	if err = handle.LinkSetUp(l); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", ifaceName).
+		WithField("netlink", "LinkSetUp").Debug("completed")
+
+	link.Store(ctx, outgoing, l)
+	return hostLink, nil
+}
+
+// externalLink returns the external geneve link listening on port, creating it if missing. Like the forwarder ends
+// of the veth pairs, it is not marked as created by the forwarder: it is shared by the connections, which may survive
+// a restart.
+func externalLink(ctx context.Context, hostHandle nlhandle.Handle, port uint16) (netlink.Link, error) {
+	name := externalName(port)
+	if l, err := hostHandle.LinkByName(name); err == nil {
+		return l, nil
+	}
+	if err := hostHandle.LinkAdd(&netlink.Geneve{
+		LinkAttrs: netlink.LinkAttrs{Name: name},
+		FlowBased: true,
+		Dport:     port,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the external GENEVE interface")
+	}
+	l, err := hostHandle.LinkByName(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = hostHandle.LinkSetUp(l); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = hostHandle.QdiscReplace(newIngress(l)); err != nil {
+		return nil, errors.Wrapf(err, "failed to add the ingress qdisc to %s", name)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", name).
+		WithField("netlink", "LinkAdd geneve external").Debug("completed")
+	return l, nil
+}
+
+// deleteExternal deletes the filter redirecting the frames of the tunnel to the remote received by the external link,
+// and the external link itself along with its last filter
+func deleteExternal(ctx context.Context, hostHandle nlhandle.Handle, remote net.IP, port uint16, vni uint32) error {
+	externalMu.Lock()
+	defer externalMu.Unlock()
+
+	external, err := hostHandle.LinkByName(externalName(port))
+	if err != nil {
+		return nil
+	}
+	decaps, err := decapFilters(hostHandle, external, remote, vni)
+	if err != nil {
+		return err
+	}
+	for _, decap := range decaps {
+		if err = hostHandle.FilterDel(decap); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	filters, err := hostHandle.FilterList(external, ingressHandle)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if len(filters) > 0 {
+		return nil
+	}
+	if err = hostHandle.LinkDel(external); err != nil {
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", external.Attrs().Name).
+		WithField("netlink", "LinkDel").Info("completed")
+	return nil
+}
+
+// decapFilters returns the filters of the external link redirecting the frames of the tunnel to the remote
+func decapFilters(hostHandle nlhandle.Handle, external netlink.Link, remote net.IP, vni uint32) ([]netlink.Filter, error) {
+	filters, err := hostHandle.FilterList(external, ingressHandle)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var rv []netlink.Filter
+	for _, filter := range filters {
+		if flower, ok := filter.(*netlink.Flower); ok && flower.EncKeyId == vni && flower.EncSrcIP.Equal(remote) {
+			rv = append(rv, filter)
+		}
+	}
+	return rv, nil
+}
+
+func newIngress(l netlink.Link) *netlink.Ingress {
+	return &netlink.Ingress{QdiscAttrs: netlink.QdiscAttrs{
+		LinkIndex: l.Attrs().Index,
+		Handle:    ingressHandle,
+		Parent:    netlink.HANDLE_INGRESS,
+	}}
+}
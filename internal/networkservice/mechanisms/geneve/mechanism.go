@@ -0,0 +1,83 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package geneve
+
+import (
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+// Mechanism - helper for the GENEVE mechanism parameters. The parameters are the same as the ones of the VXLAN
+// mechanism (src/dst IP and port, vni), so the vxlan helpers are reused for them.
+type Mechanism struct {
+	*vxlan.Mechanism
+}
+
+// ToMechanism - convert unified mechanism to helper type
+func ToMechanism(m *networkservice.Mechanism) *Mechanism {
+	if m.GetType() == MECHANISM {
+		if m.Parameters == nil {
+			m.Parameters = map[string]string{}
+		}
+		return &Mechanism{
+			Mechanism: &vxlan.Mechanism{Mechanism: m},
+		}
+	}
+	return nil
+}
+
+// Key returns the VNI of the tunnel, it is the key allocated by tunnelkey
+func (m *Mechanism) Key() uint32 {
+	return m.VNI()
+}
+
+// SetKey sets the VNI of the tunnel
+func (m *Mechanism) SetKey(key uint32) {
+	m.SetVNI(key)
+}
+
+// SrcOptions returns the TLV options of the packets sent by the source forwarder
+func (m *Mechanism) SrcOptions() ([]nlhandle.GeneveOption, error) {
+	return ParseOptions(m.GetParameters()[SrcTLVOptions])
+}
+
+// SetSrcOptions sets the TLV options of the packets sent by the source forwarder
+func (m *Mechanism) SetSrcOptions(options []nlhandle.GeneveOption) {
+	m.setOptions(SrcTLVOptions, options)
+}
+
+// DstOptions returns the TLV options of the packets sent by the destination forwarder
+func (m *Mechanism) DstOptions() ([]nlhandle.GeneveOption, error) {
+	return ParseOptions(m.GetParameters()[DstTLVOptions])
+}
+
+// SetDstOptions sets the TLV options of the packets sent by the destination forwarder
+func (m *Mechanism) SetDstOptions(options []nlhandle.GeneveOption) {
+	m.setOptions(DstTLVOptions, options)
+}
+
+func (m *Mechanism) setOptions(key string, options []nlhandle.GeneveOption) {
+	if len(options) == 0 {
+		delete(m.GetParameters(), key)
+		return
+	}
+	m.GetParameters()[key] = formatOptions(options)
+}
@@ -0,0 +1,43 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package geneve
+
+import (
+	"net"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/tunnelkey"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+// NewServer - returns a new server for the geneve remote mechanism, whose links listen on tunnelPort and send their
+// packets with the TLV options
+func NewServer(tunnelIP net.IP, tunnelPort uint16, options ...nlhandle.GeneveOption) networkservice.NetworkServiceServer {
+	return chain.NewNetworkServiceServer(
+		newEndpointServer(tunnelIP, tunnelPort, options),
+		tunnelkey.NewServer(vniBits, func(m *networkservice.Mechanism) tunnelkey.Mechanism {
+			if mechanism := ToMechanism(m); mechanism != nil {
+				return mechanism
+			}
+			return nil
+		}),
+	)
+}
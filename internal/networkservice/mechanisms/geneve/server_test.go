@@ -0,0 +1,126 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package geneve
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+func newRequest(connID string, srcIP net.IP) *networkservice.NetworkServiceRequest {
+	mechanism := &networkservice.Mechanism{Cls: cls.REMOTE, Type: MECHANISM, Parameters: map[string]string{}}
+	ToMechanism(mechanism).SetSrcIP(srcIP).SetSrcPort(DefaultPort)
+	return &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{Id: connID, Mechanism: mechanism},
+	}
+}
+
+func TestServerVNI(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		srcIP, dstIP string
+		wantOdd      bool
+	}{
+		{name: "from the lower tunnel IP", srcIP: "10.0.0.1", dstIP: "10.0.0.2"},
+		{name: "from the higher tunnel IP", srcIP: "10.0.0.2", dstIP: "10.0.0.1", wantOdd: true},
+		{name: "IPv6 from the lower tunnel IP", srcIP: "fd00::1", dstIP: "fd00::2"},
+		{name: "IPv6 from the higher tunnel IP", srcIP: "fd00::2", dstIP: "fd00::1", wantOdd: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := chain.NewNetworkServiceServer(metadata.NewServer(), NewServer(net.ParseIP(tc.dstIP), DefaultPort))
+
+			vnis := make(map[uint32]struct{})
+			for i := 0; i < 16; i++ {
+				conn, err := server.Request(context.Background(), newRequest(fmt.Sprintf("conn-%d", i), net.ParseIP(tc.srcIP)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				mechanism := ToMechanism(conn.GetMechanism())
+				if !mechanism.DstIP().Equal(net.ParseIP(tc.dstIP)) || mechanism.DstPort() != DefaultPort {
+					t.Errorf("got destination %s:%d, want %s:%d", mechanism.DstIP(), mechanism.DstPort(), tc.dstIP, DefaultPort)
+				}
+				vni := mechanism.VNI()
+				if vni == 0 || vni >= 1<<vniBits {
+					t.Fatalf("got VNI %d, want one of %d bits", vni, vniBits)
+				}
+				if odd := vni%2 == 1; odd != tc.wantOdd {
+					t.Errorf("got VNI %d, want an odd one: %v", vni, tc.wantOdd)
+				}
+				if _, ok := vnis[vni]; ok {
+					t.Errorf("VNI %d allocated twice", vni)
+				}
+				vnis[vni] = struct{}{}
+
+				// The refresh of the connection keeps its VNI
+				refreshed, err := server.Request(context.Background(), &networkservice.NetworkServiceRequest{Connection: conn})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := ToMechanism(refreshed.GetMechanism()).VNI(); got != vni {
+					t.Errorf("got VNI %d after the refresh, want %d", got, vni)
+				}
+			}
+		})
+	}
+}
+
+func TestEndpointOptions(t *testing.T) {
+	options := []nlhandle.GeneveOption{{Class: 0x0102, Type: 0x03, Data: []byte{1, 2, 3, 4}}}
+
+	server := chain.NewNetworkServiceServer(metadata.NewServer(), NewServer(net.ParseIP("10.0.0.2"), DefaultPort, options...))
+	conn, err := server.Request(context.Background(), newRequest("conn-1", net.ParseIP("10.0.0.1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conn.GetMechanism().GetParameters()[DstTLVOptions]; got != "0102:03:01020304" {
+		t.Errorf("got destination options %q, want 0102:03:01020304", got)
+	}
+
+	// The client sets its options on the preferences and on the mechanism of the refreshed connections, and clears
+	// them once it has none
+	request := &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{Id: "conn-1", Mechanism: conn.GetMechanism()},
+		MechanismPreferences: []*networkservice.Mechanism{
+			{Cls: cls.REMOTE, Type: MECHANISM, Parameters: map[string]string{}},
+		},
+	}
+	if _, err = newEndpointClient(net.ParseIP("10.0.0.1"), DefaultPort, options).Request(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []*networkservice.Mechanism{request.GetMechanismPreferences()[0], request.GetConnection().GetMechanism()} {
+		if got := m.GetParameters()[SrcTLVOptions]; got != "0102:03:01020304" {
+			t.Errorf("got source options %q, want 0102:03:01020304", got)
+		}
+	}
+	if _, err = newEndpointClient(net.ParseIP("10.0.0.1"), DefaultPort, nil).Request(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := request.GetConnection().GetMechanism().GetParameters()[SrcTLVOptions]; ok {
+		t.Errorf("got source options %q, want none", got)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package geneve
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	// The geneve header has room for 252 bytes of options, each of them with 4 bytes of class, type and length and
+	// up to 124 bytes of data
+	maxOptionsLen   = 252
+	maxOptionData   = 124
+	optionHeaderLen = 4
+	// The options whose type has the high bit set are critical, the receivers not knowing them drop the packets
+	criticalType = 0x80
+)
+
+// ParseOptions parses the TLV options set in the geneve header of the packets the forwarder sends. They are comma
+// separated CLASS:TYPE:DATA triplets of hexadecimal numbers, like the geneve_opts of the tc tunnel_key action.
+// The critical options are rejected: the forwarders without options would drop the packets carrying them.
+func ParseOptions(s string) ([]nlhandle.GeneveOption, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var rv []nlhandle.GeneveOption
+	length := 0
+	for _, field := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(field), ":")
+		if len(parts) != 3 {
+			return nil, errors.Errorf("invalid geneve option %q, expected CLASS:TYPE:DATA", field)
+		}
+		class, err := strconv.ParseUint(parts[0], 16, 16)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid class of geneve option %q", field)
+		}
+		optType, err := strconv.ParseUint(parts[1], 16, 8)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid type of geneve option %q", field)
+		}
+		if optType&criticalType != 0 {
+			return nil, errors.Errorf("geneve option %q is critical", field)
+		}
+		data, err := hex.DecodeString(parts[2])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid data of geneve option %q", field)
+		}
+		if len(data)%4 != 0 || len(data) > maxOptionData {
+			return nil, errors.Errorf("data of geneve option %q must be a multiple of 4 bytes up to %d bytes long", field, maxOptionData)
+		}
+		length += optionHeaderLen + len(data)
+		rv = append(rv, nlhandle.GeneveOption{Class: uint16(class), Type: uint8(optType), Data: data})
+	}
+	if length > maxOptionsLen {
+		return nil, errors.Errorf("geneve options %q are %d bytes long, more than %d", s, length, maxOptionsLen)
+	}
+	return rv, nil
+}
+
+// formatOptions returns the options in the format of ParseOptions
+func formatOptions(options []nlhandle.GeneveOption) string {
+	fields := make([]string, 0, len(options))
+	for _, opt := range options {
+		fields = append(fields, fmt.Sprintf("%04x:%02x:%x", opt.Class, opt.Type, opt.Data))
+	}
+	return strings.Join(fields, ",")
+}
+
+// optionsLen returns the length of the options in the geneve header
+func optionsLen(options []nlhandle.GeneveOption) int {
+	length := 0
+	for _, opt := range options {
+		length += optionHeaderLen + len(opt.Data)
+	}
+	return length
+}
@@ -0,0 +1,69 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package geneve
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		s       string
+		wantLen int
+		wantErr bool
+	}{
+		{name: "none", s: ""},
+		{name: "one option", s: "0102:03:0a0b0c0d", wantLen: 8},
+		{name: "two options", s: "0102:03:0a0b0c0d, ffff:7f:0a0b0c0d0e0f1011", wantLen: 20},
+		{name: "option without data", s: "0102:03:", wantLen: 4},
+		{name: "longest data", s: "0102:03:" + string(bytes.Repeat([]byte("00"), 124)), wantLen: 128},
+		{name: "missing data", s: "0102:03", wantErr: true},
+		{name: "class out of range", s: "10000:03:0a0b0c0d", wantErr: true},
+		{name: "type out of range", s: "0102:100:0a0b0c0d", wantErr: true},
+		{name: "critical type", s: "0102:83:0a0b0c0d", wantErr: true},
+		{name: "invalid data", s: "0102:03:0a0b0c0x", wantErr: true},
+		{name: "data not a multiple of 4 bytes", s: "0102:03:0a0b0c", wantErr: true},
+		{name: "data too long", s: "0102:03:" + string(bytes.Repeat([]byte("00"), 128)), wantErr: true},
+		{
+			name:    "options too long",
+			s:       "0102:03:" + string(bytes.Repeat([]byte("00"), 124)) + ",0102:04:" + string(bytes.Repeat([]byte("00"), 124)),
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			options, err := ParseOptions(tc.s)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want one: %v", err, tc.wantErr)
+			}
+			if got := optionsLen(options); got != tc.wantLen {
+				t.Errorf("got options of %d bytes, want %d", got, tc.wantLen)
+			}
+			// The options are exchanged in the format they are parsed from
+			reparsed, err := ParseOptions(formatOptions(options))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if formatOptions(reparsed) != formatOptions(options) {
+				t.Errorf("got %q once formatted and parsed again, want %q", formatOptions(reparsed), formatOptions(options))
+			}
+		})
+	}
+}
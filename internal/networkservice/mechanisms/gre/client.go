@@ -0,0 +1,73 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package gre
+
+import (
+	"context"
+	"net"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/payload"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+)
+
+type greClient struct {
+	mechanismType string
+	payload       string
+}
+
+// NewClient - returns a new client for the gre remote mechanism
+func NewClient(tunnelIP net.IP) networkservice.NetworkServiceClient {
+	return chain.NewNetworkServiceClient(
+		&greClient{mechanismType: MECHANISM, payload: payload.IP},
+		newEndpointClient(tunnelIP),
+	)
+}
+
+// NewTapClient - returns a new client for the gretap remote mechanism
+func NewTapClient(tunnelIP net.IP) networkservice.NetworkServiceClient {
+	return chain.NewNetworkServiceClient(
+		&greClient{mechanismType: TAPMECHANISM, payload: payload.Ethernet},
+		newEndpointClient(tunnelIP),
+	)
+}
+
+func (g *greClient) Request(ctx context.Context, request *networkservice.NetworkServiceRequest, opts ...grpc.CallOption) (*networkservice.Connection, error) {
+	if request.GetConnection().GetPayload() != g.payload {
+		return next.Client(ctx).Request(ctx, request, opts...)
+	}
+
+	mechanism := &networkservice.Mechanism{
+		Cls:        cls.REMOTE,
+		Type:       g.mechanismType,
+		Parameters: make(map[string]string),
+	}
+	request.MechanismPreferences = append(request.MechanismPreferences, mechanism)
+
+	return next.Client(ctx).Request(ctx, request, opts...)
+}
+
+func (g *greClient) Close(ctx context.Context, conn *networkservice.Connection, opts ...grpc.CallOption) (*empty.Empty, error) {
+	return next.Client(ctx).Close(ctx, conn, opts...)
+}
@@ -0,0 +1,302 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package gre
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+// Create creates the gre or gretap link for the mechanism of the conn and moves it to the target network namespace.
+// All netlink operations are performed through the handles returned by nl.
+func Create(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, outgoing bool) error {
+	logger := log.FromContext(ctx).WithField("gre", "Intf create")
+	mechanism := ToMechanism(conn.GetMechanism())
+	if mechanism == nil {
+		return nil
+	}
+	if mechanism.SrcIP() == nil {
+		return errors.Errorf("gre SrcIP not provided")
+	}
+	if mechanism.DstIP() == nil {
+		return errors.Errorf("gre DstIP not provided")
+	}
+	if mechanism.Key() == 0 {
+		return errors.Errorf("gre key not provided")
+	}
+	ifaceName := mechanism.GetParameters()[kernel.InterfaceNameKey]
+	if ifaceName == "" {
+		return errors.Errorf("gre interface name not provided")
+	}
+	netNsURL := mechanism.GetParameters()[kernel.NetNSURL]
+	if netNsURL == "" {
+		return errors.Errorf("gre inode URL not provided")
+	}
+
+	// The remote end of the tunnel is the source of the mechanism for incoming connections and its destination
+	// for the outgoing ones, see vxlan.Create.
+	localIP, remoteIP := mechanism.DstIP(), mechanism.SrcIP()
+	if outgoing {
+		localIP, remoteIP = mechanism.SrcIP(), mechanism.DstIP()
+	}
+	if (localIP.To4() == nil) != (remoteIP.To4() == nil) {
+		return errors.Errorf("gre local IP %s and remote IP %s are of different address families", localIP, remoteIP)
+	}
+	key := mechanism.Key()
+
+	logger.Infof("netnsurl: %v: iface: %v: localIP: %s: remoteIP: %s: key: %v: tap: %v", netNsURL, ifaceName, localIP.String(), remoteIP.String(), key, mechanism.IsTap())
+
+	handle, err := nl.FromURL(netNsURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Close()
+
+	hostHandle, err := nl.Host()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer hostHandle.Close()
+
+	// Treat the Request as redundant if the link created by the forwarder is still present in the target namespace
+	if _, ok := link.Load(ctx, outgoing); ok {
+		if _, err = handle.LinkByName(ifaceName); err == nil {
+			return nil
+		}
+	}
+
+	// After a restart, take over the link created for the connection by the previous forwarder instance if it still
+	// tunnels to the same remote with the same key, so that the traffic is not interrupted
+	if prevLink, err := handle.LinkByName(ifaceName); err == nil && adoptable(ctx, prevLink, netNsURL, mechanism.IsTap(), localIP, remoteIP, key) {
+		link.Adopt(ctx, outgoing, prevLink)
+		return nil
+	}
+
+	// The forwarder is not aware of any other interface with that name in the target namespace, it is a stale one
+	if prevLink, err := handle.LinkByName(ifaceName); err == nil {
+		if err = handle.LinkDel(prevLink); err != nil {
+			return errors.WithStack(err)
+		}
+		metrics.StaleLinkDeletions.Inc(strings.ToLower(mechanism.GetType()))
+		log.FromContext(ctx).
+			WithField("link.Name", prevLink.Attrs().Name).
+			WithField("netlink", "LinkDel").Debug("completed")
+	}
+
+	// Create the gre link in the host network namespace, so that it encapsulates through the underlay of the node,
+	// and move it to the target namespace afterwards.
+	fwdNsIfaceName := linuxIfaceName(conn.GetId())
+	var greLink netlink.Link = newGretun(fwdNsIfaceName, localIP, remoteIP, key, int(conn.GetContext().GetMTU()))
+	if mechanism.IsTap() {
+		greLink = newGretap(fwdNsIfaceName, localIP, remoteIP, key, int(conn.GetContext().GetMTU()))
+	}
+	if err = hostHandle.LinkAdd(greLink); err != nil {
+		return errors.Wrapf(err, "failed to create %s interface", mechanism.GetType())
+	}
+	log.FromContext(ctx).WithField("link.Name", fwdNsIfaceName).WithField("netlink", "LinkAdd "+greLink.Type()).Debug("completed")
+
+	l, err := hostHandle.LinkByName(fwdNsIfaceName)
+	if err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", fwdNsIfaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkByName").Debug("error")
+		return errors.WithStack(err)
+	}
+
+	if err = hostHandle.LinkSetNs(l, netNsURL); err != nil {
+		return errors.Wrapf(err, "unable to change to netns")
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "LinkSetNsFd").Debug("completed")
+
+	if l, err = handle.LinkByName(fwdNsIfaceName); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err = handle.LinkSetName(l, ifaceName); err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("link.NewName", ifaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkSetName").Debug("error")
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("link.NewName", ifaceName).
+		WithField("netlink", "LinkSetName").Debug("completed")
+
+	// Set link alias to the name of the network service client pod requesting for this link.
+	if linkAlias := link.Alias(conn.GetLabels()["podName"]); linkAlias != "" {
+		if err = handle.LinkSetAlias(l, linkAlias); err != nil {
+			return errors.WithStack(err)
+		}
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("alias", linkAlias).
+			WithField("netlink", "LinkSetAlias").Debug("completed")
+	}
+
+	if err = handle.LinkSetUp(l); err != nil {
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "LinkSetUp").Debug("completed")
+
+	link.Store(ctx, outgoing, l)
+
+	return nil
+}
+
+// Delete deletes the gre or gretap link for the mechanism of the conn from the target network namespace
+func Delete(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, outgoing bool) error {
+	mechanism := ToMechanism(conn.GetMechanism())
+	if mechanism == nil {
+		return nil
+	}
+	ifaceName := mechanism.GetParameters()[kernel.InterfaceNameKey]
+	if ifaceName == "" {
+		return errors.Errorf("gre interface name not provided")
+	}
+	netNsURL := mechanism.GetParameters()[kernel.NetNSURL]
+	if netNsURL == "" {
+		return errors.Errorf("gre inode URL not provided")
+	}
+
+	handle, err := nl.FromURL(netNsURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Close()
+
+	l, err := handle.LinkByName(ifaceName)
+	if err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", ifaceName).
+			WithField("netlink", "LinkByName").Debug("NotFound")
+		link.Delete(ctx, outgoing)
+		return nil
+	}
+
+	if err = handle.LinkDel(l); err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", ifaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkDel").Debug("error")
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", ifaceName).
+		WithField("netlink", "LinkDel").Info("completed")
+	link.Delete(ctx, outgoing)
+
+	return nil
+}
+
+func linuxIfaceName(ifaceName string) string {
+	if len(ifaceName) <= kernel.LinuxIfMaxLength {
+		return ifaceName
+	}
+	return ifaceName[:kernel.LinuxIfMaxLength]
+}
+
+// Overhead returns the encapsulation overhead of the gre or gretap links for the mechanism, which depends on the
+// address family of the underlay. It is 0 for other mechanism types.
+func Overhead(m *networkservice.Mechanism) int {
+	mechanism := ToMechanism(m)
+	if mechanism == nil {
+		return 0
+	}
+	switch ipv4 := mechanism.SrcIP().To4() != nil; {
+	case mechanism.IsTap() && ipv4:
+		return overheadTapIPv4
+	case mechanism.IsTap():
+		return overheadTapIPv6
+	case ipv4:
+		return overheadIPv4
+	default:
+		return overheadIPv6
+	}
+}
+
+// adoptable reports whether l is the gre link, or the gretap one if tap is set, created by the forwarder for the
+// connection between localIP and remoteIP with the given key
+func adoptable(ctx context.Context, l netlink.Link, netNSURL string, tap bool, localIP, remoteIP net.IP, key uint32) bool {
+	if !link.Owned(ctx, l, netNSURL) {
+		return false
+	}
+	switch greLink := l.(type) {
+	case *netlink.Gretun:
+		return !tap && greLink.IKey == key && greLink.OKey == key &&
+			greLink.Local.Equal(localIP) && greLink.Remote.Equal(remoteIP)
+	case *netlink.Gretap:
+		return tap && greLink.IKey == key && greLink.OKey == key &&
+			greLink.Local.Equal(localIP) && greLink.Remote.Equal(remoteIP)
+	default:
+		return false
+	}
+}
+
+// newGretun returns the gre link to create, an ip6gre one for an IPv6 underlay. The key is used in both directions.
+// A zero mtu leaves it to the kernel.
+func newGretun(ifaceName string, localIP, remoteIP net.IP, key uint32, mtu int) *netlink.Gretun {
+	return &netlink.Gretun{
+		LinkAttrs: netlink.LinkAttrs{
+			Name:  ifaceName,
+			Group: link.Group,
+			MTU:   mtu,
+		},
+		Local:  localIP,
+		Remote: remoteIP,
+		IKey:   key,
+		OKey:   key,
+		// netlink always sends the attribute, keep the default of iproute2
+		PMtuDisc: 1,
+	}
+}
+
+// newGretap returns the gretap link to create, an ip6gretap one for an IPv6 underlay, see newGretun
+func newGretap(ifaceName string, localIP, remoteIP net.IP, key uint32, mtu int) *netlink.Gretap {
+	return &netlink.Gretap{
+		LinkAttrs: netlink.LinkAttrs{
+			Name:  ifaceName,
+			Group: link.Group,
+			MTU:   mtu,
+		},
+		Local:    localIP,
+		Remote:   remoteIP,
+		IKey:     key,
+		OKey:     key,
+		PMtuDisc: 1,
+	}
+}
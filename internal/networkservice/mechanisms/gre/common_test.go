@@ -0,0 +1,192 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package gre
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	connID   = "conn-1"
+	netNSURL = "inode://4/1001"
+)
+
+type ctxServer struct {
+	ctx context.Context
+}
+
+func (s *ctxServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	s.ctx = ctx
+	return request.GetConnection(), nil
+}
+
+func (s *ctxServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+// metadataContext returns a context carrying the per connection metadata of connID, as the elements following the
+// metadata server in a chain get it
+func metadataContext(t *testing.T) context.Context {
+	t.Helper()
+	s := &ctxServer{}
+	request := &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: connID}}
+	if _, err := chain.NewNetworkServiceServer(metadata.NewServer(), s).Request(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	return s.ctx
+}
+
+func newMechanism(mechanismType, srcIP, dstIP string, key uint32) *networkservice.Mechanism {
+	m := &networkservice.Mechanism{
+		Cls:  cls.REMOTE,
+		Type: mechanismType,
+		Parameters: map[string]string{
+			kernel.InterfaceNameKey: "nsm-1",
+			kernel.NetNSURL:         netNSURL,
+		},
+	}
+	mechanism := ToMechanism(m).SetSrcIP(net.ParseIP(srcIP)).SetDstIP(net.ParseIP(dstIP))
+	mechanism.SetKey(key)
+	return m
+}
+
+// tunnels reports whether l is a gre or gretap link from localIP to remoteIP with the key in both directions
+func tunnels(l netlink.Link, localIP, remoteIP net.IP, key uint32) bool {
+	switch greLink := l.(type) {
+	case *netlink.Gretun:
+		return greLink.IKey == key && greLink.OKey == key && greLink.Local.Equal(localIP) && greLink.Remote.Equal(remoteIP)
+	case *netlink.Gretap:
+		return greLink.IKey == key && greLink.OKey == key && greLink.Local.Equal(localIP) && greLink.Remote.Equal(remoteIP)
+	default:
+		return false
+	}
+}
+
+func TestCreate(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		mechanismType string
+		srcIP, dstIP  string
+		outgoing      bool
+		wantType      string
+	}{
+		{name: "gre outgoing", mechanismType: MECHANISM, srcIP: "10.0.0.1", dstIP: "10.0.0.2", outgoing: true, wantType: "gre"},
+		{name: "gre incoming", mechanismType: MECHANISM, srcIP: "10.0.0.2", dstIP: "10.0.0.1", wantType: "gre"},
+		{name: "ip6gre", mechanismType: MECHANISM, srcIP: "fd00::1", dstIP: "fd00::2", outgoing: true, wantType: "ip6gre"},
+		{name: "gretap", mechanismType: TAPMECHANISM, srcIP: "10.0.0.1", dstIP: "10.0.0.2", outgoing: true, wantType: "gretap"},
+		{name: "ip6gretap", mechanismType: TAPMECHANISM, srcIP: "fd00::1", dstIP: "fd00::2", outgoing: true, wantType: "ip6gretap"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := nlhandle.NewFake()
+			fake.AddNamespace(netNSURL)
+			ctx := metadataContext(t)
+			conn := &networkservice.Connection{
+				Id:        connID,
+				Mechanism: newMechanism(tc.mechanismType, tc.srcIP, tc.dstIP, 42),
+				Context:   &networkservice.ConnectionContext{MTU: 1400},
+			}
+
+			if err := Create(ctx, fake, conn, tc.outgoing); err != nil {
+				t.Fatal(err)
+			}
+			handle, err := fake.FromURL(netNSURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer handle.Close()
+			l, err := handle.LinkByName("nsm-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if l.Type() != tc.wantType {
+				t.Errorf("got link type %s, want %s", l.Type(), tc.wantType)
+			}
+			if l.Attrs().MTU != 1400 {
+				t.Errorf("got MTU %d, want 1400", l.Attrs().MTU)
+			}
+			// The local end of the tunnel is the destination of the mechanism of the incoming connections
+			localIP, remoteIP := net.ParseIP(tc.srcIP), net.ParseIP(tc.dstIP)
+			if !tc.outgoing {
+				localIP, remoteIP = remoteIP, localIP
+			}
+			if !tunnels(l, localIP, remoteIP, 42) {
+				t.Errorf("got link %+v, want a tunnel from %s to %s with key 42", l, localIP, remoteIP)
+			}
+
+			// The refresh keeps the link, the Close deletes it
+			if err = Create(ctx, fake, conn, tc.outgoing); err != nil {
+				t.Fatal(err)
+			}
+			if refreshed, err := handle.LinkByName("nsm-1"); err != nil || refreshed.Attrs().Index != l.Attrs().Index {
+				t.Errorf("the link was not kept by the refresh: %v", err)
+			}
+			if err = Delete(ctx, fake, conn, tc.outgoing); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = handle.LinkByName("nsm-1"); err == nil {
+				t.Error("the link was not deleted")
+			}
+			if _, ok := link.Load(ctx, tc.outgoing); ok {
+				t.Error("the link is still cached")
+			}
+		})
+	}
+}
+
+func TestCreateMixedFamilies(t *testing.T) {
+	fake := nlhandle.NewFake()
+	fake.AddNamespace(netNSURL)
+	conn := &networkservice.Connection{Id: connID, Mechanism: newMechanism(MECHANISM, "10.0.0.1", "fd00::2", 42)}
+	if err := Create(metadataContext(t), fake, conn, true); err == nil {
+		t.Error("got no error for tunnel IPs of different address families")
+	}
+}
+
+func TestOverhead(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		mechanism *networkservice.Mechanism
+		want      int
+	}{
+		{name: "gre IPv4", mechanism: newMechanism(MECHANISM, "10.0.0.1", "10.0.0.2", 42), want: 28},
+		{name: "gre IPv6", mechanism: newMechanism(MECHANISM, "fd00::1", "fd00::2", 42), want: 48},
+		{name: "gretap IPv4", mechanism: newMechanism(TAPMECHANISM, "10.0.0.1", "10.0.0.2", 42), want: 42},
+		{name: "gretap IPv6", mechanism: newMechanism(TAPMECHANISM, "fd00::1", "fd00::2", 42), want: 62},
+		{name: "other mechanism", mechanism: &networkservice.Mechanism{Type: "VXLAN"}, want: 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Overhead(tc.mechanism); got != tc.want {
+				t.Errorf("got overhead %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package gre implements the GRE remote mechanism for the IP payload connections and the GRETAP one for the
+// Ethernet payload connections. The GRE links carry the IP packets of the pods directly, the GRETAP links carry
+// Ethernet frames like the vxlan and geneve ones, for the underlays dropping their UDP traffic. The client and server
+// elements negotiate the tunnel endpoints and the GRE key, which tells apart the tunnels between the same two nodes,
+// through the mechanism parameters, and Create builds the gre, ip6gre, gretap or ip6gretap link the xconnect server
+// moves into the pod network namespace.
+package gre
+
+import (
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/common"
+)
+
+const (
+	// MECHANISM string
+	MECHANISM = "GRE"
+	// TAPMECHANISM string
+	TAPMECHANISM = "GRETAP"
+
+	// SrcIP - source tunnel IP parameter
+	SrcIP = common.SrcIP
+	// DstIP - destination tunnel IP parameter
+	DstIP = common.DstIP
+	// Key - GRE key parameter
+	Key = "key"
+
+	// The GRE keys use the whole 32 bits of the key field
+	keyBits = 32
+
+	// The encapsulation overhead (outer IP and GRE headers with a key) subtracted from the underlay MTU, per
+	// underlay address family
+	overheadIPv4 = 28
+	overheadIPv6 = 48
+	// The gretap links also encapsulate the Ethernet header of the frames
+	overheadTapIPv4 = overheadIPv4 + 14
+	overheadTapIPv6 = overheadIPv6 + 14
+)
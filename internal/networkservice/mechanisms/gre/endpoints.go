@@ -0,0 +1,88 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package gre
+
+import (
+	"context"
+	"net"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// The client sets the source tunnel endpoint and the server sets the destination one, before the key is allocated
+// by tunnelkey.
+
+type endpointClient struct {
+	tunnelIP net.IP
+}
+
+func newEndpointClient(tunnelIP net.IP) networkservice.NetworkServiceClient {
+	return &endpointClient{
+		tunnelIP: tunnelIP,
+	}
+}
+
+func (e *endpointClient) Request(ctx context.Context, request *networkservice.NetworkServiceRequest, opts ...grpc.CallOption) (*networkservice.Connection, error) {
+	for _, m := range request.GetMechanismPreferences() {
+		if mech := ToMechanism(m); mech != nil {
+			mech.SetSrcIP(e.tunnelIP)
+
+			log.FromContext(ctx).
+				WithField("greEndpointClient", "request").
+				WithField("mechSrcIp", mech.SrcIP()).
+				Debugf("set mechanism src")
+		}
+	}
+	return next.Client(ctx).Request(ctx, request, opts...)
+}
+
+func (e *endpointClient) Close(ctx context.Context, conn *networkservice.Connection, opts ...grpc.CallOption) (*empty.Empty, error) {
+	return next.Client(ctx).Close(ctx, conn, opts...)
+}
+
+type endpointServer struct {
+	tunnelIP net.IP
+}
+
+func newEndpointServer(tunnelIP net.IP) networkservice.NetworkServiceServer {
+	return &endpointServer{
+		tunnelIP: tunnelIP,
+	}
+}
+
+func (e *endpointServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if mechanism := ToMechanism(request.GetConnection().GetMechanism()); mechanism != nil {
+		mechanism.SetDstIP(e.tunnelIP)
+
+		log.FromContext(ctx).
+			WithField("greEndpointServer", "request").
+			WithField("mechanism.DstIP", mechanism.DstIP()).
+			Debugf("set mechanism dst")
+	}
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (e *endpointServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
@@ -0,0 +1,83 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package gre
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+)
+
+// Mechanism - helper for the GRE and GRETAP mechanism parameters
+type Mechanism struct {
+	*networkservice.Mechanism
+}
+
+// ToMechanism - convert unified mechanism to helper type
+func ToMechanism(m *networkservice.Mechanism) *Mechanism {
+	if m.GetType() == MECHANISM || m.GetType() == TAPMECHANISM {
+		if m.Parameters == nil {
+			m.Parameters = map[string]string{}
+		}
+		return &Mechanism{Mechanism: m}
+	}
+	return nil
+}
+
+// IsTap reports whether the mechanism is a GRETAP one, carrying Ethernet frames
+func (m *Mechanism) IsTap() bool {
+	return m.GetType() == TAPMECHANISM
+}
+
+// SrcIP returns the tunnel IP of the source forwarder
+func (m *Mechanism) SrcIP() net.IP {
+	return net.ParseIP(m.GetParameters()[SrcIP])
+}
+
+// SetSrcIP sets the tunnel IP of the source forwarder
+func (m *Mechanism) SetSrcIP(ip net.IP) *Mechanism {
+	m.GetParameters()[SrcIP] = ip.String()
+	return m
+}
+
+// DstIP returns the tunnel IP of the destination forwarder
+func (m *Mechanism) DstIP() net.IP {
+	return net.ParseIP(m.GetParameters()[DstIP])
+}
+
+// SetDstIP sets the tunnel IP of the destination forwarder
+func (m *Mechanism) SetDstIP(ip net.IP) *Mechanism {
+	m.GetParameters()[DstIP] = ip.String()
+	return m
+}
+
+// Key returns the GRE key of the tunnel, 0 if it is not set
+func (m *Mechanism) Key() uint32 {
+	key, err := strconv.ParseUint(m.GetParameters()[Key], 10, keyBits)
+	if err != nil {
+		return 0
+	}
+	return uint32(key)
+}
+
+// SetKey sets the GRE key of the tunnel
+func (m *Mechanism) SetKey(key uint32) {
+	m.GetParameters()[Key] = strconv.FormatUint(uint64(key), 10)
+}
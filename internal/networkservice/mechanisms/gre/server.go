@@ -0,0 +1,41 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package gre
+
+import (
+	"net"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/tunnelkey"
+)
+
+// NewServer - returns a new server for the gre or gretap remote mechanism
+func NewServer(tunnelIP net.IP) networkservice.NetworkServiceServer {
+	return chain.NewNetworkServiceServer(
+		newEndpointServer(tunnelIP),
+		tunnelkey.NewServer(keyBits, func(m *networkservice.Mechanism) tunnelkey.Mechanism {
+			if mechanism := ToMechanism(m); mechanism != nil {
+				return mechanism
+			}
+			return nil
+		}),
+	)
+}
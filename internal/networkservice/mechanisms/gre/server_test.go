@@ -0,0 +1,90 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package gre
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+)
+
+func newRequest(connID, mechanismType string, srcIP net.IP) *networkservice.NetworkServiceRequest {
+	mechanism := &networkservice.Mechanism{Cls: cls.REMOTE, Type: mechanismType, Parameters: map[string]string{}}
+	ToMechanism(mechanism).SetSrcIP(srcIP)
+	return &networkservice.NetworkServiceRequest{
+		Connection: &networkservice.Connection{Id: connID, Mechanism: mechanism},
+	}
+}
+
+func TestServerKey(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		mechanismType string
+		srcIP, dstIP  string
+		wantOdd       bool
+	}{
+		{name: "gre from the lower tunnel IP", mechanismType: MECHANISM, srcIP: "10.0.0.1", dstIP: "10.0.0.2"},
+		{name: "gre from the higher tunnel IP", mechanismType: MECHANISM, srcIP: "10.0.0.2", dstIP: "10.0.0.1", wantOdd: true},
+		{name: "gretap from the lower tunnel IP", mechanismType: TAPMECHANISM, srcIP: "fd00::1", dstIP: "fd00::2"},
+		{name: "gretap from the higher tunnel IP", mechanismType: TAPMECHANISM, srcIP: "fd00::2", dstIP: "fd00::1", wantOdd: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := chain.NewNetworkServiceServer(metadata.NewServer(), NewServer(net.ParseIP(tc.dstIP)))
+
+			keys := make(map[uint32]struct{})
+			for i := 0; i < 16; i++ {
+				request := newRequest(fmt.Sprintf("conn-%d", i), tc.mechanismType, net.ParseIP(tc.srcIP))
+				conn, err := server.Request(context.Background(), request)
+				if err != nil {
+					t.Fatal(err)
+				}
+				mechanism := ToMechanism(conn.GetMechanism())
+				if !mechanism.DstIP().Equal(net.ParseIP(tc.dstIP)) {
+					t.Errorf("got DstIP %s, want %s", mechanism.DstIP(), tc.dstIP)
+				}
+				key := mechanism.Key()
+				if key == 0 {
+					t.Fatal("no key allocated")
+				}
+				if odd := key%2 == 1; odd != tc.wantOdd {
+					t.Errorf("got key %d, want an odd one: %v", key, tc.wantOdd)
+				}
+				if _, ok := keys[key]; ok {
+					t.Errorf("key %d allocated twice", key)
+				}
+				keys[key] = struct{}{}
+
+				// The refresh of the connection keeps its key
+				refreshed, err := server.Request(context.Background(), &networkservice.NetworkServiceRequest{Connection: conn})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := ToMechanism(refreshed.GetMechanism()).Key(); got != key {
+					t.Errorf("got key %d after the refresh, want %d", got, key)
+				}
+			}
+		})
+	}
+}
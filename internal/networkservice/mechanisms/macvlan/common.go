@@ -0,0 +1,278 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package macvlan
+
+import (
+	"context"
+	"strings"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+// LinkType returns the link type of the local connection between the pods of srcConn and dstConn. The linkType
+// parameter of the kernel mechanism of the endpoint takes precedence over the one of the client, which takes
+// precedence over the linkType label of the connection. It defaults to LinkTypeVeth.
+func LinkType(srcConn, dstConn *networkservice.Connection) (string, error) {
+	linkType := dstConn.GetMechanism().GetParameters()[LinkTypeKey]
+	if linkType == "" {
+		linkType = srcConn.GetMechanism().GetParameters()[LinkTypeKey]
+	}
+	if linkType == "" {
+		linkType = srcConn.GetLabels()[LinkTypeKey]
+	}
+	switch linkType = strings.ToLower(strings.TrimSpace(linkType)); linkType {
+	case "":
+		return LinkTypeVeth, nil
+	case LinkTypeVeth, LinkTypeMacvlan, LinkTypeIPvlanL2, LinkTypeIPvlanL3:
+		return linkType, nil
+	default:
+		return "", errors.Errorf("unsupported local link type: %s", linkType)
+	}
+}
+
+// Create creates the macvlan or ipvlan child of the parent interface of the forwarder network namespace for the
+// kernel mechanism of the conn, according to linkType, and moves it to the target network namespace. Unlike a veth
+// pair, each end of the local connection has its own child, so it is called for both.
+// All netlink operations are performed through the handles returned by nl.
+func Create(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, isSrc bool, parent, linkType string) error {
+	mechanism := kernel.ToMechanism(conn.GetMechanism())
+	if mechanism == nil {
+		return nil
+	}
+	log.FromContext(ctx).Infof("%s create: isSrc: %v, mech: %v", linkType, isSrc, mechanism)
+	if parent == "" {
+		return errors.Errorf("%s parent interface not configured", linkType)
+	}
+
+	// Construct the netlink handle for the target namespace for this kernel interface
+	handle, err := nl.FromURL(mechanism.GetNetNSURL())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Close()
+
+	// Construct the netlink handle for the forwarder namespace where the parent interface is
+	hostHandle, err := nl.Host()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer hostHandle.Close()
+
+	parentLink, err := hostHandle.LinkByName(parent)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the %s parent interface %s", linkType, parent)
+	}
+
+	// Check if the link is present in the cache. If present, the create request can be ignored.
+	if _, ok := link.Load(ctx, isSrc); ok {
+		if _, err = handle.LinkByName(mechanism.GetInterfaceName()); err == nil {
+			return nil
+		}
+	}
+
+	// After a restart, take over the child created for the connection by the previous forwarder instance if it is
+	// still of the same type on the same parent, so that the traffic is not interrupted
+	if prevLink, err := handle.LinkByName(mechanism.GetInterfaceName()); err == nil && adoptable(ctx, prevLink, mechanism.GetNetNSURL(), parentLink.Attrs().Index, linkType) {
+		link.Adopt(ctx, isSrc, prevLink)
+		return nil
+	}
+
+	// Link not in cache. Delete the previous (stale or of unknown origin) kernel interface if there is one in the
+	// target namespace.
+	if prevLink, err := handle.LinkByName(mechanism.GetInterfaceName()); err == nil {
+		if err = handle.LinkDel(prevLink); err != nil {
+			return errors.WithStack(err)
+		}
+		metrics.StaleLinkDeletions.Inc(linkType)
+		log.FromContext(ctx).
+			WithField("link.Name", prevLink.Attrs().Name).
+			WithField("netlink", "LinkDel").Debug("completed")
+	}
+
+	// The child can only be created next to its parent, and moved to the target namespace afterwards. Its MTU cannot
+	// exceed the one of the parent.
+	mtu := int(conn.GetContext().GetMTU())
+	if parentMTU := parentLink.Attrs().MTU; parentMTU > 0 && (mtu == 0 || mtu > parentMTU) {
+		mtu = parentMTU
+	}
+	// Both children of the connection are created in the same namespace, they are told apart by the end of the
+	// connection they are for
+	fwdNsIfaceName := linuxIfaceName(conn.GetId())
+	if !isSrc {
+		fwdNsIfaceName = linuxIfaceName("peer-" + conn.GetId())
+	}
+	if err = hostHandle.LinkAdd(newChild(fwdNsIfaceName, parentLink.Attrs().Index, linkType, mtu)); err != nil {
+		return errors.Wrapf(err, "failed to create %s interface", linkType)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", fwdNsIfaceName).
+		WithField("link.ParentName", parent).
+		WithField("netlink", "LinkAdd "+linkType).Debug("completed")
+
+	l, err := hostHandle.LinkByName(fwdNsIfaceName)
+	if err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", fwdNsIfaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkByName").Debug("error")
+		return errors.WithStack(err)
+	}
+
+	// Set/Insert the link l to the target netns
+	if err = hostHandle.LinkSetNs(l, mechanism.GetNetNSURL()); err != nil {
+		return errors.Wrapf(err, "unable to change to netns")
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "LinkSetNsFd").Debug("completed")
+
+	if l, err = handle.LinkByName(fwdNsIfaceName); err != nil {
+		return errors.WithStack(err)
+	}
+
+	name := mechanism.GetInterfaceName()
+	if err = handle.LinkSetName(l, name); err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("link.NewName", name).
+			WithField("err", err).
+			WithField("netlink", "LinkSetName").Debug("error")
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("link.NewName", name).
+		WithField("netlink", "LinkSetName").Debug("completed")
+
+	// Set link alias to the name of the network service client pod requesting for this link.
+	if linkAlias := link.Alias(conn.GetLabels()["podName"]); linkAlias != "" {
+		if err = handle.LinkSetAlias(l, linkAlias); err != nil {
+			return errors.WithStack(err)
+		}
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("alias", linkAlias).
+			WithField("netlink", "LinkSetAlias").Debug("completed")
+	}
+
+	if err = handle.LinkSetUp(l); err != nil {
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "LinkSetUp").Debug("completed")
+
+	// Store the link info in the cache
+	link.Store(ctx, isSrc, l)
+
+	return nil
+}
+
+// Delete deletes the macvlan or ipvlan child for the kernel mechanism of the conn from the target network namespace.
+// Links of other types, like the veth pairs, are left to their own Delete.
+func Delete(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, isSrc bool) error {
+	mechanism := kernel.ToMechanism(conn.GetMechanism())
+	if mechanism == nil {
+		return nil
+	}
+	handle, err := nl.FromURL(mechanism.GetNetNSURL())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Close()
+
+	l, err := handle.LinkByName(mechanism.GetInterfaceName())
+	if err != nil || childLinkType(l) == "" {
+		return nil
+	}
+
+	if err = handle.LinkDel(l); err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("err", err).
+			WithField("netlink", "LinkDel").Debug("error")
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "LinkDel").Debug("completed")
+	link.Delete(ctx, isSrc)
+
+	return nil
+}
+
+func linuxIfaceName(ifaceName string) string {
+	if len(ifaceName) <= kernel.LinuxIfMaxLength {
+		return ifaceName
+	}
+	return ifaceName[:kernel.LinuxIfMaxLength]
+}
+
+// childLinkType returns the link type of l if it is a macvlan or ipvlan child created by Create, "" otherwise
+func childLinkType(l netlink.Link) string {
+	switch child := l.(type) {
+	case *netlink.Macvlan:
+		if child.Mode == netlink.MACVLAN_MODE_BRIDGE {
+			return LinkTypeMacvlan
+		}
+	case *netlink.IPVlan:
+		switch child.Mode {
+		case netlink.IPVLAN_MODE_L2:
+			return LinkTypeIPvlanL2
+		case netlink.IPVLAN_MODE_L3:
+			return LinkTypeIPvlanL3
+		}
+	}
+	return ""
+}
+
+// adoptable reports whether l is the child of type linkType created by the forwarder for the connection on
+// the parent interface parentIndex
+func adoptable(ctx context.Context, l netlink.Link, netNSURL string, parentIndex int, linkType string) bool {
+	return link.Owned(ctx, l, netNSURL) &&
+		childLinkType(l) == linkType &&
+		l.Attrs().ParentIndex == parentIndex
+}
+
+// newChild returns the child of type linkType to create on the parent interface parentIndex. A zero mtu leaves it to
+// the kernel.
+func newChild(ifaceName string, parentIndex int, linkType string, mtu int) netlink.Link {
+	attrs := netlink.LinkAttrs{
+		Name:        ifaceName,
+		Group:       link.Group,
+		MTU:         mtu,
+		ParentIndex: parentIndex,
+	}
+	switch linkType {
+	case LinkTypeIPvlanL2:
+		return &netlink.IPVlan{LinkAttrs: attrs, Mode: netlink.IPVLAN_MODE_L2, Flag: netlink.IPVLAN_FLAG_BRIDGE}
+	case LinkTypeIPvlanL3:
+		return &netlink.IPVlan{LinkAttrs: attrs, Mode: netlink.IPVLAN_MODE_L3, Flag: netlink.IPVLAN_FLAG_BRIDGE}
+	default:
+		return &netlink.Macvlan{LinkAttrs: attrs, Mode: netlink.MACVLAN_MODE_BRIDGE}
+	}
+}
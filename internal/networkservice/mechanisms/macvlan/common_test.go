@@ -0,0 +1,273 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package macvlan
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	connID      = "conn-1"
+	parent      = "eth1"
+	parentMTU   = 1500
+	srcNetNSURL = "inode://4/1001"
+	dstNetNSURL = "inode://4/1002"
+)
+
+type ctxServer struct {
+	ctx context.Context
+}
+
+func (s *ctxServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	s.ctx = ctx
+	return request.GetConnection(), nil
+}
+
+func (s *ctxServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+// metadataContext returns a context carrying the per connection metadata of connID, as the elements following the
+// metadata server in a chain get it
+func metadataContext(t *testing.T) context.Context {
+	t.Helper()
+	s := &ctxServer{}
+	request := &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: connID}}
+	if _, err := chain.NewNetworkServiceServer(metadata.NewServer(), s).Request(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	return s.ctx
+}
+
+func newConn(netNSURL, ifaceName string, params, labels map[string]string, mtu uint32) *networkservice.Connection {
+	mechanism := kernel.New(netNSURL)
+	mechanism.GetParameters()[kernel.InterfaceNameKey] = ifaceName
+	for k, v := range params {
+		mechanism.GetParameters()[k] = v
+	}
+	return &networkservice.Connection{
+		Id:        connID,
+		Mechanism: mechanism,
+		Context:   &networkservice.ConnectionContext{MTU: mtu},
+		Labels:    labels,
+	}
+}
+
+// newFake returns a fake with the parent interface in the host namespace and the namespaces of both pods
+func newFake(t *testing.T) (*nlhandle.Fake, netlink.Link) {
+	t.Helper()
+	fake := nlhandle.NewFake()
+	fake.AddNamespace(srcNetNSURL)
+	fake.AddNamespace(dstNetNSURL)
+	handle, err := fake.Host()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	parentLink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: parent, MTU: parentMTU}}
+	if err = handle.LinkAdd(parentLink); err != nil {
+		t.Fatal(err)
+	}
+	return fake, parentLink
+}
+
+func linkByName(t *testing.T, fake *nlhandle.Fake, netNSURL, name string) (netlink.Link, error) {
+	t.Helper()
+	handle, err := fake.FromURL(netNSURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	return handle.LinkByName(name)
+}
+
+func TestLinkType(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		srcParam  string
+		dstParam  string
+		label     string
+		want      string
+		wantError bool
+	}{
+		{name: "default", want: LinkTypeVeth},
+		{name: "label", label: LinkTypeIPvlanL3, want: LinkTypeIPvlanL3},
+		{name: "client parameter over label", srcParam: LinkTypeIPvlanL2, label: LinkTypeIPvlanL3, want: LinkTypeIPvlanL2},
+		{name: "endpoint parameter over client parameter", srcParam: LinkTypeIPvlanL2, dstParam: LinkTypeMacvlan, label: LinkTypeIPvlanL3, want: LinkTypeMacvlan},
+		{name: "endpoint parameter over label", dstParam: LinkTypeVeth, label: LinkTypeMacvlan, want: LinkTypeVeth},
+		{name: "case and spaces", label: " MACVLAN ", want: LinkTypeMacvlan},
+		{name: "unsupported", dstParam: "bridge", wantError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srcParams, dstParams := map[string]string{}, map[string]string{}
+			if tc.srcParam != "" {
+				srcParams[LinkTypeKey] = tc.srcParam
+			}
+			if tc.dstParam != "" {
+				dstParams[LinkTypeKey] = tc.dstParam
+			}
+			labels := map[string]string{}
+			if tc.label != "" {
+				labels[LinkTypeKey] = tc.label
+			}
+			srcConn := newConn(srcNetNSURL, "nsm-src", srcParams, labels, 0)
+			dstConn := newConn(dstNetNSURL, "nsm-dst", dstParams, nil, 0)
+
+			got, err := LinkType(srcConn, dstConn)
+			if tc.wantError {
+				if err == nil {
+					t.Fatalf("got link type %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got link type %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	for _, tc := range []struct {
+		linkType string
+		mtu      uint32
+		wantMTU  int
+		check    func(l netlink.Link) bool
+	}{
+		{
+			linkType: LinkTypeMacvlan,
+			mtu:      1400,
+			wantMTU:  1400,
+			check: func(l netlink.Link) bool {
+				child, ok := l.(*netlink.Macvlan)
+				return ok && child.Mode == netlink.MACVLAN_MODE_BRIDGE
+			},
+		},
+		{
+			linkType: LinkTypeIPvlanL2,
+			mtu:      9000,
+			wantMTU:  parentMTU,
+			check: func(l netlink.Link) bool {
+				child, ok := l.(*netlink.IPVlan)
+				return ok && child.Mode == netlink.IPVLAN_MODE_L2
+			},
+		},
+		{
+			linkType: LinkTypeIPvlanL3,
+			wantMTU:  parentMTU,
+			check: func(l netlink.Link) bool {
+				child, ok := l.(*netlink.IPVlan)
+				return ok && child.Mode == netlink.IPVLAN_MODE_L3
+			},
+		},
+	} {
+		t.Run(tc.linkType, func(t *testing.T) {
+			fake, parentLink := newFake(t)
+			ctx := metadataContext(t)
+			ends := []struct {
+				conn  *networkservice.Connection
+				isSrc bool
+			}{
+				{conn: newConn(srcNetNSURL, "nsm-src", nil, nil, tc.mtu), isSrc: true},
+				{conn: newConn(dstNetNSURL, "nsm-dst", nil, nil, tc.mtu), isSrc: false},
+			}
+			for _, end := range ends {
+				if err := Create(ctx, fake, end.conn, end.isSrc, parent, tc.linkType); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, end := range ends {
+				mechanism := kernel.ToMechanism(end.conn.GetMechanism())
+				l, err := linkByName(t, fake, mechanism.GetNetNSURL(), mechanism.GetInterfaceName())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !tc.check(l) {
+					t.Errorf("%s: got a %s link of the wrong mode", mechanism.GetInterfaceName(), l.Type())
+				}
+				if l.Attrs().ParentIndex != parentLink.Attrs().Index {
+					t.Errorf("%s: got parent index %d, want %d", mechanism.GetInterfaceName(), l.Attrs().ParentIndex, parentLink.Attrs().Index)
+				}
+				if l.Attrs().MTU != tc.wantMTU {
+					t.Errorf("%s: got MTU %d, want %d", mechanism.GetInterfaceName(), l.Attrs().MTU, tc.wantMTU)
+				}
+			}
+
+			for _, end := range ends {
+				if err := Delete(ctx, fake, end.conn, end.isSrc); err != nil {
+					t.Fatal(err)
+				}
+				mechanism := kernel.ToMechanism(end.conn.GetMechanism())
+				if _, err := linkByName(t, fake, mechanism.GetNetNSURL(), mechanism.GetInterfaceName()); err == nil {
+					t.Errorf("%s was not deleted", mechanism.GetInterfaceName())
+				}
+				if _, ok := link.Load(ctx, end.isSrc); ok {
+					t.Errorf("%s is still cached", mechanism.GetInterfaceName())
+				}
+			}
+		})
+	}
+}
+
+func TestCreateMissingParent(t *testing.T) {
+	fake, _ := newFake(t)
+	conn := newConn(srcNetNSURL, "nsm-src", nil, nil, 0)
+	if err := Create(metadataContext(t), fake, conn, true, "", LinkTypeMacvlan); err == nil || !strings.Contains(err.Error(), "not configured") {
+		t.Errorf("got error %v, want the parent not to be configured", err)
+	}
+	if err := Create(metadataContext(t), fake, conn, true, "eth9", LinkTypeMacvlan); err == nil {
+		t.Error("got no error for a missing parent interface")
+	}
+}
+
+func TestDeleteOnlyChildren(t *testing.T) {
+	fake, _ := newFake(t)
+	handle, err := fake.FromURL(srcNetNSURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	// The veth pairs of the local connections are left to veth.Delete
+	if err = handle.LinkAdd(&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "nsm-src"}, PeerName: "nsm-peer"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Delete(metadataContext(t), fake, newConn(srcNetNSURL, "nsm-src", nil, nil, 0), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = handle.LinkByName("nsm-src"); err != nil {
+		t.Errorf("the veth link was deleted: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package macvlan implements the macvlan and ipvlan variants of the local kernel mechanism. Instead of the veth pair
+// of the local connections, each pod of the connection gets a macvlan (bridge mode) or ipvlan (L2 or L3 mode) child
+// of a host interface, which gives it direct access to the underlay as well, e.g. for the gateway endpoints. The
+// variant is selected by the linkType parameter of the kernel mechanism of either pod, or by the linkType label of
+// the connection.
+package macvlan
+
+const (
+	// LinkTypeKey - kernel mechanism parameter and connection label selecting the link type of a local connection
+	LinkTypeKey = "linkType"
+
+	// LinkTypeVeth - a veth pair between the pods, the default
+	LinkTypeVeth = "veth"
+	// LinkTypeMacvlan - a macvlan child in bridge mode in each pod
+	LinkTypeMacvlan = "macvlan"
+	// LinkTypeIPvlanL2 - an ipvlan child in L2 mode in each pod
+	LinkTypeIPvlanL2 = "ipvlan-l2"
+	// LinkTypeIPvlanL3 - an ipvlan child in L3 mode in each pod
+	LinkTypeIPvlanL3 = "ipvlan-l3"
+)
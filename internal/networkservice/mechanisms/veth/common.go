@@ -2,22 +2,29 @@ package veth
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"net"
+	"path"
 	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/networkservicemesh/api/pkg/api/networkservice"
 	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
-	kernellink "github.com/networkservicemesh/sdk-kernel/pkg/kernel"
-	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/tools/nshandle"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/payload"
 	"github.com/networkservicemesh/sdk/pkg/tools/log"
 
 	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
 
 	"github.com/vishvananda/netlink"
 )
 
+// addrGenModeNone is the IN6_ADDR_GEN_MODE_NONE value of the addr_gen_mode sysctl
+const addrGenModeNone = "1"
+
 func toAlias(conn *networkservice.Connection, isSrc bool) string {
 	// Naming is tricky.  We want to name based on either the next or prev connection id depending on whether we
 	// are on the client or server side.  Since this chain element is designed for use in a Forwarder,
@@ -42,17 +49,26 @@ func getVethLinkNamePair(conn *networkservice.Connection, isSrc bool) (string, s
 	}
 }
 
-func Create(ctx context.Context, conn *networkservice.Connection, isSrc bool) error {
+// Create creates the veth link for the kernel mechanism of the conn and moves it to the target network namespace.
+// All netlink operations are performed through the handles returned by nl.
+func Create(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, isSrc bool) error {
 	if mechanism := kernel.ToMechanism(conn.GetMechanism()); mechanism != nil {
 		log.FromContext(ctx).Infof("veth create: isSrc: %v, mech: %v", isSrc, mechanism)
 
 		// Construct the netlink handle for the target namespace for this kernel interface
-		handle, err := kernellink.GetNetlinkHandle(mechanism.GetNetNSURL())
+		handle, err := nl.FromURL(mechanism.GetNetNSURL())
 		if err != nil {
 			return errors.WithStack(err)
 		}
 		defer handle.Close()
 
+		// Construct the netlink handle for the forwarder namespace where the veth pair is created
+		hostHandle, err := nl.Host()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer hostHandle.Close()
+
 		// Check if the link is present in the cache. If present, the create request can be ignored.
 		if linkCached, ok := link.Load(ctx, isSrc); ok {
 			log.FromContext(ctx).Debug("veth create: link found in cache: isSrc: %v, linkCached: %v", isSrc, linkCached)
@@ -68,6 +84,7 @@ func Create(ctx context.Context, conn *networkservice.Connection, isSrc bool) er
 			if err = handle.LinkDel(prevLink); err != nil {
 				return errors.WithStack(err)
 			}
+			metrics.StaleLinkDeletions.Inc("veth")
 			log.FromContext(ctx).
 				WithField("link.Name", prevLink.Attrs().Name).
 				WithField("duration", time.Since(now)).
@@ -86,12 +103,15 @@ func Create(ctx context.Context, conn *networkservice.Connection, isSrc bool) er
 			now := time.Now()
 			veth := &netlink.Veth{
 				LinkAttrs: netlink.LinkAttrs{
-					Name: linkName,
+					Name:  linkName,
+					Group: link.Group,
+					// The peer is created with the same MTU
+					MTU: int(conn.GetContext().GetMTU()),
 				},
 				PeerName: peerName,
 			}
 			l = veth
-			if addErr := netlink.LinkAdd(l); addErr != nil {
+			if addErr := hostHandle.LinkAdd(l); addErr != nil {
 				return addErr
 			}
 			log.FromContext(ctx).
@@ -102,7 +122,7 @@ func Create(ctx context.Context, conn *networkservice.Connection, isSrc bool) er
 		}
 
 		now := time.Now()
-		l, err = netlink.LinkByName(linkName)
+		l, err = hostHandle.LinkByName(linkName)
 		if err != nil {
 			log.FromContext(ctx).
 				WithField("duration", time.Since(now)).
@@ -112,16 +132,9 @@ func Create(ctx context.Context, conn *networkservice.Connection, isSrc bool) er
 			return errors.WithStack(err)
 		}
 
-		// Construct the nsHandle for the target namespace for this kernel interface
-		nsHandle, err := nshandle.FromURL(mechanism.GetNetNSURL())
-		if err != nil {
-			return errors.WithStack(err)
-		}
-		defer func() { _ = nsHandle.Close() }()
-
 		// Set/Insert the link l to the target netns
 		now = time.Now()
-		if err = netlink.LinkSetNsFd(l, int(nsHandle)); err != nil {
+		if err = hostHandle.LinkSetNs(l, mechanism.GetNetNSURL()); err != nil {
 			return errors.Wrapf(err, "unable to change to netns")
 		}
 		log.FromContext(ctx).
@@ -165,18 +178,33 @@ func Create(ctx context.Context, conn *networkservice.Connection, isSrc bool) er
 			WithField("netlink", "LinkSetName").Debug("completed")
 
 		// Set link alias if pod name is set in the labels
-		if conn.GetLabels() != nil {
-			linkAlias := conn.GetLabels()["podName"]
-			if linkAlias != "" {
-				now = time.Now()
-				if err = handle.LinkSetAlias(l, linkAlias); err != nil {
-					return errors.WithStack(err)
-				}
-				log.FromContext(ctx).
-					WithField("link.Name", l.Attrs().Name).
-					WithField("alias", linkAlias).
-					WithField("duration", time.Since(now)).
-					WithField("netlink", "LinkSetAlias").Debug("completed")
+		if linkAlias := link.Alias(conn.GetLabels()["podName"]); linkAlias != "" {
+			now = time.Now()
+			if err = handle.LinkSetAlias(l, linkAlias); err != nil {
+				return errors.WithStack(err)
+			}
+			log.FromContext(ctx).
+				WithField("link.Name", l.Attrs().Name).
+				WithField("alias", linkAlias).
+				WithField("duration", time.Since(now)).
+				WithField("netlink", "LinkSetAlias").Debug("completed")
+		}
+
+		// Mark the link as created by the forwarder. The peer end is marked by its own Create.
+		now = time.Now()
+		if err = handle.LinkSetGroup(l, link.Group); err != nil {
+			return errors.WithStack(err)
+		}
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("group", link.Group).
+			WithField("duration", time.Since(now)).
+			WithField("netlink", "LinkSetGroup").Debug("completed")
+
+		// The IP payload connections get a point-to-point L3 link instead of an Ethernet one
+		if conn.GetPayload() == payload.IP {
+			if err = setPointToPoint(ctx, handle, l, name, conn.GetId()); err != nil {
+				return err
 			}
 		}
 
@@ -197,11 +225,60 @@ func Create(ctx context.Context, conn *networkservice.Connection, isSrc bool) er
 	return nil
 }
 
-func Delete(ctx context.Context, conn *networkservice.Connection, isSrc bool) error {
+// Adopt takes over the veth pair created for the local cross connect of srcConn and dstConn by a previous forwarder
+// instance, storing both of its ends in the link cache, so that Create leaves it in place instead of creating it
+// again. The pair is only adopted if both ends are recorded for the connection and are peers of each other.
+func Adopt(ctx context.Context, nl nlhandle.Provider, srcConn, dstConn *networkservice.Connection) {
+	if _, ok := link.Load(ctx, true); ok {
+		return
+	}
+	srcLink := lookupOwned(ctx, nl, srcConn)
+	if srcLink == nil {
+		return
+	}
+	dstLink := lookupOwned(ctx, nl, dstConn)
+	if dstLink == nil {
+		return
+	}
+	if srcLink.Attrs().ParentIndex != dstLink.Attrs().Index || dstLink.Attrs().ParentIndex != srcLink.Attrs().Index {
+		log.FromContext(ctx).
+			WithField("link.Name", srcLink.Attrs().Name).
+			WithField("link.PeerName", dstLink.Attrs().Name).
+			WithField("veth", "Adopt").Debug("not peers")
+		return
+	}
+	link.Adopt(ctx, true, srcLink)
+	link.Adopt(ctx, false, dstLink)
+}
+
+// lookupOwned returns the veth link of the kernel mechanism of the conn if it was created for the conn, nil otherwise
+func lookupOwned(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection) netlink.Link {
+	mechanism := kernel.ToMechanism(conn.GetMechanism())
+	if mechanism == nil {
+		return nil
+	}
+	handle, err := nl.FromURL(mechanism.GetNetNSURL())
+	if err != nil {
+		return nil
+	}
+	defer handle.Close()
+
+	l, err := handle.LinkByName(mechanism.GetInterfaceName())
+	if err != nil {
+		return nil
+	}
+	if _, ok := l.(*netlink.Veth); !ok || !link.Owned(ctx, l, mechanism.GetNetNSURL()) {
+		return nil
+	}
+	return l
+}
+
+// Delete deletes the veth link for the kernel mechanism of the conn from the target network namespace
+func Delete(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, isSrc bool) error {
 	if mechanism := kernel.ToMechanism(conn.GetMechanism()); mechanism != nil {
 		log.FromContext(ctx).Infof("veth delete: isSrc: %v, mech: %v", isSrc, mechanism)
 		// Construct the netlink handle for the target namespace for this kernel interface
-		handle, err := kernellink.GetNetlinkHandle(mechanism.GetNetNSURL())
+		handle, err := nl.FromURL(mechanism.GetNetNSURL())
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -227,6 +304,7 @@ func Delete(ctx context.Context, conn *networkservice.Connection, isSrc bool) er
 			log.FromContext(ctx).
 				WithField("link.Name", mechanism.GetInterfaceName()).
 				WithField("netlink", "LinkByName").Debug("NotFound")
+			link.Delete(ctx, isSrc)
 			return nil
 		}
 
@@ -248,6 +326,39 @@ func Delete(ctx context.Context, conn *networkservice.Connection, isSrc bool) er
 	return nil
 }
 
+// setPointToPoint configures the end l of the veth pair of an IP payload connection, named name, as a point-to-point
+// L3 link. ARP is turned off, so that the kernel addresses every frame to the MAC of l itself, and both ends get the
+// same MAC, derived from the connection, so that the peer accepts these frames: nothing depends on neighbor
+// resolution across the pair, and the MACs survive forwarder restarts. No IPv6 link-local address is generated.
+func setPointToPoint(ctx context.Context, handle nlhandle.Handle, l netlink.Link, name, connID string) error {
+	// There is no such sysctl if IPv6 is disabled in the pod
+	if err := handle.Sysctl(path.Join("net/ipv6/conf", name, "addr_gen_mode"), addrGenModeNone); err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", name).
+			WithField("err", err).
+			WithField("sysctl", "addr_gen_mode").Debug("error")
+	}
+
+	hwaddr := pointToPointMAC(connID)
+	if err := handle.LinkSetHardwareAddr(l, hwaddr); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := handle.LinkSetARPOff(l); err != nil {
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", name).
+		WithField("link.HardwareAddr", hwaddr.String()).
+		WithField("veth", "setPointToPoint").Debug("completed")
+	return nil
+}
+
+// pointToPointMAC returns the locally administered unicast MAC of both ends of the veth pair of the connection connID
+func pointToPointMAC(connID string) net.HardwareAddr {
+	sum := sha256.Sum256([]byte(connID))
+	return net.HardwareAddr{0x02, sum[0], sum[1], sum[2], sum[3], sum[4]}
+}
+
 func linuxIfaceName(ifaceName string) string {
 	if len(ifaceName) <= kernel.LinuxIfMaxLength {
 		return ifaceName
@@ -0,0 +1,188 @@
+package veth
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/payload"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	connID      = "conn-1"
+	srcNetNSURL = "inode://4/1001"
+	dstNetNSURL = "inode://4/1002"
+)
+
+type ctxServer struct {
+	ctx context.Context
+}
+
+func (s *ctxServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	s.ctx = ctx
+	return request.GetConnection(), nil
+}
+
+func (s *ctxServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+// metadataContext returns a context carrying the per connection metadata of connID, as the elements following the
+// metadata server in a chain get it
+func metadataContext(t *testing.T) context.Context {
+	t.Helper()
+	s := &ctxServer{}
+	request := &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: connID}}
+	if _, err := chain.NewNetworkServiceServer(metadata.NewServer(), s).Request(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	return s.ctx
+}
+
+func newConn(netNSURL, ifaceName, payloadType string) *networkservice.Connection {
+	mechanism := kernel.New(netNSURL)
+	mechanism.GetParameters()[kernel.InterfaceNameKey] = ifaceName
+	return &networkservice.Connection{
+		Id:        connID,
+		Mechanism: mechanism,
+		Payload:   payloadType,
+		Labels:    map[string]string{"podName": "nsc-1"},
+	}
+}
+
+func newFake() *nlhandle.Fake {
+	fake := nlhandle.NewFake()
+	fake.AddNamespace(srcNetNSURL)
+	fake.AddNamespace(dstNetNSURL)
+	return fake
+}
+
+func lookup(t *testing.T, fake *nlhandle.Fake, netNSURL, name string) netlink.Link {
+	t.Helper()
+	handle, err := fake.FromURL(netNSURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	l, err := handle.LinkByName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestCreate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		payload string
+		stale   bool
+	}{
+		{name: "ethernet", payload: payload.Ethernet},
+		{name: "ip", payload: payload.IP},
+		{name: "stale link", payload: payload.Ethernet, stale: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFake()
+			if tc.stale {
+				handle, _ := fake.FromURL(srcNetNSURL)
+				if err := handle.LinkAdd(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "nsm-src"}}); err != nil {
+					t.Fatal(err)
+				}
+				handle.Close()
+			}
+			ctx := metadataContext(t)
+			srcConn := newConn(srcNetNSURL, "nsm-src", tc.payload)
+			dstConn := newConn(dstNetNSURL, "nsm-dst", tc.payload)
+			if err := Create(ctx, fake, srcConn, true); err != nil {
+				t.Fatal(err)
+			}
+			if err := Create(ctx, fake, dstConn, false); err != nil {
+				t.Fatal(err)
+			}
+
+			srcLink := lookup(t, fake, srcNetNSURL, "nsm-src")
+			dstLink := lookup(t, fake, dstNetNSURL, "nsm-dst")
+			if _, ok := srcLink.(*netlink.Veth); !ok {
+				t.Fatalf("got a %s link, want a veth", srcLink.Type())
+			}
+			if srcLink.Attrs().ParentIndex != dstLink.Attrs().Index {
+				t.Errorf("the links are not peers of each other")
+			}
+			for _, l := range []netlink.Link{srcLink, dstLink} {
+				attrs := l.Attrs()
+				if attrs.Group != link.Group || attrs.Alias != "nsc-1" || attrs.Flags&net.FlagUp == 0 {
+					t.Errorf("%s: got group %d, alias %q, flags %v", attrs.Name, attrs.Group, attrs.Alias, attrs.Flags)
+				}
+				noARP := attrs.RawFlags&unix.IFF_NOARP != 0
+				if want := tc.payload == payload.IP; noARP != want {
+					t.Errorf("%s: got ARP off %v, want %v", attrs.Name, noARP, want)
+				}
+			}
+			if tc.payload == payload.IP && srcLink.Attrs().HardwareAddr.String() != dstLink.Attrs().HardwareAddr.String() {
+				t.Errorf("got MACs %s and %s, want the same one", srcLink.Attrs().HardwareAddr, dstLink.Attrs().HardwareAddr)
+			}
+			if _, ok := link.Load(ctx, true); !ok {
+				t.Error("the src link is not cached")
+			}
+			if _, ok := link.Load(ctx, false); !ok {
+				t.Error("the dst link is not cached")
+			}
+
+			// A refresh leaves the links in place
+			if err := Create(ctx, fake, srcConn, true); err != nil {
+				t.Fatal(err)
+			}
+			if l := lookup(t, fake, srcNetNSURL, "nsm-src"); l.Attrs().Index != srcLink.Attrs().Index {
+				t.Error("the link was recreated on refresh")
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	fake := newFake()
+	ctx := metadataContext(t)
+	srcConn := newConn(srcNetNSURL, "nsm-src", payload.Ethernet)
+	dstConn := newConn(dstNetNSURL, "nsm-dst", payload.Ethernet)
+	if err := Create(ctx, fake, srcConn, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := Create(ctx, fake, dstConn, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Delete(ctx, fake, srcConn, true); err != nil {
+		t.Fatal(err)
+	}
+	// The peer is deleted along with the src end, deleting it again is a no-op
+	if err := Delete(ctx, fake, dstConn, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, netNSURL := range []string{srcNetNSURL, dstNetNSURL} {
+		handle, _ := fake.FromURL(netNSURL)
+		links, err := handle.LinkList()
+		handle.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(links) != 0 {
+			t.Errorf("%s: got %d links left, want none", netNSURL, len(links))
+		}
+	}
+	if _, ok := link.Load(ctx, true); ok {
+		t.Error("the src link is still cached")
+	}
+	if _, ok := link.Load(ctx, false); ok {
+		t.Error("the dst link is still cached")
+	}
+}
@@ -0,0 +1,55 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package vlan
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+)
+
+type vlanClient struct {
+}
+
+// NewClient - returns a new client for the vlan remote mechanism. The VLAN ID is left to the endpoint. The
+// sub-interfaces are Ethernet links, but the mechanism is offered for the IP payload connections as well, since the
+// endpoint on the VLAN is expected to resolve the neighbors.
+func NewClient() networkservice.NetworkServiceClient {
+	return &vlanClient{}
+}
+
+func (v *vlanClient) Request(ctx context.Context, request *networkservice.NetworkServiceRequest, opts ...grpc.CallOption) (*networkservice.Connection, error) {
+	mechanism := &networkservice.Mechanism{
+		Cls:        cls.REMOTE,
+		Type:       MECHANISM,
+		Parameters: make(map[string]string),
+	}
+	request.MechanismPreferences = append(request.MechanismPreferences, mechanism)
+
+	return next.Client(ctx).Request(ctx, request, opts...)
+}
+
+func (v *vlanClient) Close(ctx context.Context, conn *networkservice.Connection, opts ...grpc.CallOption) (*empty.Empty, error) {
+	return next.Client(ctx).Close(ctx, conn, opts...)
+}
@@ -0,0 +1,252 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package vlan
+
+import (
+	"context"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+// Create creates the vlan sub-interface of the parent interface of the forwarder network namespace for the vlan
+// mechanism of the conn and moves it to the target network namespace. The kernel allows a single sub-interface per
+// VLAN of the parent, so a VLAN can only be attached to one connection of the node at a time: the Request of a
+// second connection on a VLAN in use fails.
+// All netlink operations are performed through the handles returned by nl.
+func Create(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, outgoing bool, parent string) error {
+	logger := log.FromContext(ctx).WithField("vlan", "Intf create")
+	mechanism := ToMechanism(conn.GetMechanism())
+	if mechanism == nil {
+		return nil
+	}
+	if parent == "" {
+		return errors.Errorf("vlan parent interface not configured")
+	}
+	vlanID := mechanism.VLANID()
+	if vlanID == 0 {
+		return errors.Errorf("vlan ID %q not provided or out of the %d-%d range", mechanism.GetParameters()[VLANID], minVLANID, maxVLANID)
+	}
+	ifaceName := mechanism.GetParameters()[kernel.InterfaceNameKey]
+	if ifaceName == "" {
+		return errors.Errorf("vlan interface name not provided")
+	}
+	netNsURL := mechanism.GetParameters()[kernel.NetNSURL]
+	if netNsURL == "" {
+		return errors.Errorf("vlan inode URL not provided")
+	}
+
+	logger.Infof("netnsurl: %v: iface: %v: parent: %v: vlanID: %v", netNsURL, ifaceName, parent, vlanID)
+
+	handle, err := nl.FromURL(netNsURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Close()
+
+	hostHandle, err := nl.Host()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer hostHandle.Close()
+
+	parentLink, err := hostHandle.LinkByName(parent)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the vlan parent interface %s", parent)
+	}
+
+	// Treat the Request as redundant if the link created by the forwarder is still present in the target namespace
+	if _, ok := link.Load(ctx, outgoing); ok {
+		if _, err = handle.LinkByName(ifaceName); err == nil {
+			return nil
+		}
+	}
+
+	// After a restart, take over the link created for the connection by the previous forwarder instance if it is
+	// still on the same VLAN of the parent, so that the traffic is not interrupted
+	if prevLink, err := handle.LinkByName(ifaceName); err == nil && adoptable(ctx, prevLink, netNsURL, parentLink.Attrs().Index, vlanID) {
+		link.Adopt(ctx, outgoing, prevLink)
+		return nil
+	}
+
+	// The forwarder is not aware of any other interface with that name in the target namespace, it is a stale one
+	if prevLink, err := handle.LinkByName(ifaceName); err == nil {
+		if err = handle.LinkDel(prevLink); err != nil {
+			return errors.WithStack(err)
+		}
+		metrics.StaleLinkDeletions.Inc("vlan")
+		log.FromContext(ctx).
+			WithField("link.Name", prevLink.Attrs().Name).
+			WithField("netlink", "LinkDel").Debug("completed")
+	}
+
+	// The sub-interface can only be created next to its parent, and moved to the target namespace afterwards. Its MTU
+	// cannot exceed the one of the parent.
+	mtu := int(conn.GetContext().GetMTU())
+	if parentMTU := parentLink.Attrs().MTU; parentMTU > 0 && (mtu == 0 || mtu > parentMTU) {
+		mtu = parentMTU
+	}
+	fwdNsIfaceName := linuxIfaceName(conn.GetId())
+	if err = hostHandle.LinkAdd(newVLAN(fwdNsIfaceName, parentLink.Attrs().Index, vlanID, mtu)); err != nil {
+		if errors.Is(err, unix.EEXIST) {
+			return errors.Errorf("vlan %d of %s is already attached to another connection", vlanID, parent)
+		}
+		return errors.Wrapf(err, "failed to create VLAN interface")
+	}
+	log.FromContext(ctx).WithField("link.Name", fwdNsIfaceName).WithField("netlink", "LinkAdd vlan").Debug("completed")
+
+	l, err := hostHandle.LinkByName(fwdNsIfaceName)
+	if err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", fwdNsIfaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkByName").Debug("error")
+		return errors.WithStack(err)
+	}
+
+	if err = hostHandle.LinkSetNs(l, netNsURL); err != nil {
+		return errors.Wrapf(err, "unable to change to netns")
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "LinkSetNsFd").Debug("completed")
+
+	if l, err = handle.LinkByName(fwdNsIfaceName); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err = handle.LinkSetName(l, ifaceName); err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("link.NewName", ifaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkSetName").Debug("error")
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("link.NewName", ifaceName).
+		WithField("netlink", "LinkSetName").Debug("completed")
+
+	// Set link alias to the name of the network service client pod requesting for this link.
+	if linkAlias := link.Alias(conn.GetLabels()["podName"]); linkAlias != "" {
+		if err = handle.LinkSetAlias(l, linkAlias); err != nil {
+			return errors.WithStack(err)
+		}
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("alias", linkAlias).
+			WithField("netlink", "LinkSetAlias").Debug("completed")
+	}
+
+	if err = handle.LinkSetUp(l); err != nil {
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "LinkSetUp").Debug("completed")
+
+	link.Store(ctx, outgoing, l)
+
+	return nil
+}
+
+// Delete deletes the vlan sub-interface for the vlan mechanism of the conn from the target network namespace
+func Delete(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, outgoing bool) error {
+	mechanism := ToMechanism(conn.GetMechanism())
+	if mechanism == nil {
+		return nil
+	}
+	ifaceName := mechanism.GetParameters()[kernel.InterfaceNameKey]
+	if ifaceName == "" {
+		return errors.Errorf("vlan interface name not provided")
+	}
+	netNsURL := mechanism.GetParameters()[kernel.NetNSURL]
+	if netNsURL == "" {
+		return errors.Errorf("vlan inode URL not provided")
+	}
+
+	handle, err := nl.FromURL(netNsURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Close()
+
+	l, err := handle.LinkByName(ifaceName)
+	if err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", ifaceName).
+			WithField("netlink", "LinkByName").Debug("NotFound")
+		link.Delete(ctx, outgoing)
+		return nil
+	}
+
+	if err = handle.LinkDel(l); err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", ifaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkDel").Debug("error")
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", ifaceName).
+		WithField("netlink", "LinkDel").Info("completed")
+	link.Delete(ctx, outgoing)
+
+	return nil
+}
+
+func linuxIfaceName(ifaceName string) string {
+	if len(ifaceName) <= kernel.LinuxIfMaxLength {
+		return ifaceName
+	}
+	return ifaceName[:kernel.LinuxIfMaxLength]
+}
+
+// adoptable reports whether l is the vlan sub-interface created by the forwarder for the connection on the
+// VLAN vlanID of the parent interface parentIndex
+func adoptable(ctx context.Context, l netlink.Link, netNSURL string, parentIndex, vlanID int) bool {
+	vlanLink, ok := l.(*netlink.Vlan)
+	return ok && link.Owned(ctx, l, netNSURL) &&
+		vlanLink.VlanId == vlanID &&
+		vlanLink.ParentIndex == parentIndex
+}
+
+// newVLAN returns the 802.1Q sub-interface to create on the parent interface parentIndex. A zero mtu leaves it to the
+// kernel.
+func newVLAN(ifaceName string, parentIndex, vlanID, mtu int) *netlink.Vlan {
+	return &netlink.Vlan{
+		LinkAttrs: netlink.LinkAttrs{
+			Name:        ifaceName,
+			Group:       link.Group,
+			MTU:         mtu,
+			ParentIndex: parentIndex,
+		},
+		VlanId:       vlanID,
+		VlanProtocol: netlink.VLAN_PROTOCOL_8021Q,
+	}
+}
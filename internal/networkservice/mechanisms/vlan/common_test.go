@@ -0,0 +1,245 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package vlan
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	parent    = "eth1"
+	parentMTU = 1500
+)
+
+type ctxServer struct {
+	ctx context.Context
+}
+
+func (s *ctxServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	s.ctx = ctx
+	return request.GetConnection(), nil
+}
+
+func (s *ctxServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+// metadataContext returns a context carrying the per connection metadata of connID, as the elements following the
+// metadata server in a chain get it
+func metadataContext(t *testing.T, connID string) context.Context {
+	t.Helper()
+	s := &ctxServer{}
+	request := &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: connID}}
+	if _, err := chain.NewNetworkServiceServer(metadata.NewServer(), s).Request(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	return s.ctx
+}
+
+func newConn(connID, netNSURL, vlanID string, mtu uint32) *networkservice.Connection {
+	return &networkservice.Connection{
+		Id: connID,
+		Mechanism: &networkservice.Mechanism{
+			Cls:  cls.REMOTE,
+			Type: MECHANISM,
+			Parameters: map[string]string{
+				kernel.InterfaceNameKey: "nsm-1",
+				kernel.NetNSURL:         netNSURL,
+				VLANID:                  vlanID,
+			},
+		},
+		Context: &networkservice.ConnectionContext{MTU: mtu},
+		Labels:  map[string]string{"podName": "nsc-1"},
+	}
+}
+
+// newFake returns a fake with the parent interface in the host namespace and the network namespaces netNSURLs
+func newFake(t *testing.T, netNSURLs ...string) (*nlhandle.Fake, netlink.Link) {
+	t.Helper()
+	fake := nlhandle.NewFake()
+	for _, netNSURL := range netNSURLs {
+		fake.AddNamespace(netNSURL)
+	}
+	handle, err := fake.Host()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	parentLink := &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: parent, MTU: parentMTU}}
+	if err = handle.LinkAdd(parentLink); err != nil {
+		t.Fatal(err)
+	}
+	return fake, parentLink
+}
+
+func linkByName(t *testing.T, fake *nlhandle.Fake, netNSURL, name string) (netlink.Link, error) {
+	t.Helper()
+	handle, err := fake.FromURL(netNSURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	return handle.LinkByName(name)
+}
+
+func TestCreate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		vlanID  string
+		mtu     uint32
+		wantMTU int
+		wantErr string
+	}{
+		{name: "lowest VLAN ID", vlanID: "1", mtu: 1400, wantMTU: 1400},
+		{name: "highest VLAN ID", vlanID: "4094", mtu: 1400, wantMTU: 1400},
+		{name: "MTU clamped to the parent", vlanID: "100", mtu: 9000, wantMTU: parentMTU},
+		{name: "MTU of the parent by default", vlanID: "100", wantMTU: parentMTU},
+		{name: "missing VLAN ID", wantErr: "not provided or out of the 1-4094 range"},
+		{name: "reserved VLAN ID 0", vlanID: "0", wantErr: "not provided or out of the 1-4094 range"},
+		{name: "reserved VLAN ID 4095", vlanID: "4095", wantErr: "not provided or out of the 1-4094 range"},
+		{name: "invalid VLAN ID", vlanID: "vlan100", wantErr: "not provided or out of the 1-4094 range"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			const netNSURL = "inode://4/1001"
+			fake, parentLink := newFake(t, netNSURL)
+			ctx := metadataContext(t, "conn-1")
+			conn := newConn("conn-1", netNSURL, tc.vlanID, tc.mtu)
+
+			err := Create(ctx, fake, conn, true, parent)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l, err := linkByName(t, fake, netNSURL, "nsm-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			vlanLink, ok := l.(*netlink.Vlan)
+			if !ok {
+				t.Fatalf("got a link of type %s, want vlan", l.Type())
+			}
+			if want, _ := strconv.Atoi(tc.vlanID); vlanLink.VlanId != want {
+				t.Errorf("got VLAN ID %d, want %d", vlanLink.VlanId, want)
+			}
+			if vlanLink.ParentIndex != parentLink.Attrs().Index {
+				t.Errorf("got parent index %d, want %d", vlanLink.ParentIndex, parentLink.Attrs().Index)
+			}
+			if vlanLink.MTU != tc.wantMTU {
+				t.Errorf("got MTU %d, want %d", vlanLink.MTU, tc.wantMTU)
+			}
+			if vlanLink.Group != link.Group {
+				t.Errorf("got group %d, want %d", vlanLink.Group, link.Group)
+			}
+		})
+	}
+}
+
+func TestCreateRefresh(t *testing.T) {
+	const netNSURL = "inode://4/1001"
+	fake, _ := newFake(t, netNSURL)
+	ctx := metadataContext(t, "conn-1")
+	conn := newConn("conn-1", netNSURL, "100", 0)
+
+	if err := Create(ctx, fake, conn, true, parent); err != nil {
+		t.Fatal(err)
+	}
+	before, err := linkByName(t, fake, netNSURL, "nsm-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The refresh of the connection keeps the sub-interface, the VLAN it is on is not in use by another connection
+	if err = Create(ctx, fake, conn, true, parent); err != nil {
+		t.Fatal(err)
+	}
+	after, err := linkByName(t, fake, netNSURL, "nsm-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Attrs().Index != before.Attrs().Index {
+		t.Error("the sub-interface was recreated by the refresh")
+	}
+}
+
+func TestCreateVLANInUse(t *testing.T) {
+	const netNSURL1, netNSURL2 = "inode://4/1001", "inode://4/1002"
+	fake, _ := newFake(t, netNSURL1, netNSURL2)
+	if err := Create(metadataContext(t, "conn-1"), fake, newConn("conn-1", netNSURL1, "100", 0), true, parent); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := metadataContext(t, "conn-2")
+	err := Create(ctx, fake, newConn("conn-2", netNSURL2, "100", 0), true, parent)
+	if err == nil || !strings.Contains(err.Error(), "vlan 100 of eth1 is already attached to another connection") {
+		t.Fatalf("got error %v, want the VLAN to be in use", err)
+	}
+	if _, err = linkByName(t, fake, netNSURL1, "nsm-1"); err != nil {
+		t.Errorf("the sub-interface of the first connection is gone: %v", err)
+	}
+
+	// Another VLAN of the parent is available
+	if err = Create(ctx, fake, newConn("conn-2", netNSURL2, "101", 0), true, parent); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	const netNSURL = "inode://4/1001"
+	fake, _ := newFake(t, netNSURL)
+	ctx := metadataContext(t, "conn-1")
+	conn := newConn("conn-1", netNSURL, "100", 0)
+	if err := Create(ctx, fake, conn, true, parent); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := Delete(ctx, fake, conn, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := linkByName(t, fake, netNSURL, "nsm-1"); err == nil {
+		t.Error("the sub-interface was not deleted")
+	}
+	if _, ok := link.Load(ctx, true); ok {
+		t.Error("the sub-interface is still cached")
+	}
+
+	// The VLAN is available again once the connection is closed
+	if err := Create(metadataContext(t, "conn-2"), fake, newConn("conn-2", netNSURL, "100", 0), true, parent); err != nil {
+		t.Fatal(err)
+	}
+}
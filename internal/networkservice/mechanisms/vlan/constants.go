@@ -0,0 +1,37 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package vlan implements the VLAN remote mechanism, which attaches the client pods directly to a VLAN of the uplink
+// of the node instead of tunneling to another forwarder. The endpoint serving the VLAN, typically a network service
+// endpoint outside of the cluster, selects the mechanism and sets its VLAN ID; Create builds the 802.1Q
+// sub-interface of the configured parent interface and moves it into the pod network namespace. A VLAN can only be
+// attached to one connection of the node at a time. The mechanism is only ever requested by the forwarder, so there
+// is no server element for it.
+package vlan
+
+const (
+	// MECHANISM string
+	MECHANISM = "VLAN"
+
+	// VLANID - VLAN ID parameter, set by the endpoint
+	VLANID = "vlanID"
+
+	// The valid 802.1Q VLAN IDs, 0 and 4095 are reserved
+	minVLANID = 1
+	maxVLANID = 4094
+)
@@ -0,0 +1,56 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package vlan
+
+import (
+	"strconv"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+)
+
+// Mechanism - helper for the VLAN mechanism parameters
+type Mechanism struct {
+	*networkservice.Mechanism
+}
+
+// ToMechanism - convert unified mechanism to helper type
+func ToMechanism(m *networkservice.Mechanism) *Mechanism {
+	if m.GetType() == MECHANISM {
+		if m.Parameters == nil {
+			m.Parameters = map[string]string{}
+		}
+		return &Mechanism{Mechanism: m}
+	}
+	return nil
+}
+
+// VLANID returns the VLAN ID of the mechanism, or 0 if it is not set or invalid
+func (m *Mechanism) VLANID() int {
+	id, err := strconv.Atoi(m.GetParameters()[VLANID])
+	if err != nil || id < minVLANID || id > maxVLANID {
+		return 0
+	}
+	return id
+}
+
+// SetVLANID sets the VLAN ID of the mechanism
+func (m *Mechanism) SetVLANID(id int) *Mechanism {
+	m.GetParameters()[VLANID] = strconv.Itoa(id)
+	return m
+}
@@ -2,6 +2,7 @@ package vxlan
 
 import (
 	"context"
+	"encoding/base64"
 	"net"
 
 	"github.com/golang/protobuf/ptypes/empty"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/networkservicemesh/api/pkg/api/networkservice"
 	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	vxlanMech "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
 	"github.com/networkservicemesh/api/pkg/api/networkservice/payload"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms/vxlan/vni"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
@@ -16,13 +18,15 @@ import (
 )
 
 type vxlanClient struct {
+	options *vxlanOptions
 }
 
 // NewClient - returns a new client for the vxlan remote mechanism
-func NewClient(tunnelIP net.IP) networkservice.NetworkServiceClient {
+func NewClient(tunnelIP net.IP, options ...Option) networkservice.NetworkServiceClient {
+	o := newOptions(options)
 	return chain.NewNetworkServiceClient(
-		&vxlanClient{},
-		vni.NewClient(tunnelIP, vni.WithTunnelPort(vxlanDefaultPort)),
+		&vxlanClient{options: o},
+		vni.NewClient(tunnelIP, vni.WithTunnelPort(o.tunnelPort)),
 	)
 }
 
@@ -36,6 +40,23 @@ func (v *vxlanClient) Request(ctx context.Context, request *networkservice.Netwo
 		Type:       MECHANISM,
 		Parameters: make(map[string]string),
 	}
+	if v.options.altTunnelIP != nil {
+		mechanism.Parameters[SrcAltIP] = v.options.altTunnelIP.String()
+	}
+	if v.options.ipsec != nil {
+		// The selected mechanism of a refresh is the one of the connection, it gets the nonce as well
+		current := vxlanMech.ToMechanism(request.GetConnection().GetMechanism())
+		nonce, err := v.options.ipsec.Nonce(servedConnID(request.GetConnection()), decodeNonce(current, SrcIPsecNonce))
+		if err != nil {
+			return nil, err
+		}
+		mechanism.Parameters[SrcIPsecKey] = base64.StdEncoding.EncodeToString(v.options.ipsec.PublicKey())
+		mechanism.Parameters[SrcIPsecNonce] = base64.StdEncoding.EncodeToString(nonce)
+		if current != nil {
+			current.GetParameters()[SrcIPsecKey] = mechanism.Parameters[SrcIPsecKey]
+			current.GetParameters()[SrcIPsecNonce] = mechanism.Parameters[SrcIPsecNonce]
+		}
+	}
 	request.MechanismPreferences = append(request.MechanismPreferences, mechanism)
 
 	conn, err := next.Client(ctx).Request(ctx, request, opts...)
@@ -49,3 +70,13 @@ func (v *vxlanClient) Request(ctx context.Context, request *networkservice.Netwo
 func (v *vxlanClient) Close(ctx context.Context, conn *networkservice.Connection, opts ...grpc.CallOption) (*empty.Empty, error) {
 	return next.Client(ctx).Close(ctx, conn, opts...)
 }
+
+// servedConnID returns the id of the connection the forwarder serves, the path segment preceding the one of the conn
+// of the client
+func servedConnID(conn *networkservice.Connection) string {
+	path := conn.GetPath()
+	if path.GetIndex() == 0 || int(path.GetIndex()) > len(path.GetPathSegments()) {
+		return ""
+	}
+	return path.GetPathSegments()[path.GetIndex()-1].GetId()
+}
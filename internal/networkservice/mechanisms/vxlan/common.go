@@ -2,9 +2,8 @@ package vxlan
 
 import (
 	"context"
+	"encoding/base64"
 	"net"
-	"os"
-	"strconv"
 
 	"github.com/networkservicemesh/api/pkg/api/networkservice"
 	vxlanMech "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
@@ -13,30 +12,19 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
-	kernellink "github.com/networkservicemesh/sdk-kernel/pkg/kernel"
-	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/tools/nshandle"
 
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/connstate"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/ipsec"
 	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
-	"github.com/safchain/ethtool"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
 	"github.com/vishvananda/netlink"
 )
 
-func ethtoolSetTxOff(iface string, config map[string]bool) error {
-	ethHandle, err := ethtool.NewEthtool()
-	if err != nil {
-		return err
-	}
-	defer ethHandle.Close()
-
-	err = ethHandle.Change(iface, config)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func Create(ctx context.Context, conn *networkservice.Connection, outgoing bool) error {
+// Create creates the vxlan link for the vxlan mechanism of the conn and moves it to the target network namespace.
+// All netlink operations are performed through the handles returned by nl.
+func Create(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, outgoing bool, options ...Option) error {
+	o := newOptions(options)
 	logger := log.FromContext(ctx).WithField("vxlan", "Intf create")
 	if mechanism := vxlanMech.ToMechanism(conn.GetMechanism()); mechanism != nil {
 		if mechanism.GetParameters() == nil {
@@ -76,17 +64,37 @@ func Create(ctx context.Context, conn *networkservice.Connection, outgoing bool)
 			egressIP = mechanism.SrcIP()
 		}
 
+		if isIPv4(egressIP) != isIPv4(remoteIP) {
+			return errors.Errorf("vxlan local IP %s and remote IP %s are of different address families", egressIP, remoteIP)
+		}
+
 		vni := mechanism.VNI()
 
+		// The vxlan link sends to the port it listens on, so both forwarders have to use the same one
+		remotePort := mechanism.SrcPort()
+		if outgoing {
+			remotePort = mechanism.DstPort()
+		}
+		if remotePort != 0 && remotePort != o.tunnelPort {
+			return errors.Errorf("vxlan port mismatch: local %d, remote %d", o.tunnelPort, remotePort)
+		}
+
 		logger.Infof("netnsurl: %v: iface: %v: srcIP: %s: dstIP: %s: vni: %v", netNsUrl, ifaceName, egressIP.String(), remoteIP.String(), vni)
 
 		// Construct the netlink handle for the target network namespace for this kernel interface
-		handle, err := kernellink.GetNetlinkHandle(netNsUrl)
+		handle, err := nl.FromURL(netNsUrl)
 		if err != nil {
 			return errors.WithStack(err)
 		}
 		defer handle.Close()
 
+		// Construct the netlink handle for the forwarder namespace where the vxlan link is created
+		hostHandle, err := nl.Host()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer hostHandle.Close()
+
 		// The cache only contains links created by the forwarder. Check the cache for the link.
 		// If the link is present, treat the Link Create request as redundant and return.
 		if _, ok := link.Load(ctx, outgoing); ok {
@@ -96,13 +104,24 @@ func Create(ctx context.Context, conn *networkservice.Connection, outgoing bool)
 			}
 		}
 
-		// Forwarder is not aware of the link since it is not present in the cache.
-		// Delete the previous kernel interface if there is one in the target namespace, it could be a stale/dangling interface.
+		// Forwarder is not aware of the link since it is not present in the cache. After a restart, the link created
+		// for the connection by the previous forwarder instance may still be in place: take it over if it still
+		// tunnels to the same remote with the same VNI, so that the traffic is not interrupted.
+		if prevLink, err := handle.LinkByName(ifaceName); err == nil && adoptable(ctx, prevLink, netNsUrl, egressIP, remoteIP, int(vni), o.tunnelPort) {
+			if err = acquireIPsec(ctx, o.ipsec, conn.GetId(), hostHandle, mechanism, outgoing, egressIP, remoteIP, o.tunnelPort); err != nil {
+				return err
+			}
+			link.Adopt(ctx, outgoing, prevLink)
+			return nil
+		}
+
+		// Otherwise delete the previous kernel interface if there is one in the target namespace, it could be a stale/dangling interface.
 		var prevLink netlink.Link
 		if prevLink, err = handle.LinkByName(ifaceName); err == nil {
 			if err = handle.LinkDel(prevLink); err != nil {
 				return errors.WithStack(err)
 			}
+			metrics.StaleLinkDeletions.Inc("vxlan")
 			log.FromContext(ctx).
 				WithField("link.Name", prevLink.Attrs().Name).
 				WithField("netlink", "LinkDel").Debug("completed")
@@ -110,13 +129,14 @@ func Create(ctx context.Context, conn *networkservice.Connection, outgoing bool)
 
 		// Create the vxlan link in the host network namespace. It will be inserted into the target namespace later on in the func.
 		fwdNsIfaceName := getVxlanLinkName(conn.GetId())
-		if err := netlink.LinkAdd(newVXLAN(ctx, fwdNsIfaceName, egressIP, remoteIP, int(vni))); err != nil {
+		vxlanLink := newVXLAN(fwdNsIfaceName, egressIP, remoteIP, int(vni), o.tunnelPort, int(conn.GetContext().GetMTU()))
+		if err := hostHandle.LinkAdd(vxlanLink); err != nil {
 			return errors.Wrapf(err, "failed to create VXLAN interface")
 		}
 
 		log.FromContext(ctx).WithField("link.Name", fwdNsIfaceName).WithField("netlink", "LinkAdd vxlan").Debug("completed")
 
-		if os.Getenv("NSM_VXLAN_CHECKSUM_OFFLOAD") == "disable" {
+		if !o.checksumOffload {
 
 			var ifaceConfig = map[string]bool{
 				"tx-checksum-ip-generic": false,
@@ -126,7 +146,7 @@ func Create(ctx context.Context, conn *networkservice.Connection, outgoing bool)
 				"tx-checksum-fcoe-crc":   false,
 			}
 
-			err = ethtoolSetTxOff(fwdNsIfaceName, ifaceConfig)
+			err = hostHandle.EthtoolChange(fwdNsIfaceName, ifaceConfig)
 			if err != nil {
 				// This is a best effort operation. Some platforms might not have the checksum features
 				// we are looking to turn off.
@@ -137,7 +157,7 @@ func Create(ctx context.Context, conn *networkservice.Connection, outgoing bool)
 			}
 		}
 
-		l, err := netlink.LinkByName(fwdNsIfaceName)
+		l, err := hostHandle.LinkByName(fwdNsIfaceName)
 		if err != nil {
 			log.FromContext(ctx).
 				WithField("link.Name", fwdNsIfaceName).
@@ -146,15 +166,12 @@ func Create(ctx context.Context, conn *networkservice.Connection, outgoing bool)
 			return errors.WithStack(err)
 		}
 
-		// Construct the nsHandle for the target namespace for this kernel interface
-		nsHandle, err := nshandle.FromURL(mechanism.GetParameters()["inodeURL"])
-		if err != nil {
-			return errors.WithStack(err)
+		if err = acquireIPsec(ctx, o.ipsec, conn.GetId(), hostHandle, mechanism, outgoing, egressIP, remoteIP, uint16(vxlanLink.Port)); err != nil {
+			return err
 		}
-		defer func() { _ = nsHandle.Close() }()
 
 		// Insert the link in the target namespace
-		if err = netlink.LinkSetNsFd(l, int(nsHandle)); err != nil {
+		if err = hostHandle.LinkSetNs(l, netNsUrl); err != nil {
 			return errors.Wrapf(err, "unable to change to netns")
 		}
 		log.FromContext(ctx).
@@ -185,17 +202,14 @@ func Create(ctx context.Context, conn *networkservice.Connection, outgoing bool)
 			WithField("netlink", "LinkSetName").Debug("completed")
 
 		// Set link alias to the name of the network service client pod requesting for this link.
-		if conn.GetLabels() != nil {
-			linkAlias := conn.GetLabels()["podName"]
-			if linkAlias != "" {
-				if err = handle.LinkSetAlias(l, linkAlias); err != nil {
-					return errors.WithStack(err)
-				}
-				log.FromContext(ctx).
-					WithField("link.Name", l.Attrs().Name).
-					WithField("alias", linkAlias).
-					WithField("netlink", "LinkSetAlias").Debug("completed")
+		if linkAlias := link.Alias(conn.GetLabels()["podName"]); linkAlias != "" {
+			if err = handle.LinkSetAlias(l, linkAlias); err != nil {
+				return errors.WithStack(err)
 			}
+			log.FromContext(ctx).
+				WithField("link.Name", l.Attrs().Name).
+				WithField("alias", linkAlias).
+				WithField("netlink", "LinkSetAlias").Debug("completed")
 		}
 
 		// Set the state of the link to UP.
@@ -214,7 +228,9 @@ func Create(ctx context.Context, conn *networkservice.Connection, outgoing bool)
 	return nil
 }
 
-func Delete(ctx context.Context, conn *networkservice.Connection, outgoing bool) error {
+// Delete deletes the vxlan link for the vxlan mechanism of the conn from the target network namespace
+func Delete(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, outgoing bool, options ...Option) error {
+	o := newOptions(options)
 	if mechanism := vxlanMech.ToMechanism(conn.GetMechanism()); mechanism != nil {
 		if mechanism.GetParameters() == nil {
 			return errors.Errorf("vxlan delete: link parameters not provided")
@@ -232,12 +248,19 @@ func Delete(ctx context.Context, conn *networkservice.Connection, outgoing bool)
 		}
 
 		// Construct the netlink handle for the target namespace for this kernel interface
-		handle, err := kernellink.GetNetlinkHandle(netNsUrl)
+		handle, err := nl.FromURL(netNsUrl)
 		if err != nil {
 			return errors.WithStack(err)
 		}
 		defer handle.Close()
 
+		// The vxlan traffic towards the remote node stops being protected once the last tunnel to it is deleted
+		if hostHandle, err := nl.Host(); err == nil {
+			egressIP, remoteIP := tunnelEndpoints(mechanism, outgoing)
+			o.ipsec.Release(ctx, conn.GetId(), hostHandle, egressIP, remoteIP)
+			hostHandle.Close()
+		}
+
 		links, err := handle.LinkList()
 		if err != nil {
 			log.FromContext(ctx).
@@ -258,6 +281,7 @@ func Delete(ctx context.Context, conn *networkservice.Connection, outgoing bool)
 			log.FromContext(ctx).
 				WithField("link.Name", ifaceName).
 				WithField("netlink", "LinkByName").Debug("NotFound")
+			link.Delete(ctx, outgoing)
 			return nil
 		}
 
@@ -278,6 +302,114 @@ func Delete(ctx context.Context, conn *networkservice.Connection, outgoing bool)
 	return nil
 }
 
+// acquireIPsec protects the vxlan traffic of the connection connID between egressIP and remoteIP if both forwarders
+// have ipsec enabled
+func acquireIPsec(ctx context.Context, m *ipsec.Manager, connID string, hostHandle nlhandle.Handle, mechanism *vxlanMech.Mechanism,
+	outgoing bool, egressIP, remoteIP net.IP, port uint16) error {
+	if m == nil {
+		return nil
+	}
+	peerKey, err := ipsecPeerKey(mechanism, outgoing)
+	if err != nil {
+		return err
+	}
+	if peerKey == nil {
+		log.FromContext(ctx).WithField("vxlan", "ipsec").Warnf("remote forwarder %s does not have ipsec enabled, the tunnel is not protected", remoteIP)
+		return nil
+	}
+	return m.Acquire(ctx, connID, hostHandle, egressIP, remoteIP, port, peerKey, ipsecNonces(mechanism))
+}
+
+// RestoreIPsec hands the vxlan connections of the records, persisted by the previous forwarder instance, over to the
+// ipsec manager of the options, so that the SAs protecting their tunnels are removed once the last of them is
+// closed, whether they are refreshed after the restart or not. The SAs in place are looked up through the host
+// handle of nl.
+func RestoreIPsec(nl nlhandle.Provider, records []*connstate.Record, options ...Option) error {
+	o := newOptions(options)
+	if o.ipsec == nil {
+		return nil
+	}
+	hostHandle, err := nl.Host()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer hostHandle.Close()
+
+	for _, record := range records {
+		// The remote mechanism is the source one of the incoming connections and the destination one of the outgoing
+		// connections
+		mechanism, outgoing := vxlanMech.ToMechanism(record.SrcMechanism), false
+		if mechanism == nil {
+			mechanism, outgoing = vxlanMech.ToMechanism(record.DstMechanism), true
+		}
+		if mechanism == nil {
+			continue
+		}
+		peerKey, err := ipsecPeerKey(mechanism, outgoing)
+		if err != nil || peerKey == nil {
+			continue
+		}
+		egressIP, remoteIP := tunnelEndpoints(mechanism, outgoing)
+		if err = o.ipsec.Restore(record.ID, hostHandle, egressIP, remoteIP, o.tunnelPort, peerKey, ipsecNonces(mechanism)); err != nil {
+			return errors.Wrapf(err, "failed to restore the ipsec protection of %s", record.ID)
+		}
+	}
+	return nil
+}
+
+// ipsecPeerKey returns the ipsec public key of the remote forwarder, nil if it does not have ipsec enabled
+func ipsecPeerKey(mechanism *vxlanMech.Mechanism, outgoing bool) ([]byte, error) {
+	peerKeyParam := SrcIPsecKey
+	if outgoing {
+		peerKeyParam = DstIPsecKey
+	}
+	encodedKey, ok := mechanism.GetParameters()[peerKeyParam]
+	if !ok {
+		return nil, nil
+	}
+	peerKey, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid ipsec key")
+	}
+	return peerKey, nil
+}
+
+// ipsecNonces returns the ipsec nonces contributed to the connection of the mechanism by both forwarders
+func ipsecNonces(mechanism *vxlanMech.Mechanism) ipsec.Nonces {
+	return ipsec.Nonces{
+		Src: decodeNonce(mechanism, SrcIPsecNonce),
+		Dst: decodeNonce(mechanism, DstIPsecNonce),
+	}
+}
+
+// decodeNonce returns the ipsec nonce of the parameter param of the mechanism, nil if it is not set or invalid
+func decodeNonce(mechanism *vxlanMech.Mechanism, param string) []byte {
+	nonce, err := base64.StdEncoding.DecodeString(mechanism.GetParameters()[param])
+	if err != nil || len(nonce) != ipsec.NonceLen {
+		return nil
+	}
+	return nonce
+}
+
+// tunnelEndpoints returns the local and remote IPs of the vxlan tunnel of the mechanism, see Create
+func tunnelEndpoints(mechanism *vxlanMech.Mechanism, outgoing bool) (egressIP, remoteIP net.IP) {
+	if outgoing {
+		return mechanism.SrcIP(), mechanism.DstIP()
+	}
+	return mechanism.DstIP(), mechanism.SrcIP()
+}
+
+// adoptable reports whether l is the vxlan link created by the forwarder for the connection between egressIP
+// and remoteIP with the given VNI and port
+func adoptable(ctx context.Context, l netlink.Link, netNSURL string, egressIP, remoteIP net.IP, vni int, port uint16) bool {
+	vxlanLink, ok := l.(*netlink.Vxlan)
+	return ok && link.Owned(ctx, l, netNSURL) &&
+		vxlanLink.VxlanId == vni &&
+		vxlanLink.Group.Equal(remoteIP) &&
+		vxlanLink.SrcAddr.Equal(egressIP) &&
+		vxlanLink.Port == int(port)
+}
+
 func getVxlanLinkName(connId string) string {
 	return linuxIfaceName(connId)
 }
@@ -289,21 +421,38 @@ func linuxIfaceName(ifaceName string) string {
 	return ifaceName[:kernel.LinuxIfMaxLength]
 }
 
-func newVXLAN(ctx context.Context, ifaceName string, egressIP, remoteIP net.IP, vni int) *netlink.Vxlan {
-	logger := log.FromContext(ctx).WithField("vxlan", "init")
-	vxlanPortNum, err := strconv.Atoi(os.Getenv("NSM_TUNNEL_PORT"))
-	if err != nil {
-		vxlanPortNum = vxlanDefaultPort
-		logger.Debugf("Vxlan port not provided. Using default value: %v\n", vxlanPortNum)
+// Overhead returns the encapsulation overhead of the vxlan links for the mechanism, which depends on the address
+// family of the underlay and on the ipsec protection agreed by both forwarders. It is 0 for other mechanism types.
+func Overhead(m *networkservice.Mechanism) int {
+	mechanism := vxlanMech.ToMechanism(m)
+	if mechanism == nil {
+		return 0
 	}
+	overhead := overheadIPv6
+	if isIPv4(mechanism.SrcIP()) {
+		overhead = overheadIPv4
+	}
+	params := mechanism.GetParameters()
+	if params[SrcIPsecKey] != "" && params[DstIPsecKey] != "" {
+		overhead += overheadESP
+	}
+	return overhead
+}
+
+// newVXLAN returns the vxlan link to create. A zero mtu leaves it to the kernel.
+func newVXLAN(ifaceName string, egressIP, remoteIP net.IP, vni int, port uint16, mtu int) *netlink.Vxlan {
+	// netlink sets the IFLA_VXLAN_LOCAL6/GROUP6 attributes instead of the IPv4 ones for IPv6 addresses, which makes
+	// the kernel open an IPv6 socket for the link
 	/* Populate the VXLAN interface configuration */
 	return &netlink.Vxlan{
 		LinkAttrs: netlink.LinkAttrs{
-			Name: ifaceName,
+			Name:  ifaceName,
+			Group: link.Group,
+			MTU:   mtu,
 		},
 		VxlanId: vni,
 		Group:   remoteIP,
-		Port:    vxlanPortNum,
+		Port:    int(port),
 		SrcAddr: egressIP,
 	}
 }
@@ -0,0 +1,268 @@
+package vxlan
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	vxlanMech "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/ipsec"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	connID   = "conn-1"
+	netNSURL = "inode://4/1001"
+)
+
+var (
+	srcIP = net.ParseIP("10.0.0.1")
+	dstIP = net.ParseIP("10.0.0.2")
+)
+
+type ctxServer struct {
+	ctx context.Context
+}
+
+func (s *ctxServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	s.ctx = ctx
+	return request.GetConnection(), nil
+}
+
+func (s *ctxServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+// metadataContext returns a context carrying the per connection metadata of connID, as the elements following the
+// metadata server in a chain get it
+func metadataContext(t *testing.T) context.Context {
+	t.Helper()
+	s := &ctxServer{}
+	request := &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: connID}}
+	if _, err := chain.NewNetworkServiceServer(metadata.NewServer(), s).Request(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	return s.ctx
+}
+
+func newConn(src, dst net.IP, port uint16) *networkservice.Connection {
+	mechanism := &networkservice.Mechanism{
+		Cls:  cls.REMOTE,
+		Type: MECHANISM,
+		Parameters: map[string]string{
+			"name":     "nsm-1",
+			"inodeURL": netNSURL,
+		},
+	}
+	vxlanMech.ToMechanism(mechanism).SetSrcIP(src).SetDstIP(dst).SetVNI(42).SetSrcPort(port).SetDstPort(port)
+	return &networkservice.Connection{
+		Id:        connID,
+		Mechanism: mechanism,
+		Labels:    map[string]string{"podName": "nsc-1"},
+	}
+}
+
+func newFake() *nlhandle.Fake {
+	fake := nlhandle.NewFake()
+	fake.AddNamespace(netNSURL)
+	return fake
+}
+
+func TestCreate(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		conn         *networkservice.Connection
+		outgoing     bool
+		options      []Option
+		wantErr      bool
+		wantLocal    net.IP
+		wantRemote   net.IP
+		wantFeatures map[string]bool
+	}{
+		{
+			name:       "outgoing",
+			conn:       newConn(srcIP, dstIP, vxlanDefaultPort),
+			outgoing:   true,
+			wantLocal:  srcIP,
+			wantRemote: dstIP,
+		},
+		{
+			name:       "incoming",
+			conn:       newConn(srcIP, dstIP, vxlanDefaultPort),
+			wantLocal:  dstIP,
+			wantRemote: srcIP,
+		},
+		{
+			name:         "checksum offload disabled",
+			conn:         newConn(srcIP, dstIP, vxlanDefaultPort),
+			outgoing:     true,
+			options:      []Option{WithChecksumOffload(false)},
+			wantLocal:    srcIP,
+			wantRemote:   dstIP,
+			wantFeatures: map[string]bool{"tx-checksum-ip-generic": false, "tx-checksum-ipv4": false},
+		},
+		{
+			name:     "port mismatch",
+			conn:     newConn(srcIP, dstIP, vxlanDefaultPort+1),
+			outgoing: true,
+			wantErr:  true,
+		},
+		{
+			name:     "address family mismatch",
+			conn:     newConn(srcIP, net.ParseIP("fd00::2"), vxlanDefaultPort),
+			outgoing: true,
+			wantErr:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFake()
+			ctx := metadataContext(t)
+			err := Create(ctx, fake, tc.conn, tc.outgoing, tc.options...)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			handle, _ := fake.FromURL(netNSURL)
+			defer handle.Close()
+			l, err := handle.LinkByName("nsm-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			vxlanLink, ok := l.(*netlink.Vxlan)
+			if !ok {
+				t.Fatalf("got a %s link, want a vxlan", l.Type())
+			}
+			if vxlanLink.VxlanId != 42 || vxlanLink.Port != vxlanDefaultPort ||
+				!vxlanLink.SrcAddr.Equal(tc.wantLocal) || !vxlanLink.Group.Equal(tc.wantRemote) {
+				t.Errorf("got vni %d, port %d, local %s, remote %s", vxlanLink.VxlanId, vxlanLink.Port, vxlanLink.SrcAddr, vxlanLink.Group)
+			}
+			if attrs := l.Attrs(); attrs.Group != link.Group || attrs.Alias != "nsc-1" || attrs.Flags&net.FlagUp == 0 {
+				t.Errorf("got group %d, alias %q, flags %v", attrs.Group, attrs.Alias, attrs.Flags)
+			}
+			features, err := fake.Features(netNSURL, "nsm-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for feature, want := range tc.wantFeatures {
+				if got, ok := features[feature]; !ok || got != want {
+					t.Errorf("feature %s: got %v, want %v", feature, got, want)
+				}
+			}
+			if tc.wantFeatures == nil && len(features) != 0 {
+				t.Errorf("got features %v changed, want none", features)
+			}
+			if _, ok := link.Load(ctx, tc.outgoing); !ok {
+				t.Error("the link is not cached")
+			}
+
+			// A refresh leaves the link in place
+			if err = Create(ctx, fake, tc.conn, tc.outgoing, tc.options...); err != nil {
+				t.Fatal(err)
+			}
+			if refreshed, err := handle.LinkByName("nsm-1"); err != nil || refreshed.Attrs().Index != l.Attrs().Index {
+				t.Error("the link was recreated on refresh")
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	fake := newFake()
+	ctx := metadataContext(t)
+	conn := newConn(srcIP, dstIP, vxlanDefaultPort)
+	if err := Create(ctx, fake, conn, true); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := Delete(ctx, fake, conn, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handle, _ := fake.FromURL(netNSURL)
+	defer handle.Close()
+	if _, err := handle.LinkByName("nsm-1"); err == nil {
+		t.Error("the link was not deleted")
+	}
+	if _, ok := link.Load(ctx, true); ok {
+		t.Error("the link is still cached")
+	}
+}
+
+func TestCreateIPsec(t *testing.T) {
+	localManager, err := ipsec.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remoteManager, err := ipsec.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcNonce, err := localManager.Nonce(connID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstNonce, err := remoteManager.Nonce(connID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The parameters set by the vxlan client of the local forwarder and the vxlan server of the remote one
+	conn := newConn(srcIP, dstIP, vxlanDefaultPort)
+	params := conn.GetMechanism().GetParameters()
+	params[SrcIPsecKey] = base64.StdEncoding.EncodeToString(localManager.PublicKey())
+	params[SrcIPsecNonce] = base64.StdEncoding.EncodeToString(srcNonce)
+	params[DstIPsecKey] = base64.StdEncoding.EncodeToString(remoteManager.PublicKey())
+	params[DstIPsecNonce] = base64.StdEncoding.EncodeToString(dstNonce)
+	if got, want := Overhead(conn.GetMechanism()), overheadIPv4+overheadESP; got != want {
+		t.Errorf("got overhead %d, want %d", got, want)
+	}
+
+	localFake, remoteFake := newFake(), newFake()
+	localCtx, remoteCtx := metadataContext(t), metadataContext(t)
+	if err = Create(localCtx, localFake, conn, true, WithIPsec(localManager)); err != nil {
+		t.Fatal(err)
+	}
+	if err = Create(remoteCtx, remoteFake, conn, false, WithIPsec(remoteManager)); err != nil {
+		t.Fatal(err)
+	}
+
+	spis := func(fake *nlhandle.Fake) map[string]int {
+		rv := make(map[string]int)
+		for _, state := range fake.XfrmStates("") {
+			rv[state.Src.String()+"->"+state.Dst.String()] = state.Spi
+		}
+		return rv
+	}
+	localSPIs, remoteSPIs := spis(localFake), spis(remoteFake)
+	if len(localSPIs) != 2 {
+		t.Fatalf("got %d xfrm states, want 2", len(localSPIs))
+	}
+	for dir, spi := range localSPIs {
+		if remoteSPIs[dir] != spi {
+			t.Errorf("%s: got SPI %#x on the remote forwarder, want %#x", dir, remoteSPIs[dir], spi)
+		}
+	}
+
+	if err = Delete(localCtx, localFake, conn, true, WithIPsec(localManager)); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(localFake.XfrmStates("")); got != 0 {
+		t.Errorf("got %d xfrm states after the Delete, want 0", got)
+	}
+}
@@ -8,4 +8,24 @@ const (
 	// MECHANISM string
 	MECHANISM        = vxlan.MECHANISM
 	vxlanDefaultPort = 4789
+
+	// The encapsulation overhead (outer Ethernet, IP, UDP and VXLAN headers) subtracted from the underlay MTU, per
+	// underlay address family
+	overheadIPv4 = 50
+	overheadIPv6 = 70
+	// overheadESP is the ESP header, IV, padding, trailer and ICV added by the AES-GCM transport mode SAs protecting
+	// the tunnels with ipsec, rounded up to a multiple of 4
+	overheadESP = 40
+
+	// SrcAltIP - tunnel IP of the other address family of a dual-stack source forwarder
+	SrcAltIP = "src_alt_ip"
+
+	// SrcIPsecKey - ipsec public key of the source forwarder, base64 encoded
+	SrcIPsecKey = "src_ipsec_key"
+	// DstIPsecKey - ipsec public key of the destination forwarder, base64 encoded
+	DstIPsecKey = "dst_ipsec_key"
+	// SrcIPsecNonce - ipsec nonce of the connection contributed by the source forwarder, base64 encoded
+	SrcIPsecNonce = "src_ipsec_nonce"
+	// DstIPsecNonce - ipsec nonce of the connection contributed by the destination forwarder, base64 encoded
+	DstIPsecNonce = "dst_ipsec_nonce"
 )
@@ -0,0 +1,65 @@
+package vxlan
+
+import (
+	"context"
+	"net"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	vxlanMech "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+)
+
+// tunnelIPs are the tunnel IPs of a forwarder, one per address family it has
+type tunnelIPs []net.IP
+
+func newTunnelIPs(tunnelIP net.IP, o *vxlanOptions) tunnelIPs {
+	ips := tunnelIPs{tunnelIP}
+	if o.altTunnelIP != nil {
+		ips = append(ips, o.altTunnelIP)
+	}
+	return ips
+}
+
+// forFamily returns the tunnel IP of the address family of ip, nil if there is none
+func (t tunnelIPs) forFamily(ip net.IP) net.IP {
+	for _, tunnelIP := range t {
+		if isIPv4(tunnelIP) == isIPv4(ip) {
+			return tunnelIP
+		}
+	}
+	return nil
+}
+
+func isIPv4(ip net.IP) bool {
+	return ip.To4() != nil
+}
+
+// familyServer selects the source tunnel IP the vxlan tunnel uses. It runs before the vni server, so that the
+// VNI is allocated for the source IP that is actually used.
+type familyServer struct {
+	tunnelIPs tunnelIPs
+}
+
+func (f *familyServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	mechanism := vxlanMech.ToMechanism(request.GetConnection().GetMechanism())
+	if mechanism == nil {
+		return next.Server(ctx).Request(ctx, request)
+	}
+
+	// Switch to the other tunnel IP of a dual-stack source forwarder if this one does not have the family of the
+	// advertised one
+	srcIP := mechanism.SrcIP()
+	altSrcIP := net.ParseIP(mechanism.GetParameters()[SrcAltIP])
+	if srcIP != nil && f.tunnelIPs.forFamily(srcIP) == nil && altSrcIP != nil && f.tunnelIPs.forFamily(altSrcIP) != nil {
+		mechanism.SetSrcIP(altSrcIP)
+		mechanism.GetParameters()[SrcAltIP] = srcIP.String()
+	}
+
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (f *familyServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
@@ -0,0 +1,62 @@
+package vxlan
+
+import (
+	"net"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/ipsec"
+)
+
+// Option configures the vxlan client, server and links
+type Option func(o *vxlanOptions)
+
+type vxlanOptions struct {
+	altTunnelIP     net.IP
+	tunnelPort      uint16
+	checksumOffload bool
+	ipsec           *ipsec.Manager
+}
+
+func newOptions(options []Option) *vxlanOptions {
+	o := &vxlanOptions{
+		tunnelPort:      vxlanDefaultPort,
+		checksumOffload: true,
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+	return o
+}
+
+// WithAltTunnelIP sets the tunnel IP of the other address family of a dual-stack node. Tunnels use the address
+// family of the source forwarder tunnel IP if both forwarders have it, the other one otherwise.
+func WithAltTunnelIP(ip net.IP) Option {
+	return func(o *vxlanOptions) {
+		o.altTunnelIP = ip
+	}
+}
+
+// WithTunnelPort sets the UDP port the vxlan links listen on and send to, advertised to the remote forwarders
+// through the mechanism parameters. Defaults to 4789.
+func WithTunnelPort(port uint16) Option {
+	return func(o *vxlanOptions) {
+		if port != 0 {
+			o.tunnelPort = port
+		}
+	}
+}
+
+// WithChecksumOffload sets whether the tx checksum offload features of the vxlan links stay enabled. Some
+// platforms corrupt the checksums of the encapsulated packets, disabling the offload works around it.
+func WithChecksumOffload(enabled bool) Option {
+	return func(o *vxlanOptions) {
+		o.checksumOffload = enabled
+	}
+}
+
+// WithIPsec enables the protection of the vxlan traffic with the XFRM SAs installed by m. The protection is only
+// established with the remote forwarders that have it enabled as well.
+func WithIPsec(m *ipsec.Manager) Option {
+	return func(o *vxlanOptions) {
+		o.ipsec = m
+	}
+}
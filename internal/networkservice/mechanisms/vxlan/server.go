@@ -2,27 +2,56 @@ package vxlan
 
 import (
 	"context"
+	"encoding/base64"
 	"net"
 
 	"github.com/golang/protobuf/ptypes/empty"
 
 	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	vxlanMech "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/common/mechanisms/vxlan/vni"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/pkg/errors"
 )
 
-type vxlanServer struct{}
+type vxlanServer struct {
+	tunnelIPs tunnelIPs
+	options   *vxlanOptions
+}
 
 // NewServer - returns a new server for the vxlan remote mechanism
-func NewServer(tunnelIP net.IP) networkservice.NetworkServiceServer {
+func NewServer(tunnelIP net.IP, options ...Option) networkservice.NetworkServiceServer {
+	o := newOptions(options)
+	ips := newTunnelIPs(tunnelIP, o)
 	return chain.NewNetworkServiceServer(
-		vni.NewServer(tunnelIP, vni.WithTunnelPort(vxlanDefaultPort)),
-		&vxlanServer{},
+		&familyServer{tunnelIPs: ips},
+		vni.NewServer(tunnelIP, vni.WithTunnelPort(o.tunnelPort)),
+		&vxlanServer{tunnelIPs: ips, options: o},
 	)
 }
 
 func (v *vxlanServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	if mechanism := vxlanMech.ToMechanism(request.GetConnection().GetMechanism()); mechanism != nil {
+		// The vni server advertises the primary tunnel IP, use the one of the family selected by the familyServer
+		// instead
+		dstIP := v.tunnelIPs.forFamily(mechanism.SrcIP())
+		if dstIP == nil {
+			return nil, errors.Errorf("no tunnel IP of the address family of the source tunnel IP %s", mechanism.SrcIP())
+		}
+		mechanism.SetDstIP(dstIP)
+
+		// Agree to protect the tunnel if the source forwarder offered it
+		if _, ok := mechanism.GetParameters()[SrcIPsecKey]; ok && v.options.ipsec != nil {
+			nonce, err := v.options.ipsec.Nonce(request.GetConnection().GetId(), decodeNonce(mechanism, DstIPsecNonce))
+			if err != nil {
+				return nil, err
+			}
+			mechanism.GetParameters()[DstIPsecKey] = base64.StdEncoding.EncodeToString(v.options.ipsec.PublicKey())
+			mechanism.GetParameters()[DstIPsecNonce] = base64.StdEncoding.EncodeToString(nonce)
+		}
+	}
+
 	conn, err := next.Server(ctx).Request(ctx, request)
 	if err != nil {
 		return nil, err
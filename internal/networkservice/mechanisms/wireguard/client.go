@@ -0,0 +1,101 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package wireguard
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/payload"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+type wireguardClient struct {
+	tunnel *Tunnel
+}
+
+// NewClient - returns a new client for the wireguard remote mechanism. The client offers the mechanism with the
+// tunnel IP, public key and a listen port of this forwarder as the source parameters.
+func NewClient(tunnel *Tunnel) networkservice.NetworkServiceClient {
+	return &wireguardClient{tunnel: tunnel}
+}
+
+func (w *wireguardClient) Request(ctx context.Context, request *networkservice.NetworkServiceRequest, opts ...grpc.CallOption) (*networkservice.Connection, error) {
+	if request.GetConnection().GetPayload() != payload.IP {
+		return next.Client(ctx).Request(ctx, request, opts...)
+	}
+
+	port, loaded := loadPort(ctx, true)
+	if !loaded {
+		var err error
+		if port, err = w.tunnel.allocatePort(recordedConnID(request.GetConnection(), true)); err != nil {
+			return nil, err
+		}
+	}
+
+	mechanism := &networkservice.Mechanism{
+		Cls:        cls.REMOTE,
+		Type:       MECHANISM,
+		Parameters: make(map[string]string),
+	}
+	ToMechanism(mechanism).
+		SetSrcIP(w.tunnel.ip).
+		SetSrcPort(port).
+		SetSrcPublicKey(w.tunnel.PublicKey())
+	request.MechanismPreferences = append(request.MechanismPreferences, mechanism)
+
+	log.FromContext(ctx).
+		WithField("wireguardClient", "request").
+		WithField("mechSrcIp", w.tunnel.ip).
+		WithField("mechSrcPort", port).
+		Debugf("set mechanism src")
+
+	conn, err := next.Client(ctx).Request(ctx, request, opts...)
+	if err != nil {
+		if !loaded {
+			w.tunnel.releasePort(port)
+		}
+		return nil, err
+	}
+
+	// Give the port back if another mechanism was selected
+	if ToMechanism(conn.GetMechanism()) == nil {
+		_, _ = loadAndDeletePort(ctx, true)
+		w.tunnel.releasePort(port)
+		return conn, nil
+	}
+	storePort(ctx, true, port)
+
+	return conn, nil
+}
+
+func (w *wireguardClient) Close(ctx context.Context, conn *networkservice.Connection, opts ...grpc.CallOption) (*empty.Empty, error) {
+	if port, ok := loadAndDeletePort(ctx, true); ok {
+		w.tunnel.releasePort(port)
+	} else {
+		// The metadata is lost if the forwarder has restarted since the Request
+		w.tunnel.releaseReserved(recordedConnID(conn, true))
+	}
+	return next.Client(ctx).Close(ctx, conn, opts...)
+}
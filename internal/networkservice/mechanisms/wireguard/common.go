@@ -0,0 +1,323 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package wireguard
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+// Create creates and configures the wireguard link for the wireguard mechanism of the conn and moves it to the
+// target network namespace. The link is configured before it is moved, so that its UDP socket stays in the
+// forwarder network namespace.
+func Create(ctx context.Context, nl nlhandle.Provider, tunnel *Tunnel, conn *networkservice.Connection, outgoing bool) error {
+	logger := log.FromContext(ctx).WithField("wireguard", "Intf create")
+	mechanism := ToMechanism(conn.GetMechanism())
+	if mechanism == nil {
+		return nil
+	}
+	if tunnel == nil {
+		return errors.New("wireguard mechanism is not enabled")
+	}
+	ifaceName := mechanism.GetParameters()[kernel.InterfaceNameKey]
+	if ifaceName == "" {
+		return errors.Errorf("wireguard interface name not provided")
+	}
+	netNsURL := mechanism.GetParameters()[kernel.NetNSURL]
+	if netNsURL == "" {
+		return errors.Errorf("wireguard inode URL not provided")
+	}
+
+	// The peer is the destination forwarder for the outgoing connections and the source one for the incoming ones
+	var listenPort, peerPort uint16
+	var peerIP net.IP
+	var peerKey []byte
+	var err error
+	if outgoing {
+		listenPort, peerIP, peerPort = mechanism.SrcPort(), mechanism.DstIP(), mechanism.DstPort()
+		peerKey, err = mechanism.DstPublicKey()
+	} else {
+		listenPort, peerIP, peerPort = mechanism.DstPort(), mechanism.SrcIP(), mechanism.SrcPort()
+		peerKey, err = mechanism.SrcPublicKey()
+	}
+	if err != nil {
+		return errors.Wrap(err, "wireguard peer public key not provided")
+	}
+	if listenPort == 0 || peerIP == nil || peerPort == 0 {
+		return errors.Errorf("wireguard tunnel endpoints not provided")
+	}
+	allowedIPs := peerAllowedIPs(conn, outgoing)
+	if len(allowedIPs) == 0 {
+		logger.Warnf("no allowed IPs for the wireguard peer, no traffic will pass")
+	}
+
+	logger.Infof("netnsurl: %v: iface: %v: listenPort: %v: peer: %s:%v: allowedIPs: %v", netNsURL, ifaceName, listenPort, peerIP, peerPort, allowedIPs)
+	device := &nlhandle.WireguardDevice{
+		PrivateKey: tunnel.privateKey.Bytes(),
+		ListenPort: listenPort,
+		Peers: []nlhandle.WireguardPeer{{
+			PublicKey:           peerKey,
+			Endpoint:            &net.UDPAddr{IP: peerIP, Port: int(peerPort)},
+			AllowedIPs:          allowedIPs,
+			PersistentKeepalive: persistentKeepalive * time.Second,
+		}},
+	}
+
+	handle, err := nl.FromURL(netNsURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Close()
+
+	hostHandle, err := nl.Host()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer hostHandle.Close()
+
+	// Treat the Request as redundant if the link created by the forwarder is still present in the target namespace,
+	// only following the changes of the peer, like a new key or port after a restart of the peer forwarder
+	if _, ok := link.Load(ctx, outgoing); ok {
+		if l, err := handle.LinkByName(ifaceName); err == nil {
+			if prev, ok := loadDevice(ctx, outgoing); ok && reflect.DeepEqual(prev, device) {
+				return nil
+			}
+			return configure(ctx, handle, l, device, outgoing)
+		}
+	}
+
+	// After a restart, take over the link created for the connection by the previous forwarder instance, so that the
+	// traffic is not interrupted. Its configuration is not known, it is replaced with the current one.
+	if prevLink, err := handle.LinkByName(ifaceName); err == nil && adoptable(ctx, prevLink, netNsURL) {
+		if err = configure(ctx, handle, prevLink, device, outgoing); err != nil {
+			return err
+		}
+		link.Adopt(ctx, outgoing, prevLink)
+		return nil
+	}
+
+	// The forwarder is not aware of any interface with that name in the target namespace, it is a stale one
+	if prevLink, err := handle.LinkByName(ifaceName); err == nil {
+		if err = handle.LinkDel(prevLink); err != nil {
+			return errors.WithStack(err)
+		}
+		metrics.StaleLinkDeletions.Inc("wireguard")
+		log.FromContext(ctx).
+			WithField("link.Name", prevLink.Attrs().Name).
+			WithField("netlink", "LinkDel").Debug("completed")
+	}
+
+	fwdNsIfaceName := linuxIfaceName(conn.GetId())
+	if err = hostHandle.LinkAdd(&netlink.Wireguard{
+		LinkAttrs: netlink.LinkAttrs{
+			Name:  fwdNsIfaceName,
+			Group: link.Group,
+			MTU:   int(conn.GetContext().GetMTU()),
+		},
+	}); err != nil {
+		return errors.Wrapf(err, "failed to create wireguard interface")
+	}
+	log.FromContext(ctx).WithField("link.Name", fwdNsIfaceName).WithField("netlink", "LinkAdd wireguard").Debug("completed")
+
+	l, err := hostHandle.LinkByName(fwdNsIfaceName)
+	if err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", fwdNsIfaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkByName").Debug("error")
+		return errors.WithStack(err)
+	}
+
+	if err = hostHandle.WireguardSetDevice(l, device); err != nil {
+		_ = hostHandle.LinkDel(l)
+		return errors.Wrapf(err, "failed to configure wireguard interface")
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", fwdNsIfaceName).
+		WithField("netlink", "WireguardSetDevice").Debug("completed")
+
+	if err = hostHandle.LinkSetNs(l, netNsURL); err != nil {
+		return errors.Wrapf(err, "unable to change to netns")
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "LinkSetNsFd").Debug("completed")
+
+	if l, err = handle.LinkByName(fwdNsIfaceName); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err = handle.LinkSetName(l, ifaceName); err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("link.NewName", ifaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkSetName").Debug("error")
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("link.NewName", ifaceName).
+		WithField("netlink", "LinkSetName").Debug("completed")
+
+	// Set link alias to the name of the network service client pod requesting for this link.
+	if linkAlias := link.Alias(conn.GetLabels()["podName"]); linkAlias != "" {
+		if err = handle.LinkSetAlias(l, linkAlias); err != nil {
+			return errors.WithStack(err)
+		}
+		log.FromContext(ctx).
+			WithField("link.Name", l.Attrs().Name).
+			WithField("alias", linkAlias).
+			WithField("netlink", "LinkSetAlias").Debug("completed")
+	}
+
+	if err = handle.LinkSetUp(l); err != nil {
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "LinkSetUp").Debug("completed")
+
+	link.Store(ctx, outgoing, l)
+	storeDevice(ctx, outgoing, device)
+
+	return nil
+}
+
+// configure replaces the configuration of the wireguard link l, in the network namespace of handle, with device
+func configure(ctx context.Context, handle nlhandle.Handle, l netlink.Link, device *nlhandle.WireguardDevice, outgoing bool) error {
+	if err := handle.WireguardSetDevice(l, device); err != nil {
+		return errors.Wrapf(err, "failed to configure wireguard interface")
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("netlink", "WireguardSetDevice").Debug("completed")
+	storeDevice(ctx, outgoing, device)
+	return nil
+}
+
+// adoptable reports whether l is the wireguard link created by the forwarder for the connection
+func adoptable(ctx context.Context, l netlink.Link, netNSURL string) bool {
+	return l.Type() == "wireguard" && link.Owned(ctx, l, netNSURL)
+}
+
+// Delete deletes the wireguard link for the wireguard mechanism of the conn from the target network namespace
+func Delete(ctx context.Context, nl nlhandle.Provider, conn *networkservice.Connection, outgoing bool) error {
+	mechanism := ToMechanism(conn.GetMechanism())
+	if mechanism == nil {
+		return nil
+	}
+	ifaceName := mechanism.GetParameters()[kernel.InterfaceNameKey]
+	if ifaceName == "" {
+		return errors.Errorf("wireguard interface name not provided")
+	}
+	netNsURL := mechanism.GetParameters()[kernel.NetNSURL]
+	if netNsURL == "" {
+		return errors.Errorf("wireguard inode URL not provided")
+	}
+
+	handle, err := nl.FromURL(netNsURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Close()
+
+	l, err := handle.LinkByName(ifaceName)
+	if err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", ifaceName).
+			WithField("netlink", "LinkByName").Debug("NotFound")
+		link.Delete(ctx, outgoing)
+		return nil
+	}
+
+	if err = handle.LinkDel(l); err != nil {
+		log.FromContext(ctx).
+			WithField("link.Name", ifaceName).
+			WithField("err", err).
+			WithField("netlink", "LinkDel").Debug("error")
+		return errors.WithStack(err)
+	}
+	log.FromContext(ctx).
+		WithField("link.Name", ifaceName).
+		WithField("netlink", "LinkDel").Info("completed")
+	link.Delete(ctx, outgoing)
+
+	return nil
+}
+
+// peerAllowedIPs returns the addresses the peer may send from and that are routed to it: the addresses of the
+// remote end of the connection and the routes the local end has through it.
+func peerAllowedIPs(conn *networkservice.Connection, outgoing bool) []net.IPNet {
+	ipContext := conn.GetContext().GetIpContext()
+	var cidrs []string
+	if outgoing {
+		cidrs = append(cidrs, ipContext.GetDstIpAddrs()...)
+		for _, route := range ipContext.GetSrcRoutes() {
+			cidrs = append(cidrs, route.GetPrefix())
+		}
+	} else {
+		cidrs = append(cidrs, ipContext.GetSrcIpAddrs()...)
+		for _, route := range ipContext.GetDstRoutes() {
+			cidrs = append(cidrs, route.GetPrefix())
+		}
+	}
+
+	var rv []net.IPNet
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		rv = append(rv, *ipNet)
+	}
+	return rv
+}
+
+// Overhead returns the encapsulation overhead of the wireguard links for the mechanism, which depends on the
+// address family of the underlay. It is 0 for other mechanism types.
+func Overhead(m *networkservice.Mechanism) int {
+	mechanism := ToMechanism(m)
+	if mechanism == nil {
+		return 0
+	}
+	if mechanism.SrcIP().To4() != nil {
+		return overheadIPv4
+	}
+	return overheadIPv6
+}
+
+func linuxIfaceName(ifaceName string) string {
+	if len(ifaceName) <= kernel.LinuxIfMaxLength {
+		return ifaceName
+	}
+	return ifaceName[:kernel.LinuxIfMaxLength]
+}
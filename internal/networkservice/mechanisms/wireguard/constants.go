@@ -0,0 +1,57 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package wireguard implements the WIREGUARD remote mechanism, which encrypts the traffic of the connection between
+// the nodes. Each forwarder has its own key pair; the public keys and the tunnel endpoints of both forwarders are
+// exchanged through the mechanism parameters. Wireguard links are L3 only, so the mechanism is only offered for
+// connections with the IP payload.
+package wireguard
+
+import (
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/common"
+)
+
+const (
+	// MECHANISM string
+	MECHANISM = "WIREGUARD"
+
+	// SrcIP - source tunnel IP parameter
+	SrcIP = common.SrcIP
+	// DstIP - destination tunnel IP parameter
+	DstIP = common.DstIP
+	// SrcPort - source listen port parameter
+	SrcPort = common.SrcPort
+	// DstPort - destination listen port parameter
+	DstPort = common.DstPort
+	// SrcPublicKey - public key of the source forwarder, base64 encoded
+	SrcPublicKey = "src_public_key"
+	// DstPublicKey - public key of the destination forwarder, base64 encoded
+	DstPublicKey = "dst_public_key"
+
+	// Every wireguard link has its own UDP socket in the forwarder network namespace. The listen ports are
+	// allocated from [wireguardBasePort, wireguardBasePort+wireguardPortCount).
+	wireguardBasePort  = 51820
+	wireguardPortCount = 1024
+
+	persistentKeepalive = 25 // seconds
+
+	// The encapsulation overhead (outer IP and UDP headers, wireguard header and authentication tag) subtracted from
+	// the underlay MTU, per underlay address family
+	overheadIPv4 = 60
+	overheadIPv6 = 80
+)
@@ -0,0 +1,129 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package wireguard
+
+import (
+	"encoding/base64"
+	"net"
+	"strconv"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+)
+
+// Mechanism - helper for the WIREGUARD mechanism parameters
+type Mechanism struct {
+	*networkservice.Mechanism
+}
+
+// ToMechanism - convert unified mechanism to helper type
+func ToMechanism(m *networkservice.Mechanism) *Mechanism {
+	if m.GetType() == MECHANISM {
+		if m.Parameters == nil {
+			m.Parameters = map[string]string{}
+		}
+		return &Mechanism{Mechanism: m}
+	}
+	return nil
+}
+
+// SrcIP returns the tunnel IP of the source forwarder
+func (m *Mechanism) SrcIP() net.IP {
+	return net.ParseIP(m.GetParameters()[SrcIP])
+}
+
+// SetSrcIP sets the tunnel IP of the source forwarder
+func (m *Mechanism) SetSrcIP(ip net.IP) *Mechanism {
+	m.GetParameters()[SrcIP] = ip.String()
+	return m
+}
+
+// DstIP returns the tunnel IP of the destination forwarder
+func (m *Mechanism) DstIP() net.IP {
+	return net.ParseIP(m.GetParameters()[DstIP])
+}
+
+// SetDstIP sets the tunnel IP of the destination forwarder
+func (m *Mechanism) SetDstIP(ip net.IP) *Mechanism {
+	m.GetParameters()[DstIP] = ip.String()
+	return m
+}
+
+// SrcPort returns the listen port of the wireguard link of the source forwarder
+func (m *Mechanism) SrcPort() uint16 {
+	return parsePort(m.GetParameters()[SrcPort])
+}
+
+// SetSrcPort sets the listen port of the wireguard link of the source forwarder
+func (m *Mechanism) SetSrcPort(port uint16) *Mechanism {
+	m.GetParameters()[SrcPort] = strconv.FormatUint(uint64(port), 10)
+	return m
+}
+
+// DstPort returns the listen port of the wireguard link of the destination forwarder
+func (m *Mechanism) DstPort() uint16 {
+	return parsePort(m.GetParameters()[DstPort])
+}
+
+// SetDstPort sets the listen port of the wireguard link of the destination forwarder
+func (m *Mechanism) SetDstPort(port uint16) *Mechanism {
+	m.GetParameters()[DstPort] = strconv.FormatUint(uint64(port), 10)
+	return m
+}
+
+// SrcPublicKey returns the public key of the source forwarder
+func (m *Mechanism) SrcPublicKey() ([]byte, error) {
+	return parseKey(m.GetParameters()[SrcPublicKey])
+}
+
+// SetSrcPublicKey sets the public key of the source forwarder
+func (m *Mechanism) SetSrcPublicKey(key []byte) *Mechanism {
+	m.GetParameters()[SrcPublicKey] = base64.StdEncoding.EncodeToString(key)
+	return m
+}
+
+// DstPublicKey returns the public key of the destination forwarder
+func (m *Mechanism) DstPublicKey() ([]byte, error) {
+	return parseKey(m.GetParameters()[DstPublicKey])
+}
+
+// SetDstPublicKey sets the public key of the destination forwarder
+func (m *Mechanism) SetDstPublicKey(key []byte) *Mechanism {
+	m.GetParameters()[DstPublicKey] = base64.StdEncoding.EncodeToString(key)
+	return m
+}
+
+func parsePort(s string) uint16 {
+	port, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0
+	}
+	return uint16(port)
+}
+
+func parseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid wireguard key")
+	}
+	if len(key) != keyLen {
+		return nil, errors.Errorf("invalid wireguard key length: %d", len(key))
+	}
+	return key, nil
+}
@@ -0,0 +1,85 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package wireguard
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+type wireguardServer struct {
+	tunnel *Tunnel
+}
+
+// NewServer - returns a new server for the wireguard remote mechanism. The server completes the mechanism with the
+// tunnel IP, public key and a listen port of this forwarder as the destination parameters.
+func NewServer(tunnel *Tunnel) networkservice.NetworkServiceServer {
+	return &wireguardServer{tunnel: tunnel}
+}
+
+func (w *wireguardServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	mechanism := ToMechanism(request.GetConnection().GetMechanism())
+	if mechanism == nil {
+		return next.Server(ctx).Request(ctx, request)
+	}
+
+	port, loaded := loadPort(ctx, false)
+	if !loaded {
+		var err error
+		if port, err = w.tunnel.allocatePort(recordedConnID(request.GetConnection(), false)); err != nil {
+			return nil, err
+		}
+	}
+	mechanism.
+		SetDstIP(w.tunnel.ip).
+		SetDstPort(port).
+		SetDstPublicKey(w.tunnel.PublicKey())
+
+	log.FromContext(ctx).
+		WithField("wireguardServer", "request").
+		WithField("mechanism.DstIP", mechanism.DstIP()).
+		WithField("mechanism.DstPort", port).
+		Debugf("set mechanism dst")
+
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil {
+		if !loaded {
+			w.tunnel.releasePort(port)
+		}
+		return nil, err
+	}
+	storePort(ctx, false, port)
+
+	return conn, nil
+}
+
+func (w *wireguardServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	if port, ok := loadAndDeletePort(ctx, false); ok {
+		w.tunnel.releasePort(port)
+	} else {
+		// The metadata is lost if the forwarder has restarted since the Request
+		w.tunnel.releaseReserved(recordedConnID(conn, false))
+	}
+	return next.Server(ctx).Close(ctx, conn)
+}
@@ -0,0 +1,214 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package wireguard
+
+import (
+	"context"
+	"crypto/ecdh"
+	"net"
+	"sync"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/pkg/errors"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/connstate"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/keyfile"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const keyLen = 32
+
+// Tunnel is the state the wireguard client, server and links of a forwarder share: the tunnel IP, the key pair
+// of the forwarder and the listen ports in use.
+type Tunnel struct {
+	ip         net.IP
+	privateKey *ecdh.PrivateKey
+
+	mu    sync.Mutex
+	ports map[uint16]struct{}
+	// reserved holds the listen ports of the connections of the previous forwarder instance, by connection id,
+	// until they are refreshed or closed
+	reserved map[string]uint16
+	next     uint16
+}
+
+// Option configures the Tunnel
+type Option func(o *tunnelOptions)
+
+type tunnelOptions struct {
+	keyFile string
+	state   *connstate.Store
+}
+
+// WithKeyFile keeps the private key of the forwarder in the file at path, so that it survives the restarts of the
+// forwarder. The peers of the links in place would drop the traffic of a new key. A key is generated if the file does
+// not exist yet.
+func WithKeyFile(path string) Option {
+	return func(o *tunnelOptions) {
+		o.keyFile = path
+	}
+}
+
+// WithStateStore reserves the listen ports of the wireguard connections persisted in the store by the previous
+// forwarder instance, so that their refreshes keep the ports their links and peers are configured with
+func WithStateStore(store *connstate.Store) Option {
+	return func(o *tunnelOptions) {
+		o.state = store
+	}
+}
+
+// NewTunnel loads or generates the key pair of the forwarder
+func NewTunnel(tunnelIP net.IP, options ...Option) (*Tunnel, error) {
+	opts := &tunnelOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	privateKey, err := keyfile.LoadOrGenerate(opts.keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the wireguard key")
+	}
+	t := &Tunnel{
+		ip:         tunnelIP,
+		privateKey: privateKey,
+		ports:      make(map[uint16]struct{}),
+		reserved:   make(map[string]uint16),
+	}
+	records, err := opts.state.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the wireguard connections of the previous forwarder")
+	}
+	for _, record := range records {
+		// The listen port is the destination one of the incoming connections and the source one of the outgoing
+		// connections, whose remote mechanism is the destination one
+		if mechanism := ToMechanism(record.SrcMechanism); mechanism != nil {
+			t.reserve(record.ID, mechanism.DstPort())
+		}
+		if mechanism := ToMechanism(record.DstMechanism); mechanism != nil {
+			t.reserve(record.ID, mechanism.SrcPort())
+		}
+	}
+	return t, nil
+}
+
+// PublicKey returns the public key of the forwarder
+func (t *Tunnel) PublicKey() []byte {
+	return t.privateKey.PublicKey().Bytes()
+}
+
+func (t *Tunnel) reserve(connID string, port uint16) {
+	if port < wireguardBasePort || port >= wireguardBasePort+wireguardPortCount {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ports[port] = struct{}{}
+	t.reserved[connID] = port
+}
+
+// allocatePort returns the listen port for the connection connID: the one reserved for it by the previous forwarder
+// instance if any, or a free one
+func (t *Tunnel) allocatePort(connID string) (uint16, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if port, ok := t.reserved[connID]; ok {
+		delete(t.reserved, connID)
+		return port, nil
+	}
+	for i := 0; i < wireguardPortCount; i++ {
+		port := wireguardBasePort + (t.next+uint16(i))%wireguardPortCount
+		if _, ok := t.ports[port]; !ok {
+			t.ports[port] = struct{}{}
+			t.next = port - wireguardBasePort + 1
+			return port, nil
+		}
+	}
+	return 0, errors.New("no wireguard listen port available")
+}
+
+func (t *Tunnel) releasePort(port uint16) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.ports, port)
+}
+
+// releaseReserved releases the port reserved for the connection connID, closed before it was refreshed
+func (t *Tunnel) releaseReserved(connID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if port, ok := t.reserved[connID]; ok {
+		delete(t.reserved, connID)
+		delete(t.ports, port)
+	}
+}
+
+// recordedConnID returns the id of the connection the forwarder serves and records the state of, for the conn of
+// either the server or the client of the forwarder. The client connection is the path segment following the served
+// one.
+func recordedConnID(conn *networkservice.Connection, isClient bool) string {
+	if !isClient {
+		return conn.GetId()
+	}
+	path := conn.GetPath()
+	if path.GetIndex() == 0 || int(path.GetIndex()) > len(path.GetPathSegments()) {
+		return ""
+	}
+	return path.GetPathSegments()[path.GetIndex()-1].GetId()
+}
+
+type portKey struct{}
+
+func storePort(ctx context.Context, isClient bool, port uint16) {
+	metadata.Map(ctx, isClient).Store(portKey{}, port)
+}
+
+func loadPort(ctx context.Context, isClient bool) (uint16, bool) {
+	raw, ok := metadata.Map(ctx, isClient).Load(portKey{})
+	if !ok {
+		return 0, false
+	}
+	port, ok := raw.(uint16)
+	return port, ok
+}
+
+func loadAndDeletePort(ctx context.Context, isClient bool) (uint16, bool) {
+	raw, ok := metadata.Map(ctx, isClient).LoadAndDelete(portKey{})
+	if !ok {
+		return 0, false
+	}
+	port, ok := raw.(uint16)
+	return port, ok
+}
+
+type deviceKey struct{}
+
+// storeDevice keeps the configuration of the wireguard link of the connection, to tell the refreshes changing it
+func storeDevice(ctx context.Context, isClient bool, device *nlhandle.WireguardDevice) {
+	metadata.Map(ctx, isClient).Store(deviceKey{}, device)
+}
+
+func loadDevice(ctx context.Context, isClient bool) (*nlhandle.WireguardDevice, bool) {
+	raw, ok := metadata.Map(ctx, isClient).Load(deviceKey{})
+	if !ok {
+		return nil, false
+	}
+	device, ok := raw.(*nlhandle.WireguardDevice)
+	return device, ok
+}
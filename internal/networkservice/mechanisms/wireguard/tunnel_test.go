@@ -0,0 +1,122 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package wireguard
+
+import (
+	"bytes"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/connstate"
+)
+
+func TestNewTunnelKeyFile(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "wireguard.key")
+	first, err := NewTunnel(net.ParseIP("10.0.0.1"), WithKeyFile(keyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewTunnel(net.ParseIP("10.0.0.1"), WithKeyFile(keyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.PublicKey(), second.PublicKey()) {
+		t.Errorf("the key changed across restarts")
+	}
+
+	other, err := NewTunnel(net.ParseIP("10.0.0.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first.PublicKey(), other.PublicKey()) {
+		t.Errorf("a tunnel without key file reused the stored key")
+	}
+}
+
+func TestNewTunnelReservedPorts(t *testing.T) {
+	store, err := connstate.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	incoming := &networkservice.Mechanism{Type: MECHANISM}
+	ToMechanism(incoming).SetDstPort(wireguardBasePort)
+	outgoing := &networkservice.Mechanism{Type: MECHANISM}
+	ToMechanism(outgoing).SetSrcPort(wireguardBasePort + 1)
+	for _, record := range []*connstate.Record{
+		{ID: "incoming", SrcMechanism: incoming, DstMechanism: &networkservice.Mechanism{Type: "KERNEL"}},
+		{ID: "outgoing", SrcMechanism: &networkservice.Mechanism{Type: "KERNEL"}, DstMechanism: outgoing},
+	} {
+		if err = store.Save(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tunnel, err := NewTunnel(net.ParseIP("10.0.0.1"), WithStateStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		connID string
+		want   uint16
+	}{
+		// A new connection does not get the ports of the connections surviving the restart
+		{connID: "new", want: wireguardBasePort + 2},
+		{connID: "outgoing", want: wireguardBasePort + 1},
+		{connID: "incoming", want: wireguardBasePort},
+		{connID: "other", want: wireguardBasePort + 3},
+	} {
+		port, err := tunnel.allocatePort(tc.connID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if port != tc.want {
+			t.Errorf("allocatePort(%q) = %d, want %d", tc.connID, port, tc.want)
+		}
+	}
+
+	tunnel.releaseReserved("incoming")
+	tunnel.releasePort(wireguardBasePort)
+	if port, _ := tunnel.allocatePort("again"); port != wireguardBasePort+4 {
+		t.Errorf("allocatePort() = %d, want %d", port, wireguardBasePort+4)
+	}
+}
+
+func TestRecordedConnID(t *testing.T) {
+	conn := &networkservice.Connection{
+		Id: "client",
+		Path: &networkservice.Path{
+			Index: 1,
+			PathSegments: []*networkservice.PathSegment{
+				{Id: "server"},
+				{Id: "client"},
+			},
+		},
+	}
+	if got := recordedConnID(conn, true); got != "server" {
+		t.Errorf("recordedConnID(client) = %q, want server", got)
+	}
+	conn.Id = "server"
+	conn.Path.Index = 0
+	if got := recordedConnID(conn, false); got != "server" {
+		t.Errorf("recordedConnID(server) = %q, want server", got)
+	}
+}
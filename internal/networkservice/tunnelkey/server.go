@@ -0,0 +1,173 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package tunnelkey allocates the keys telling apart the tunnels between the same two nodes, like the VNIs of the
+// geneve links or the GRE keys, for the remote mechanisms that have no allocator in the sdk.
+package tunnelkey
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+)
+
+// Mechanism - the parameters of a tunnel mechanism the keys are allocated for
+type Mechanism interface {
+	SrcIP() net.IP
+	DstIP() net.IP
+	Key() uint32
+	SetKey(key uint32)
+}
+
+type allocatedKey struct {
+	srcIPString string
+	key         uint32
+}
+
+type keyMetadataKey struct{}
+
+type keyServer struct {
+	bits        uint
+	toMechanism func(*networkservice.Mechanism) Mechanism
+
+	// keys stores all the allocated keys
+	keys sync.Map
+}
+
+// NewServer returns a server element allocating the keys of bits bits of the mechanisms converted by toMechanism,
+// which returns nil for the other mechanism types. The source and destination tunnel IPs must already be set. A key
+// is unique per source tunnel IP, and kept for the lifetime of the connection. The key of a Request which already
+// has one, like a refresh after a forwarder restart, is kept as well.
+func NewServer(bits uint, toMechanism func(*networkservice.Mechanism) Mechanism) networkservice.NetworkServiceServer {
+	return &keyServer{
+		bits:        bits,
+		toMechanism: toMechanism,
+	}
+}
+
+func (s *keyServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	logger := log.FromContext(ctx).WithField("tunnelKeyServer", "request")
+
+	mechanism := s.toMechanism(request.GetConnection().GetMechanism())
+	if mechanism == nil {
+		return next.Server(ctx).Request(ctx, request)
+	}
+
+	k := allocatedKey{
+		srcIPString: mechanism.SrcIP().String(),
+		key:         mechanism.Key(),
+	}
+	isClient := metadata.IsClient(s)
+
+	// If we already have a key, make sure we remember it, and go on
+	if k.key != 0 && mechanism.SrcIP() != nil {
+		_, _ = s.keys.LoadOrStore(k, &k)
+		_, loaded := metadata.Map(ctx, isClient).LoadOrStore(keyMetadataKey{}, k.key)
+
+		conn, err := next.Server(ctx).Request(ctx, request)
+		if err != nil && !loaded {
+			metadata.Map(ctx, isClient).Delete(keyMetadataKey{})
+			s.keys.Delete(k)
+			logger.WithField("key", k.key).Errorf("error returned from request, deleting key. err=%v", err.Error())
+		}
+		return conn, err
+	}
+
+	key, loaded := metadata.Map(ctx, isClient).Load(keyMetadataKey{})
+	if loaded {
+		k.key = key.(uint32)
+		mechanism.SetKey(k.key)
+		logger.WithField("key", k.key).Debugf("key loaded from metadata")
+	} else {
+		for {
+			var err error
+			if k.key, err = s.generate(mechanism); err != nil {
+				return nil, err
+			}
+			// A zero key reads as not set
+			if k.key == 0 {
+				continue
+			}
+			if _, ok := s.keys.LoadOrStore(k, &k); !ok {
+				mechanism.SetKey(k.key)
+				metadata.Map(ctx, isClient).Store(keyMetadataKey{}, k.key)
+				logger.WithField("key", k.key).Debugf("key generated and stored in metadata")
+				break
+			}
+		}
+	}
+
+	conn, err := next.Server(ctx).Request(ctx, request)
+	if err != nil && !loaded {
+		metadata.Map(ctx, isClient).Delete(keyMetadataKey{})
+		s.keys.Delete(k)
+		logger.WithField("key", k.key).Errorf("error returned from request, deleting key. err=%v", err.Error())
+	}
+	return conn, err
+}
+
+func (s *keyServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	if mechanism := s.toMechanism(conn.GetMechanism()); mechanism != nil {
+		k := allocatedKey{
+			srcIPString: mechanism.SrcIP().String(),
+			key:         mechanism.Key(),
+		}
+		if k.key != 0 && mechanism.SrcIP() != nil {
+			metadata.Map(ctx, metadata.IsClient(s)).Delete(keyMetadataKey{})
+			s.keys.Delete(k)
+
+			log.FromContext(ctx).
+				WithField("tunnelKeyServer", "close").
+				WithField("srcIP", k.srcIPString).
+				WithField("key", k.key).
+				Debugf("key released")
+		}
+	}
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+// generate returns a random key for the mechanism. The forwarders of two nodes allocate the keys of the tunnels
+// between them independently, one for the connections in each direction, and these tunnels share the underlay
+// sockets of both nodes. The keys of the tunnels from the node with the lower tunnel IP are even and the ones of the
+// tunnels from the other node are odd, so that the two never collide.
+func (s *keyServer) generate(mechanism Mechanism) (uint32, error) {
+	srcIP, dstIP := mechanism.SrcIP(), mechanism.DstIP()
+	if srcIP == nil || dstIP == nil {
+		return 0, errors.Errorf("both srcIP(%s) and dstIP(%s) must be set", srcIP, dstIP)
+	}
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	key := binary.BigEndian.Uint32(b[:]) & uint32(uint64(1)<<s.bits-1)
+	if bytes.Compare(srcIP.To16(), dstIP.To16()) <= 0 {
+		return key &^ 1, nil
+	}
+	return key | 1, nil
+}
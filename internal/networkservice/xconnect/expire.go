@@ -0,0 +1,61 @@
+package xconnect
+
+import (
+	"context"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+)
+
+// restoreExpirations arms the expiration of the connections persisted by a previous forwarder instance. While the
+// forwarder runs, the timeout element of the endpoint chain closes the connections that are not refreshed before
+// their token expires. Its timers are lost on restart though, so the connections found in the state store are torn
+// down at their expiration unless a Request refreshes them before.
+func (x *xconnectServer) restoreExpirations(ctx context.Context) {
+	records, err := x.state.List()
+	if err != nil {
+		log.FromContext(ctx).WithField("xconnectServer", "restoreExpirations").Errorf("failed to list the connection states: %v", err)
+		return
+	}
+
+	// The teardown runs outside of any Request, it needs a chain providing the per connection metadata
+	closer := chain.NewNetworkServiceServer(metadata.NewServer(), x)
+
+	x.expirationsMu.Lock()
+	defer x.expirationsMu.Unlock()
+	for _, record := range records {
+		conn := &networkservice.Connection{Id: record.ID, Mechanism: record.SrcMechanism}
+		x.expirations[record.ID] = time.AfterFunc(time.Until(record.Expires), func() {
+			if !x.cancelExpiration(conn.GetId()) {
+				return
+			}
+			logger := log.FromContext(ctx).WithField("xconnectServer", "expire")
+			logger.Infof("connection %s restored from the state store expired", conn.GetId())
+			metrics.ConnectionExpirations.Inc()
+			if _, err := closer.Close(ctx, conn); err != nil {
+				logger.Errorf("failed to tear down the expired connection %s: %v", conn.GetId(), err)
+			}
+		})
+	}
+	log.FromContext(ctx).WithField("xconnectServer", "restoreExpirations").Infof("restored the expiration of %d connections", len(records))
+}
+
+// cancelExpiration stops the expiration of a restored connection, once it is refreshed, closed or expired. It reports
+// whether the expiration was pending.
+func (x *xconnectServer) cancelExpiration(connID string) bool {
+	x.expirationsMu.Lock()
+	defer x.expirationsMu.Unlock()
+
+	timer, ok := x.expirations[connID]
+	if !ok {
+		return false
+	}
+	timer.Stop()
+	delete(x.expirations, connID)
+	return true
+}
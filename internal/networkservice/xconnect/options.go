@@ -0,0 +1,101 @@
+package xconnect
+
+import (
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/vxlan"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/wireguard"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/admin"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/connstate"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/linkstats"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/orphans"
+)
+
+// Option is an option pattern for NewServer
+type Option func(x *xconnectServer)
+
+// WithNetlinkProvider sets the provider of the netlink handles used to create and delete the links.
+// Defaults to the kernel netlink provider.
+func WithNetlinkProvider(nl nlhandle.Provider) Option {
+	return func(x *xconnectServer) {
+		x.nl = nl
+	}
+}
+
+// WithConnectionContextClient sets the client that applies the connection context (IPs, routes, MTU)
+// to the created interfaces. Defaults to connectioncontextkernel.NewClient().
+func WithConnectionContextClient(client networkservice.NetworkServiceClient) Option {
+	return func(x *xconnectServer) {
+		x.connCtxClient = client
+	}
+}
+
+// WithOrphanReconciler sets the reconciler holding the interfaces left behind by a previous forwarder
+// instance. The interfaces of every Request are claimed from it before they are created.
+func WithOrphanReconciler(reconciler *orphans.Reconciler) Option {
+	return func(x *xconnectServer) {
+		x.orphans = reconciler
+	}
+}
+
+// WithStatsCollector sets the collector of the statistics of the links created for every connection
+func WithStatsCollector(collector *linkstats.Collector) Option {
+	return func(x *xconnectServer) {
+		x.stats = collector
+	}
+}
+
+// WithWireguardTunnel sets the key pair and listen ports used for the links of the wireguard remote mechanism.
+// Connections with the wireguard mechanism fail if it is not set.
+func WithWireguardTunnel(tunnel *wireguard.Tunnel) Option {
+	return func(x *xconnectServer) {
+		x.wireguard = tunnel
+	}
+}
+
+// WithVXLANOptions sets the options the vxlan links are created and deleted with
+func WithVXLANOptions(options ...vxlan.Option) Option {
+	return func(x *xconnectServer) {
+		x.vxlanOptions = options
+	}
+}
+
+// WithVLANParent sets the interface of the forwarder network namespace the vlan sub-interfaces are created on.
+// Connections with the vlan mechanism fail if it is not set.
+func WithVLANParent(parent string) Option {
+	return func(x *xconnectServer) {
+		x.vlanParent = parent
+	}
+}
+
+// WithChildParent sets the interface of the forwarder network namespace the macvlan and ipvlan children of the
+// local connections are created on. Local connections asking for them fail if it is not set.
+func WithChildParent(parent string) Option {
+	return func(x *xconnectServer) {
+		x.childParent = parent
+	}
+}
+
+// WithUnderlayMTU sets the MTU of the interface the tunnels go through. The links are created with it, less the
+// encapsulation overhead of the remote mechanism. Defaults to mtu.DefaultUnderlay.
+func WithUnderlayMTU(underlayMTU int) Option {
+	return func(x *xconnectServer) {
+		x.underlayMTU = underlayMTU
+	}
+}
+
+// WithStateStore sets the store the state of the cross connects is persisted to, for them to be torn down by a
+// Close after a forwarder restart. The state is not persisted if it is not set.
+func WithStateStore(store *connstate.Store) Option {
+	return func(x *xconnectServer) {
+		x.state = store
+	}
+}
+
+// WithAdminRegistry sets the registry the cross connects are listed from by the admin API
+func WithAdminRegistry(registry *admin.Registry) Option {
+	return func(x *xconnectServer) {
+		x.admin = registry
+	}
+}
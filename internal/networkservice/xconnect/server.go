@@ -2,27 +2,85 @@ package xconnect
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/common"
 	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/networkservice/connectioncontextkernel"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/begin"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
 	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
 
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/geneve"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/gre"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/macvlan"
 	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/veth"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/vlan"
 	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/vxlan"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/wireguard"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/admin"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/connstate"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/linkstats"
 	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/mechanismmetadata"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/mtu"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/orphans"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/qos"
 )
 
 type xconnectServer struct {
+	nl            nlhandle.Provider
+	connCtxClient networkservice.NetworkServiceClient
+	orphans       *orphans.Reconciler
+	stats         *linkstats.Collector
+	wireguard     *wireguard.Tunnel
+	vxlanOptions  []vxlan.Option
+	vlanParent    string
+	childParent   string
+	underlayMTU   int
+	state         *connstate.Store
+	admin         *admin.Registry
+
+	expirationsMu sync.Mutex
+	expirations   map[string]*time.Timer
 }
 
-// The kernel xconnect server that cross connects client and server pods through a veth link if
-// the network service client and server pods are co-located on the same node or a vxlan tunnel if
-// they are located on different nodes.
+// The kernel xconnect server that cross connects client and server pods through a veth link, or macvlan or ipvlan
+// children of a host interface if the connection asks for them, if the network service client and server pods are
+// co-located on the same node or a vxlan, geneve, gre, gretap or wireguard tunnel if they are located on different
+// nodes, or through a vlan sub-interface of the uplink if the network service endpoint is on a VLAN.
 // This server is inserted as a chain element in the kernel forwarder endpoint registration process.
-func NewServer() networkservice.NetworkServiceServer {
-	return &xconnectServer{}
+// The connections persisted in the state store by a previous forwarder instance are torn down at their expiration
+// unless they are refreshed, until ctx is done.
+func NewServer(ctx context.Context, options ...Option) networkservice.NetworkServiceServer {
+	x := &xconnectServer{
+		nl:            nlhandle.NewProvider(),
+		connCtxClient: connectioncontextkernel.NewClient(),
+		underlayMTU:   mtu.DefaultUnderlay,
+		expirations:   make(map[string]*time.Timer),
+	}
+	for _, opt := range options {
+		opt(x)
+	}
+	if x.state != nil {
+		x.restoreExpirations(ctx)
+		go func() {
+			<-ctx.Done()
+			x.expirationsMu.Lock()
+			defer x.expirationsMu.Unlock()
+			for id, timer := range x.expirations {
+				timer.Stop()
+				delete(x.expirations, id)
+			}
+		}()
+	}
+	return x
 }
 
 func createConnectionWithMechanism(mech *networkservice.Mechanism, srcConn *networkservice.Connection) *networkservice.Connection {
@@ -31,42 +89,166 @@ func createConnectionWithMechanism(mech *networkservice.Mechanism, srcConn *netw
 	return conn
 }
 
-func deleteLocalConnection(ctx context.Context, srcConn, dstConn *networkservice.Connection) error {
-	err := veth.Delete(ctx, srcConn, true)
+// claimOrphan hands the interface of the conn back from the orphan reconciler, so that an interface left behind
+// by a previous forwarder instance is not deleted by the reconciler once its connection is refreshed.
+func (x *xconnectServer) claimOrphan(ctx context.Context, conn *networkservice.Connection) {
+	params := conn.GetMechanism().GetParameters()
+	x.orphans.Claim(ctx, params[common.InodeURL], params[common.InterfaceNameKey])
+}
+
+// statsInterface returns the interface created for the conn, whose statistics are collected
+func statsInterface(conn *networkservice.Connection) linkstats.Interface {
+	params := conn.GetMechanism().GetParameters()
+	return linkstats.Interface{NetNSURL: params[common.InodeURL], Name: params[common.InterfaceNameKey]}
+}
+
+// recordCrossConnect persists the mechanisms and the links of the cross connect of the conn, so that it can still be
+// torn down by a Close received after a forwarder restart, and adds it to the admin registry to be listed and
+// force-closed through the event factory of the Request. Persisting the state is
+// best effort, a failure does not fail the Request.
+func (x *xconnectServer) recordCrossConnect(ctx context.Context, conn *networkservice.Connection, dstMech *networkservice.Mechanism, ifaces ...linkstats.Interface) {
+	record := &connstate.Record{
+		ID:           conn.GetId(),
+		SrcMechanism: conn.GetMechanism().Clone(),
+		DstMechanism: dstMech.Clone(),
+	}
+	for _, iface := range ifaces {
+		record.Links = append(record.Links, connstate.Link{NetNSURL: iface.NetNSURL, Name: iface.Name})
+	}
+	if expires := conn.GetCurrentPathSegment().GetExpires(); expires != nil {
+		record.Expires = expires.AsTime()
+	}
+	_, record.QoS = metadata.Map(ctx, false).Load(qosKey{})
+	if err := x.state.Save(record); err != nil {
+		log.FromContext(ctx).WithField("xconnectServer", "recordCrossConnect").Errorf("failed to save the connection state: %v", err)
+	}
+	x.admin.Add(conn, record, begin.FromContext(ctx))
+}
+
+// withRecordedLinks returns ctx carrying the links persisted for the conn by a previous forwarder instance, which
+// the mechanisms take over instead of creating them again. They are only looked up by the first Request of the conn
+// since the forwarder started.
+func (x *xconnectServer) withRecordedLinks(ctx context.Context, conn *networkservice.Connection) context.Context {
+	if _, ok := mechanismmetadata.Load(ctx, false); ok {
+		return ctx
+	}
+	record, err := x.state.Load(conn.GetId())
+	if err != nil {
+		log.FromContext(ctx).WithField("xconnectServer", "withRecordedLinks").Errorf("failed to load the connection state: %v", err)
+		return ctx
+	}
+	if record == nil {
+		return ctx
+	}
+	return link.WithRecorded(ctx, record.Links)
+}
+
+// loadState returns the conn with its mechanism filled in and the destination mechanism from the persisted state of
+// the conn, or a nil destination mechanism if there is none
+func (x *xconnectServer) loadState(ctx context.Context, conn *networkservice.Connection) (*networkservice.Connection, *networkservice.Mechanism) {
+	record, err := x.state.Load(conn.GetId())
+	if err != nil {
+		log.FromContext(ctx).WithField("xconnectServer", "loadState").Errorf("failed to load the connection state: %v", err)
+		return conn, nil
+	}
+	if record == nil {
+		return conn, nil
+	}
+	log.FromContext(ctx).WithField("xconnectServer", "loadState").Infof("tearing down %s from the persisted state", conn.GetId())
+	if conn.GetMechanism() == nil {
+		conn = conn.Clone()
+		conn.Mechanism = record.SrcMechanism
+	}
+	return conn, record.DstMechanism
+}
+
+func (x *xconnectServer) deleteLocalConnection(ctx context.Context, srcConn, dstConn *networkservice.Connection) error {
+	// macvlan.Delete only acts on the macvlan and ipvlan children, the veth pairs are left to veth.Delete
+	err := macvlan.Delete(ctx, x.nl, srcConn, true)
+	if err != nil {
+		return err
+	}
+	err = macvlan.Delete(ctx, x.nl, dstConn, false)
 	if err != nil {
 		return err
 	}
-	err = veth.Delete(ctx, dstConn, false)
+	err = veth.Delete(ctx, x.nl, srcConn, true)
+	if err != nil {
+		return err
+	}
+	err = veth.Delete(ctx, x.nl, dstConn, false)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func deleteRemoteConnection(ctx context.Context, srcConn *networkservice.Connection, outgoing bool) error {
-	err := vxlan.Delete(ctx, srcConn, outgoing)
+func (x *xconnectServer) deleteRemoteConnection(ctx context.Context, srcConn *networkservice.Connection, outgoing bool) error {
+	// Only the delete func matching the type of the remote mechanism acts on the connection
+	err := vxlan.Delete(ctx, x.nl, srcConn, outgoing, x.vxlanOptions...)
+	if err != nil {
+		return err
+	}
+	err = geneve.Delete(ctx, x.nl, srcConn, outgoing)
+	if err != nil {
+		return err
+	}
+	err = gre.Delete(ctx, x.nl, srcConn, outgoing)
+	if err != nil {
+		return err
+	}
+	err = wireguard.Delete(ctx, x.nl, srcConn, outgoing)
+	if err != nil {
+		return err
+	}
+	err = vlan.Delete(ctx, x.nl, srcConn, outgoing)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func handleLocalConnection(ctx context.Context, srcConn, dstConn *networkservice.Connection, request *networkservice.NetworkServiceRequest) error {
-	err := veth.Create(ctx, srcConn, true)
+func (x *xconnectServer) handleLocalConnection(ctx context.Context, srcConn, dstConn *networkservice.Connection, request *networkservice.NetworkServiceRequest) error {
+	x.claimOrphan(ctx, srcConn)
+	x.claimOrphan(ctx, dstConn)
+
+	linkType, err := macvlan.LinkType(srcConn, dstConn)
 	if err != nil {
 		return err
 	}
+	if linkType == macvlan.LinkTypeVeth {
+		// Keep the veth pair in place if it survived a forwarder restart
+		veth.Adopt(ctx, x.nl, srcConn, dstConn)
 
-	err = veth.Create(ctx, dstConn, false)
-	if err != nil {
+		err = veth.Create(ctx, x.nl, srcConn, true)
+		if err != nil {
+			return err
+		}
+
+		err = veth.Create(ctx, x.nl, dstConn, false)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = macvlan.Create(ctx, x.nl, srcConn, true, x.childParent, linkType)
+		if err != nil {
+			return err
+		}
+
+		err = macvlan.Create(ctx, x.nl, dstConn, false, x.childParent, linkType)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = x.applyQoS(ctx, srcConn); err != nil {
 		return err
 	}
 
 	req2 := request.Clone()
 	req2.Connection = dstConn
 
-	connCtxClient := connectioncontextkernel.NewClient()
-	_, err = connCtxClient.Request(ctx, req2)
+	_, err = x.connCtxClient.Request(ctx, req2)
 	if err != nil {
 		return err
 	}
@@ -74,24 +256,122 @@ func handleLocalConnection(ctx context.Context, srcConn, dstConn *networkservice
 	return nil
 }
 
-func handleRemoteConnection(ctx context.Context, srcConn *networkservice.Connection, request *networkservice.NetworkServiceRequest, outgoing bool) error {
-	err := vxlan.Create(ctx, srcConn, outgoing)
+func (x *xconnectServer) handleRemoteConnection(ctx context.Context, srcConn *networkservice.Connection, request *networkservice.NetworkServiceRequest, outgoing bool) error {
+	x.claimOrphan(ctx, srcConn)
+
+	// Only the create func matching the type of the remote mechanism acts on the connection
+	err := vxlan.Create(ctx, x.nl, srcConn, outgoing, x.vxlanOptions...)
+	if err != nil {
+		return err
+	}
+	err = geneve.Create(ctx, x.nl, srcConn, outgoing)
+	if err != nil {
+		return err
+	}
+	err = gre.Create(ctx, x.nl, srcConn, outgoing)
+	if err != nil {
+		return err
+	}
+	err = wireguard.Create(ctx, x.nl, x.wireguard, srcConn, outgoing)
 	if err != nil {
 		return err
 	}
+	err = vlan.Create(ctx, x.nl, srcConn, outgoing, x.vlanParent)
+	if err != nil {
+		return err
+	}
+
+	// The limits are enforced on the node of the client
+	if outgoing {
+		return x.applyQoS(ctx, srcConn)
+	}
+	return nil
+}
+
+// qosKey is the per connection metadata key recording that qdiscs were added to the interface of the connection
+type qosKey struct{}
+
+// applyQoS reconciles the bandwidth limits set by the labels of the conn with the qdiscs of its interface in the
+// client pod. It runs on every Request, so that the limits follow the labels of the refreshes. The interface is left
+// alone if the labels set no limits and none were applied before, by this forwarder or by the previous instance.
+func (x *xconnectServer) applyQoS(ctx context.Context, conn *networkservice.Connection) error {
+	limits, err := qos.FromLabels(conn.GetLabels())
+	if err != nil {
+		return err
+	}
+	if limits.Unlimited() && !x.qosApplied(ctx, conn) {
+		return nil
+	}
+	iface := statsInterface(conn)
+	handle, err := x.nl.FromURL(iface.NetNSURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer handle.Close()
 
+	l, err := handle.LinkByName(iface.Name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = qos.Apply(ctx, handle, l, limits); err != nil {
+		return err
+	}
+	if limits.Unlimited() {
+		metadata.Map(ctx, false).Delete(qosKey{})
+	} else {
+		metadata.Map(ctx, false).Store(qosKey{}, struct{}{})
+	}
 	return nil
 }
 
-func (x *xconnectServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+// qosApplied reports whether limits were applied to the interface of the conn, as recorded in the per connection
+// metadata or, after a restart, in the persisted state
+func (x *xconnectServer) qosApplied(ctx context.Context, conn *networkservice.Connection) bool {
+	if _, ok := metadata.Map(ctx, false).Load(qosKey{}); ok {
+		return true
+	}
+	record, err := x.state.Load(conn.GetId())
+	if err != nil {
+		log.FromContext(ctx).WithField("xconnectServer", "qosApplied").Errorf("failed to load the connection state: %v", err)
+		return false
+	}
+	return record != nil && record.QoS
+}
+
+// tunnelMTU returns the MTU of the tunnel link created for the remote mechanism: the underlay MTU less the
+// encapsulation overhead, which only the package matching the type of the mechanism reports.
+func (x *xconnectServer) tunnelMTU(remoteMech *networkservice.Mechanism) int {
+	return x.underlayMTU - vxlan.Overhead(remoteMech) - geneve.Overhead(remoteMech) - gre.Overhead(remoteMech) - wireguard.Overhead(remoteMech)
+}
+
+// connectionType returns the metrics label of a connection with the given local and remote mechanisms
+func connectionType(srcMech, dstMech *networkservice.Mechanism) string {
+	if srcMech.GetCls() == "LOCAL" && dstMech.GetCls() == "LOCAL" {
+		return metrics.ConnLocal
+	}
+	return metrics.ConnRemote
+}
+
+func (x *xconnectServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (conn *networkservice.Connection, err error) {
 	logger := log.FromContext(ctx).WithField("xconnectServer", "Request")
 
-	conn, err := next.Server(ctx).Request(ctx, request)
+	connType := metrics.ConnUnknown
+	defer func(start time.Time) {
+		metrics.XconnectRequests.Inc(connType, metrics.Result(err))
+		metrics.XconnectRequestDuration.Observe(time.Since(start).Seconds(), connType, metrics.Result(err))
+	}(time.Now())
+
+	// The connection is refreshed, it expires through the timeout element of the endpoint chain from now on
+	x.cancelExpiration(request.GetConnection().GetId())
+
+	conn, err = next.Server(ctx).Request(ctx, request)
 	if err != nil {
-		closeConnection(ctx, request.GetConnection())
+		x.closeConnection(ctx, request.GetConnection())
 		return nil, err
 	}
 
+	ctx = x.withRecordedLinks(ctx, conn)
+
 	// The xconnect server needs to know both the local and remote connection mechanism details. Unlike other forwarder (vpp and ovs) implementations where
 	// local and remote mechanisms are honoured at different points in the forwarder chain, the kernel forwarder creates both the mechanisms
 	// at one point only - here in the xconnect server. This departure from other forwarder implementations is needed because of the inherent
@@ -101,21 +381,34 @@ func (x *xconnectServer) Request(ctx context.Context, request *networkservice.Ne
 	dstMech, _ := mechanismmetadata.Load(ctx, true)
 	srcMech := conn.GetMechanism()
 	logger.Debugf("srcMech: %v, dstMech: %v", srcMech, dstMech)
+	connType = connectionType(srcMech, dstMech)
 
 	// Check if the connection is LOCAL or REMOTE
 	// If both the local and remote connection mechanisms are LOCAL, the connection request is considered to be LOCAL.
 	// If either of them is REMOTE (the other would be LOCAL, both cannot be REMOTE), the connection request is considered REMOTE.
 	if srcMech.Cls == "LOCAL" && dstMech.Cls == "LOCAL" {
+		// The veth links are set to the MTU of the underlay, so that the pods get the same MTU whether they are
+		// co-located or not. The MTU is published in the connection context for the connectioncontextkernel
+		// elements to apply it to the pod side as well.
+		mtu.Set(conn, x.underlayMTU)
 		srcConn := conn
 		dstConn := createConnectionWithMechanism(dstMech, conn)
-		err := handleLocalConnection(ctx, srcConn, dstConn, request)
+		err := x.handleLocalConnection(ctx, srcConn, dstConn, request)
 		if err != nil {
 			return nil, err
 		}
 		// If the connection was handled successfully, we need to store the dstMech. It is needed to cleanup the connection
 		// in the Close().
 		mechanismmetadata.Store(ctx, false, dstMech)
+		x.stats.Track(ctx, conn, statsInterface(srcConn), statsInterface(dstConn))
+		x.recordCrossConnect(ctx, conn, dstMech, statsInterface(srcConn), statsInterface(dstConn))
 	} else {
+		remoteMech := srcMech
+		if dstMech.Cls == "REMOTE" {
+			remoteMech = dstMech
+		}
+		mtu.Set(conn, x.tunnelMTU(remoteMech))
+
 		var srcConn *networkservice.Connection
 		if dstMech.Cls == "REMOTE" {
 			// Create a source connection object by copying the destn mechanism info. Only the relevant mechanism info
@@ -149,7 +442,7 @@ func (x *xconnectServer) Request(ctx context.Context, request *networkservice.Ne
 		outgoing := srcMech.GetCls() == "LOCAL"
 		// For remote connections, only one interface needs to be created on the local node, hence no dstConn in the
 		// handleRemoteConnection().
-		err := handleRemoteConnection(ctx, srcConn, request, outgoing)
+		err := x.handleRemoteConnection(ctx, srcConn, request, outgoing)
 		if err != nil {
 			_, errC := x.Close(ctx, srcConn)
 			if errC != nil {
@@ -166,26 +459,40 @@ func (x *xconnectServer) Request(ctx context.Context, request *networkservice.Ne
 		if srcMech.GetCls() == "REMOTE" {
 			req2 := request.Clone()
 			req2.Connection = createConnectionWithMechanism(dstMech, conn)
-			connCtxClient := connectioncontextkernel.NewClient()
-			_, err := connCtxClient.Request(ctx, req2)
+			_, err := x.connCtxClient.Request(ctx, req2)
 			if err != nil {
 				return nil, err
 			}
 		}
+		x.stats.Track(ctx, conn, statsInterface(srcConn))
+		x.recordCrossConnect(ctx, conn, dstMech, statsInterface(srcConn))
 	}
 
 	return conn, nil
 }
 
 func (x *xconnectServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	x.cancelExpiration(conn.GetId())
+
+	connType := metrics.ConnUnknown
+	if dstMech, ok := mechanismmetadata.Load(ctx, false); ok {
+		connType = connectionType(conn.GetMechanism(), dstMech)
+	}
 
-	closeConnection(ctx, conn)
+	err := x.closeConnection(ctx, conn)
+	metrics.XconnectCloses.Inc(connType, metrics.Result(err))
+	x.stats.Untrack(conn.GetId())
+	x.admin.Remove(conn.GetId())
 
 	return next.Server(ctx).Close(ctx, conn)
 }
 
-func closeConnection(ctx context.Context, conn *networkservice.Connection) error {
+func (x *xconnectServer) closeConnection(ctx context.Context, conn *networkservice.Connection) error {
 	dstMech := mechanismmetadata.LoadAndDelete(ctx, false)
+	if dstMech == nil {
+		// The metadata is lost if the forwarder has restarted since the Request
+		conn, dstMech = x.loadState(ctx, conn)
+	}
 	if dstMech == nil {
 		return nil
 	}
@@ -194,7 +501,7 @@ func closeConnection(ctx context.Context, conn *networkservice.Connection) error
 	if srcMech.Cls == "LOCAL" && dstMech.Cls == "LOCAL" {
 		srcConn := conn.Clone()
 		dstConn := createConnectionWithMechanism(dstMech, conn)
-		err := deleteLocalConnection(ctx, srcConn, dstConn)
+		err := x.deleteLocalConnection(ctx, srcConn, dstConn)
 		if err != nil {
 			return err
 		}
@@ -223,11 +530,14 @@ func closeConnection(ctx context.Context, conn *networkservice.Connection) error
 			}
 		}
 		outgoing := conn.GetMechanism().GetCls() == "LOCAL"
-		err := deleteRemoteConnection(ctx, srcConn, outgoing)
+		err := x.deleteRemoteConnection(ctx, srcConn, outgoing)
 		if err != nil {
 			return err
 		}
 	}
 
+	if err := x.state.Delete(conn.GetId()); err != nil {
+		log.FromContext(ctx).WithField("xconnectServer", "closeConnection").Errorf("failed to delete the connection state: %v", err)
+	}
 	return nil
 }
@@ -0,0 +1,324 @@
+package xconnect
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/cls"
+	"github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/kernel"
+	vxlanMech "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/begin"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/next"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/vishvananda/netlink"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/vxlan"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/connstate"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/mechanismmetadata"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	connID      = "conn-1"
+	srcNetNSURL = "inode://4/1001"
+	dstNetNSURL = "inode://4/1002"
+)
+
+// dstMechServer stands for the xconnect client of the forwarder chain, which stores the mechanism of the outgoing
+// connection for the xconnect server
+type dstMechServer struct {
+	dstMech *networkservice.Mechanism
+}
+
+func (s *dstMechServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	mechanismmetadata.Store(ctx, true, s.dstMech.Clone())
+	return next.Server(ctx).Request(ctx, request)
+}
+
+func (s *dstMechServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return next.Server(ctx).Close(ctx, conn)
+}
+
+func kernelMechanism(netNSURL, ifaceName string) *networkservice.Mechanism {
+	mechanism := kernel.New(netNSURL)
+	mechanism.GetParameters()[kernel.InterfaceNameKey] = ifaceName
+	return mechanism
+}
+
+func vxlanMechanism(src, dst string) *networkservice.Mechanism {
+	mechanism := &networkservice.Mechanism{Cls: cls.REMOTE, Type: vxlanMech.MECHANISM, Parameters: map[string]string{}}
+	vxlanMech.ToMechanism(mechanism).SetSrcIP(net.ParseIP(src)).SetDstIP(net.ParseIP(dst)).SetVNI(42)
+	return mechanism
+}
+
+// ipsecMechanism returns the vxlan mechanism with the ipsec keys of both forwarders set
+func ipsecMechanism(mechanism *networkservice.Mechanism) *networkservice.Mechanism {
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	mechanism.GetParameters()[vxlan.SrcIPsecKey] = key
+	mechanism.GetParameters()[vxlan.DstIPsecKey] = key
+	return mechanism
+}
+
+func newFake() *nlhandle.Fake {
+	fake := nlhandle.NewFake()
+	fake.AddNamespace(srcNetNSURL)
+	fake.AddNamespace(dstNetNSURL)
+	return fake
+}
+
+func newServer(fake *nlhandle.Fake, dstMech *networkservice.Mechanism, options ...Option) networkservice.NetworkServiceServer {
+	options = append([]Option{
+		WithNetlinkProvider(fake),
+		WithConnectionContextClient(chain.NewNetworkServiceClient()),
+	}, options...)
+	return chain.NewNetworkServiceServer(
+		begin.NewServer(),
+		metadata.NewServer(),
+		NewServer(context.Background(), options...),
+		&dstMechServer{dstMech: dstMech},
+	)
+}
+
+func linkNames(t *testing.T, fake *nlhandle.Fake, netNSURL string) map[string]netlink.Link {
+	t.Helper()
+	handle, err := fake.FromURL(netNSURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	links, err := handle.LinkList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv := make(map[string]netlink.Link)
+	for _, l := range links {
+		rv[l.Attrs().Name] = l
+	}
+	return rv
+}
+
+type wantLink struct {
+	netNSURL string
+	name     string
+	linkType string
+}
+
+var xconnectTests = []struct {
+	name      string
+	srcMech   *networkservice.Mechanism
+	dstMech   *networkservice.Mechanism
+	wantLinks []wantLink
+	wantMTU   uint32
+}{
+	{
+		name:    "local",
+		srcMech: kernelMechanism(srcNetNSURL, "nsm-src"),
+		dstMech: kernelMechanism(dstNetNSURL, "nsm-dst"),
+		wantLinks: []wantLink{
+			{netNSURL: srcNetNSURL, name: "nsm-src", linkType: "veth"},
+			{netNSURL: dstNetNSURL, name: "nsm-dst", linkType: "veth"},
+		},
+		wantMTU: 1500,
+	},
+	{
+		name:      "remote outgoing",
+		srcMech:   kernelMechanism(srcNetNSURL, "nsm-src"),
+		dstMech:   vxlanMechanism("10.0.0.1", "10.0.0.2"),
+		wantLinks: []wantLink{{netNSURL: srcNetNSURL, name: "nsm-src", linkType: "vxlan"}},
+		wantMTU:   1450,
+	},
+	{
+		name:      "remote incoming",
+		srcMech:   vxlanMechanism("10.0.0.2", "10.0.0.1"),
+		dstMech:   kernelMechanism(dstNetNSURL, "nsm-dst"),
+		wantLinks: []wantLink{{netNSURL: dstNetNSURL, name: "nsm-dst", linkType: "vxlan"}},
+		wantMTU:   1450,
+	},
+	{
+		name:      "remote outgoing ipsec",
+		srcMech:   kernelMechanism(srcNetNSURL, "nsm-src"),
+		dstMech:   ipsecMechanism(vxlanMechanism("10.0.0.1", "10.0.0.2")),
+		wantLinks: []wantLink{{netNSURL: srcNetNSURL, name: "nsm-src", linkType: "vxlan"}},
+		wantMTU:   1410,
+	},
+}
+
+func TestServer(t *testing.T) {
+	for _, tc := range xconnectTests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFake()
+			server := newServer(fake, tc.dstMech)
+			request := &networkservice.NetworkServiceRequest{
+				Connection: &networkservice.Connection{Id: connID, Mechanism: tc.srcMech.Clone()},
+			}
+
+			conn, err := server.Request(context.Background(), request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := conn.GetContext().GetMTU(); got != tc.wantMTU {
+				t.Errorf("got MTU %d, want %d", got, tc.wantMTU)
+			}
+			for _, want := range tc.wantLinks {
+				l, ok := linkNames(t, fake, want.netNSURL)[want.name]
+				if !ok {
+					t.Fatalf("link %s not found in %s", want.name, want.netNSURL)
+				}
+				if l.Type() != want.linkType {
+					t.Errorf("link %s: got type %s, want %s", want.name, l.Type(), want.linkType)
+				}
+			}
+
+			// A refresh leaves the links in place
+			if _, err = server.Request(context.Background(), request); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(linkNames(t, fake, srcNetNSURL)) + len(linkNames(t, fake, dstNetNSURL)); got != len(tc.wantLinks) {
+				t.Errorf("got %d links after the refresh, want %d", got, len(tc.wantLinks))
+			}
+
+			if _, err = server.Close(context.Background(), conn); err != nil {
+				t.Fatal(err)
+			}
+			for _, netNSURL := range []string{srcNetNSURL, dstNetNSURL} {
+				if links := linkNames(t, fake, netNSURL); len(links) != 0 {
+					t.Errorf("%s: got %d links left after the Close, want none", netNSURL, len(links))
+				}
+			}
+		})
+	}
+}
+
+func TestServerExpireAfterRestart(t *testing.T) {
+	for _, tc := range xconnectTests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFake()
+			store, err := connstate.NewStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			request := &networkservice.NetworkServiceRequest{
+				Connection: &networkservice.Connection{Id: connID, Mechanism: tc.srcMech.Clone()},
+			}
+			if _, err = newServer(fake, tc.dstMech, WithStateStore(store)).Request(context.Background(), request); err != nil {
+				t.Fatal(err)
+			}
+
+			// The restarted forwarder has no metadata for the connection, which has already expired: its links are
+			// found from the state store and torn down
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			_ = NewServer(ctx, WithNetlinkProvider(fake), WithStateStore(store))
+			for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+				record, err := store.Load(connID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if record == nil {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("the expired connection was not torn down")
+				}
+			}
+			for _, netNSURL := range []string{srcNetNSURL, dstNetNSURL} {
+				if links := linkNames(t, fake, netNSURL); len(links) != 0 {
+					t.Errorf("%s: got %d links left after the expiration, want none", netNSURL, len(links))
+				}
+			}
+		})
+	}
+}
+
+func TestServerAdoptAfterRestart(t *testing.T) {
+	for _, tc := range xconnectTests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFake()
+			store, err := connstate.NewStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			request := &networkservice.NetworkServiceRequest{
+				Connection: &networkservice.Connection{
+					Id:        connID,
+					Mechanism: tc.srcMech.Clone(),
+					Path: &networkservice.Path{PathSegments: []*networkservice.PathSegment{
+						{Expires: timestamppb.New(time.Now().Add(time.Hour))},
+					}},
+				},
+			}
+			if _, err = newServer(fake, tc.dstMech, WithStateStore(store)).Request(context.Background(), request); err != nil {
+				t.Fatal(err)
+			}
+			indexes := make(map[wantLink]int)
+			for _, want := range tc.wantLinks {
+				indexes[want] = linkNames(t, fake, want.netNSURL)[want.name].Attrs().Index
+			}
+
+			// The restarted forwarder takes over the recorded links when the connection is refreshed
+			if _, err = newServer(fake, tc.dstMech, WithStateStore(store)).Request(context.Background(), request.Clone()); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tc.wantLinks {
+				l, ok := linkNames(t, fake, want.netNSURL)[want.name]
+				if !ok {
+					t.Fatalf("link %s not found in %s", want.name, want.netNSURL)
+				}
+				if l.Attrs().Index != indexes[want] {
+					t.Errorf("link %s was created again instead of being adopted", want.name)
+				}
+			}
+		})
+	}
+}
+
+func TestServerStaleAfterRestart(t *testing.T) {
+	for _, tc := range xconnectTests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFake()
+			store, err := connstate.NewStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Links with the names of the connection, even in the group of the forwarder links, that the state store
+			// has no record of
+			indexes := make(map[wantLink]int)
+			for _, want := range tc.wantLinks {
+				handle, err := fake.FromURL(want.netNSURL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				stale := &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: want.name, Group: link.Group}}
+				if err = handle.LinkAdd(stale); err != nil {
+					t.Fatal(err)
+				}
+				indexes[want] = stale.Attrs().Index
+				handle.Close()
+			}
+
+			request := &networkservice.NetworkServiceRequest{
+				Connection: &networkservice.Connection{Id: connID, Mechanism: tc.srcMech.Clone()},
+			}
+			if _, err = newServer(fake, tc.dstMech, WithStateStore(store)).Request(context.Background(), request); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tc.wantLinks {
+				l, ok := linkNames(t, fake, want.netNSURL)[want.name]
+				if !ok {
+					t.Fatalf("link %s not found in %s", want.name, want.netNSURL)
+				}
+				if l.Attrs().Index == indexes[want] || l.Type() != want.linkType {
+					t.Errorf("got link %s of type %s, want the stale one replaced by a %s link", want.name, l.Type(), want.linkType)
+				}
+			}
+		})
+	}
+}
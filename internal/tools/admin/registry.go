@@ -0,0 +1,140 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package admin implements the admin API of the forwarder, which lists the active cross connects along with the state
+// of their links, read from netlink on demand, and lets an authenticated operator force-close one of them. It is
+// served as JSON over HTTP on a local unix socket and optionally on a TCP address.
+package admin
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/begin"
+	"github.com/pkg/errors"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/connstate"
+)
+
+// CrossConnect is an active cross connect of the forwarder
+type CrossConnect struct {
+	Conn   *networkservice.Connection
+	Record *connstate.Record
+	// Created is the time of the first Request of the connection, Refreshed the one of the latest
+	Created   time.Time
+	Refreshed time.Time
+
+	eventFactory begin.EventFactory
+}
+
+// ErrNotFound is returned for the connections without an active cross connect
+var ErrNotFound = errors.New("cross connect not found")
+
+// Registry keeps track of the active cross connects. A nil Registry tracks nothing.
+type Registry struct {
+	mu            sync.Mutex
+	crossConnects map[string]*CrossConnect
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{crossConnects: make(map[string]*CrossConnect)}
+}
+
+// Add adds the cross connect of the conn described by record, or updates it on refresh. eventFactory is the one of
+// the Request of the conn, the cross connect is force-closed through it.
+func (r *Registry) Add(conn *networkservice.Connection, record *connstate.Record, eventFactory begin.EventFactory) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	created := now
+	if prev, ok := r.crossConnects[record.ID]; ok {
+		created = prev.Created
+	}
+	r.crossConnects[record.ID] = &CrossConnect{
+		Conn:      conn.Clone(),
+		Record:    record,
+		Created:   created,
+		Refreshed: now,
+
+		eventFactory: eventFactory,
+	}
+}
+
+// Remove removes the cross connect of the connection
+func (r *Registry) Remove(connID string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.crossConnects, connID)
+}
+
+// Get returns the cross connect of the connection, or nil if there is none
+func (r *Registry) Get(connID string) *CrossConnect {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.crossConnects[connID]
+}
+
+// List returns the cross connects sorted by connection ID
+func (r *Registry) List() []*CrossConnect {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rv := make([]*CrossConnect, 0, len(r.crossConnects))
+	for _, xc := range r.crossConnects {
+		rv = append(rv, xc)
+	}
+	sort.Slice(rv, func(i, j int) bool { return rv[i].Record.ID < rv[j].Record.ID })
+	return rv
+}
+
+// ForceClose closes the connection as if its Close was received: the cross connect is torn down, the outgoing
+// connection is closed and the incoming one is reported deleted to its monitors.
+func (r *Registry) ForceClose(ctx context.Context, connID string) error {
+	xc := r.Get(connID)
+	if xc == nil {
+		return ErrNotFound
+	}
+	if xc.eventFactory == nil {
+		return errors.Errorf("cross connect %s can't be closed", connID)
+	}
+	select {
+	case err := <-xc.eventFactory.Close():
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
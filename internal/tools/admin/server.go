@@ -0,0 +1,316 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	vxlanMech "github.com/networkservicemesh/api/pkg/api/networkservice/mechanisms/vxlan"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/geneve"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/gre"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/wireguard"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const crossConnectsPath = "/xconnects"
+
+type crossConnectView struct {
+	ID             string            `json:"id"`
+	NetworkService string            `json:"network_service"`
+	Created        time.Time         `json:"created"`
+	Refreshed      time.Time         `json:"refreshed"`
+	Expires        *time.Time        `json:"expires,omitempty"`
+	Path           []pathSegmentView `json:"path"`
+	Src            mechanismView     `json:"src"`
+	Dst            mechanismView     `json:"dst"`
+	Interfaces     []interfaceView   `json:"interfaces"`
+}
+
+type pathSegmentView struct {
+	Name    string     `json:"name"`
+	ID      string     `json:"id"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+type mechanismView struct {
+	Cls        string            `json:"cls"`
+	Type       string            `json:"type"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	Tunnel     *tunnelView       `json:"tunnel,omitempty"`
+}
+
+type tunnelView struct {
+	VNI   uint32 `json:"vni,omitempty"`
+	SrcIP string `json:"src_ip"`
+	DstIP string `json:"dst_ip"`
+}
+
+type interfaceView struct {
+	NetNSURL string    `json:"netns_url"`
+	Name     string    `json:"name"`
+	Link     *linkView `json:"link,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type linkView struct {
+	Index        int    `json:"index"`
+	Type         string `json:"type"`
+	MTU          int    `json:"mtu"`
+	OperState    string `json:"oper_state"`
+	Up           bool   `json:"up"`
+	Alias        string `json:"alias,omitempty"`
+	HardwareAddr string `json:"hardware_addr,omitempty"`
+}
+
+type handler struct {
+	ctx      context.Context
+	registry *Registry
+	nl       nlhandle.Provider
+	token    string
+}
+
+// Option is an option pattern for NewHandler
+type Option func(h *handler)
+
+// WithToken sets the bearer token the requests to force-close a cross connect must carry. Force-closing is disabled
+// if it is not set.
+func WithToken(token string) Option {
+	return func(h *handler) {
+		h.token = token
+	}
+}
+
+// NewHandler returns the http.Handler of the admin API:
+//
+//	GET    /xconnects       lists the active cross connects
+//	GET    /xconnects/<id>  returns the cross connect of the connection <id>
+//	DELETE /xconnects/<id>  force-closes the cross connect of the connection <id>
+//
+// The state of the links of the cross connects is read through nl on every call. The force-closes are logged to the
+// logger of ctx.
+func NewHandler(ctx context.Context, registry *Registry, nl nlhandle.Provider, options ...Option) http.Handler {
+	h := &handler{ctx: ctx, registry: registry, nl: nl}
+	for _, opt := range options {
+		opt(h)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(crossConnectsPath, h.list)
+	mux.HandleFunc(crossConnectsPath+"/", h.get)
+	return mux
+}
+
+func (h *handler) list(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	views := []*crossConnectView{}
+	for _, xc := range h.registry.List() {
+		views = append(views, h.view(xc))
+	}
+	writeJSON(rw, views)
+}
+
+func (h *handler) get(rw http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodDelete {
+		h.forceClose(rw, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	xc := h.registry.Get(strings.TrimPrefix(r.URL.Path, crossConnectsPath+"/"))
+	if xc == nil {
+		http.Error(rw, "cross connect not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(rw, h.view(xc))
+}
+
+func (h *handler) forceClose(rw http.ResponseWriter, r *http.Request) {
+	connID := strings.TrimPrefix(r.URL.Path, crossConnectsPath+"/")
+	logger := log.FromContext(h.ctx).
+		WithField("admin", "ForceClose").
+		WithField("connection.Id", connID).
+		WithField("remote", r.RemoteAddr)
+
+	if h.token == "" {
+		logger.Warn("denied: force-closing is disabled")
+		http.Error(rw, "force-closing is disabled", http.StatusForbidden)
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		logger.Warn("denied: invalid token")
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	err := h.registry.ForceClose(r.Context(), connID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		logger.Info("failed: cross connect not found")
+		http.Error(rw, err.Error(), http.StatusNotFound)
+	case err != nil:
+		logger.Errorf("failed: %v", err)
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+	default:
+		logger.Info("completed")
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeJSON(rw http.ResponseWriter, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(rw)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
+
+func (h *handler) view(xc *CrossConnect) *crossConnectView {
+	v := &crossConnectView{
+		ID:             xc.Record.ID,
+		NetworkService: xc.Conn.GetNetworkService(),
+		Created:        xc.Created,
+		Refreshed:      xc.Refreshed,
+		Src:            newMechanismView(xc.Record.SrcMechanism),
+		Dst:            newMechanismView(xc.Record.DstMechanism),
+	}
+	if !xc.Record.Expires.IsZero() {
+		v.Expires = &xc.Record.Expires
+	}
+	for _, segment := range xc.Conn.GetPath().GetPathSegments() {
+		sv := pathSegmentView{Name: segment.GetName(), ID: segment.GetId()}
+		if segment.GetExpires() != nil {
+			expires := segment.GetExpires().AsTime()
+			sv.Expires = &expires
+		}
+		v.Path = append(v.Path, sv)
+	}
+	for _, l := range xc.Record.Links {
+		v.Interfaces = append(v.Interfaces, h.interfaceView(l.NetNSURL, l.Name))
+	}
+	return v
+}
+
+func newMechanismView(m *networkservice.Mechanism) mechanismView {
+	v := mechanismView{Cls: m.GetCls(), Type: m.GetType(), Parameters: m.GetParameters()}
+	if mechanism := vxlanMech.ToMechanism(m); mechanism != nil {
+		v.Tunnel = &tunnelView{VNI: mechanism.VNI(), SrcIP: mechanism.SrcIP().String(), DstIP: mechanism.DstIP().String()}
+	} else if mechanism := geneve.ToMechanism(m); mechanism != nil {
+		v.Tunnel = &tunnelView{VNI: mechanism.VNI(), SrcIP: mechanism.SrcIP().String(), DstIP: mechanism.DstIP().String()}
+	} else if mechanism := gre.ToMechanism(m); mechanism != nil {
+		v.Tunnel = &tunnelView{VNI: mechanism.Key(), SrcIP: mechanism.SrcIP().String(), DstIP: mechanism.DstIP().String()}
+	} else if mechanism := wireguard.ToMechanism(m); mechanism != nil {
+		v.Tunnel = &tunnelView{SrcIP: mechanism.SrcIP().String(), DstIP: mechanism.DstIP().String()}
+	}
+	return v
+}
+
+func (h *handler) interfaceView(netNSURL, name string) interfaceView {
+	v := interfaceView{NetNSURL: netNSURL, Name: name}
+	handle, err := h.nl.FromURL(netNSURL)
+	if err != nil {
+		v.Error = err.Error()
+		return v
+	}
+	defer handle.Close()
+
+	l, err := handle.LinkByName(name)
+	if err != nil {
+		v.Error = err.Error()
+		return v
+	}
+	attrs := l.Attrs()
+	v.Link = &linkView{
+		Index:     attrs.Index,
+		Type:      l.Type(),
+		MTU:       attrs.MTU,
+		OperState: attrs.OperState.String(),
+		Up:        attrs.Flags&net.FlagUp != 0,
+		Alias:     attrs.Alias,
+	}
+	if attrs.HardwareAddr != nil {
+		v.Link.HardwareAddr = attrs.HardwareAddr.String()
+	}
+	return v
+}
+
+// ListenAndServe serves handler on the unix socket path, or on the TCP address if network is "tcp", until ctx is
+// done. The connections are served over TLS with tlsConfig if it is not nil. A stale socket file left behind by a
+// previous forwarder instance is replaced. Errors are returned on the channel, which is closed when the server stops.
+func ListenAndServe(ctx context.Context, network, address string, handler http.Handler, tlsConfig *tls.Config) <-chan error {
+	errCh := make(chan error, 1)
+
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         tlsConfig,
+	}
+
+	if network == "unix" {
+		if err := os.MkdirAll(filepath.Dir(address), 0o700); err != nil {
+			errCh <- errors.Wrapf(err, "failed to create the directory of %s", address)
+			close(errCh)
+			return errCh
+		}
+		if err := os.Remove(address); err != nil && !os.IsNotExist(err) {
+			errCh <- errors.Wrapf(err, "failed to remove %s", address)
+			close(errCh)
+			return errCh
+		}
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		errCh <- errors.Wrapf(err, "failed to listen on %s", address)
+		close(errCh)
+		return errCh
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		defer close(errCh)
+		serve := server.Serve
+		if tlsConfig != nil {
+			serve = func(ln net.Listener) error { return server.ServeTLS(ln, "", "") }
+		}
+		if err := serve(ln); err != nil && err != http.ErrServerClosed {
+			errCh <- errors.WithStack(err)
+		}
+	}()
+
+	return errCh
+}
@@ -0,0 +1,55 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+func TestForceCloseAuthorization(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options []Option
+		header  string
+		want    int
+	}{
+		{name: "disabled", header: "Bearer secret", want: http.StatusForbidden},
+		{name: "no token", options: []Option{WithToken("secret")}, want: http.StatusUnauthorized},
+		{name: "invalid token", options: []Option{WithToken("secret")}, header: "Bearer other", want: http.StatusUnauthorized},
+		{name: "authorized", options: []Option{WithToken("secret")}, header: "Bearer secret", want: http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := NewHandler(context.Background(), NewRegistry(), nlhandle.NewFake(), tc.options...)
+			r := httptest.NewRequest(http.MethodDelete, crossConnectsPath+"/conn-1", http.NoBody)
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, r)
+			if rw.Code != tc.want {
+				t.Errorf("got status %d, want %d", rw.Code, tc.want)
+			}
+		})
+	}
+}
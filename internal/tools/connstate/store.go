@@ -0,0 +1,157 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package connstate persists the state needed to tear down the cross connects of the forwarder, so that the links of
+// a connection can still be deleted by its Close after a forwarder crash or restart, when the per connection metadata
+// is lost.
+package connstate
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+)
+
+const recordSuffix = ".json"
+
+// Link identifies a link created for a connection
+type Link struct {
+	NetNSURL string `json:"netns_url"`
+	Name     string `json:"name"`
+}
+
+// Record is the state of the cross connect of a connection
+type Record struct {
+	ID           string                    `json:"id"`
+	SrcMechanism *networkservice.Mechanism `json:"src_mechanism"`
+	DstMechanism *networkservice.Mechanism `json:"dst_mechanism"`
+	Links        []Link                    `json:"links"`
+	// Expires is the time the connection expires at unless it is refreshed
+	Expires time.Time `json:"expires"`
+	// QoS reports whether qdiscs limiting the bandwidth of the connection were added to its links
+	QoS bool `json:"qos,omitempty"`
+}
+
+// Store keeps the records in a directory, one JSON file per connection. It is meant to be on a hostPath volume, so
+// that it survives the forwarder pod. A nil Store stores nothing.
+type Store struct {
+	dir string
+}
+
+// NewStore returns a Store keeping the records in dir, which is created if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create the connection state directory %s", dir)
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) filename(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+recordSuffix)
+}
+
+// Save writes the record, replacing the previous one of the connection. The file is replaced atomically, so that a
+// crash never leaves a partially written record behind.
+func (s *Store) Save(record *Record) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	tmp, err := os.CreateTemp(s.dir, ".record-*")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmp.Name(), s.filename(record.ID)))
+}
+
+// Load returns the record of the connection, or nil if there is none
+func (s *Store) Load(id string) (*Record, error) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.read(s.filename(id))
+}
+
+// Delete removes the record of the connection, if any
+func (s *Store) Delete(id string) error {
+	if s == nil {
+		return nil
+	}
+	if err := os.Remove(s.filename(id)); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// List returns all the records
+func (s *Store) List() ([]*Record, error) {
+	if s == nil {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var rv []*Record
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), recordSuffix) {
+			continue
+		}
+		record, err := s.read(filepath.Join(s.dir, entry.Name()))
+		if err != nil || record == nil {
+			continue
+		}
+		rv = append(rv, record)
+	}
+	return rv, nil
+}
+
+func (s *Store) read(filename string) (*Record, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	record := &Record{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, errors.Wrapf(err, "invalid connection state record %s", filename)
+	}
+	return record, nil
+}
@@ -40,33 +40,15 @@ func GetInode(file string) (uint64, error) {
 	return stat.Ino, nil
 }
 
-// ResolvePodNsByInode Traverse /proc/<pid>/<suffix> files,
-// compare their inodes with inode parameter and returns file if inode matches
+// ResolvePodNsByInode returns the /proc/<pid>/ns/net file of a process in the network namespace with the inode.
+// It is looked up in the index of the DefaultNetNSResolver, which only scans /proc on a miss.
 func ResolvePodNsByInode(inode uint64) (string, error) {
-	files, err := ioutil.ReadDir("/proc")
+	filename, err := defaultResolver.Resolve(inode)
 	if err != nil {
-		return "", errors.Wrap(err, "can't read /proc directory")
-	}
-
-	for _, f := range files {
-		name := f.Name()
-		if isDigits(name) {
-			filename := path.Join("/proc", name, "/ns/net")
-			tryInode, err := GetInode(filename)
-			if err != nil {
-				// Just report into log, do not exit
-				logrus.Errorf("Can't find %s Error: %v", filename, err)
-				continue
-			}
-			if tryInode == inode {
-				cmdFound, _ := GetCmdline(name)
-				logrus.Infof("Found a pod attached to the inode: %v, filename: %v", cmdFound, filename)
-				return filename, nil
-			}
-		}
+		return "", err
 	}
-
-	return "", errors.New("not found")
+	logrus.Debugf("Found a pod attached to the inode: %v, filename: %v", inode, filename)
+	return filename, nil
 }
 
 func filenameToURL(filename string) (u *url.URL, err error) {
@@ -88,7 +70,16 @@ func GetNetnsInodeFromFile(fileUrl string) (*url.URL, error) {
 		return nil, err
 	}
 	pidstr := strconv.FormatUint(pid, 10)
-	return filenameToURL("/proc/" + pidstr + "/ns/net")
+	filename := "/proc/" + pidstr + "/ns/net"
+	u, err := filenameToURL(filename)
+	if err != nil {
+		return nil, err
+	}
+	// The inode URL is sent upstream and comes back with the refreshes and the Close of the connection
+	if inode, err := convertUrlToInode(u.String()); err == nil {
+		defaultResolver.Remember(inode, filename)
+	}
+	return u, nil
 }
 
 func GetAllNetNs() ([]uint64, error) {
@@ -111,6 +102,46 @@ func GetAllNetNs() ([]uint64, error) {
 	return inodes, nil
 }
 
+// GetAllNetNsURLs returns a file URL for every network namespace found in /proc/<pid>/ns/net,
+// keyed by the inode of the namespace.
+func GetAllNetNsURLs() (map[uint64]string, error) {
+	files, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, errors.Wrap(err, "can't read /proc directory")
+	}
+	urls := make(map[uint64]string)
+	for _, f := range files {
+		name := f.Name()
+		if isDigits(name) {
+			filename := path.Join("/proc", name, "/ns/net")
+			inode, err := GetInode(filename)
+			if err != nil {
+				continue
+			}
+			if _, ok := urls[inode]; !ok {
+				urls[inode] = (&url.URL{Scheme: "file", Path: filename}).String()
+			}
+		}
+	}
+	return urls, nil
+}
+
+// GetNetnsInodeFromURL returns the inode of the network namespace referred to by either a
+// file:///proc/<pid>/ns/net or an inode://<dev>/<ino> URL.
+func GetNetnsInodeFromURL(netnsUrl string) (uint64, error) {
+	urlObj, err := url.Parse(netnsUrl)
+	if err != nil {
+		return 0, err
+	}
+	switch urlObj.Scheme {
+	case "file":
+		return GetInode(urlObj.Path)
+	case "inode":
+		return convertUrlToInode(netnsUrl)
+	}
+	return 0, errors.Errorf("unsupported netns url: %s", netnsUrl)
+}
+
 func GetCmdline(pid string) (string, error) {
 	data, err := ioutil.ReadFile(path.Join("/proc/", pid, "cmdline"))
 	if err != nil {
@@ -0,0 +1,261 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// fullRescanInterval is the minimum time between two full rescans triggered by lookup misses
+	fullRescanInterval = 5 * time.Second
+	// missTTL is the time a lookup miss is cached for, so that the lookups of a namespace which no longer exists do
+	// not trigger a rescan each
+	missTTL = time.Second
+)
+
+// NetNSResolver resolves network namespace inodes to /proc/<pid>/ns/net paths from an index of the processes of the
+// node. The index is built by a single scan of /proc and kept up to date by incremental rescans, which only stat the
+// namespaces of the processes started since the previous scan. Hits are validated with a stat of the path, since the
+// process may have exited or moved to another namespace in the meantime.
+// The scans run outside of the lock of the index, one at a time, so that the lookups of the indexed namespaces are
+// not held up by them. The full rescans, which stat the namespaces of all the processes, are rate-limited and the
+// misses are cached briefly.
+type NetNSResolver struct {
+	procDir string
+
+	scanMu sync.Mutex
+
+	mu       sync.Mutex
+	byInode  map[uint64]string
+	byPID    map[string]uint64
+	misses   map[uint64]time.Time
+	scans    uint64
+	lastFull time.Time
+}
+
+var defaultResolver = NewNetNSResolver("/proc")
+
+// DefaultNetNSResolver returns the resolver shared by the functions of this package and the recvfd and sendfd
+// elements
+func DefaultNetNSResolver() *NetNSResolver {
+	return defaultResolver
+}
+
+// NewNetNSResolver returns a resolver of the network namespaces of the processes in procDir
+func NewNetNSResolver(procDir string) *NetNSResolver {
+	return &NetNSResolver{
+		procDir: procDir,
+		byInode: make(map[uint64]string),
+		byPID:   make(map[string]uint64),
+		misses:  make(map[uint64]time.Time),
+	}
+}
+
+// Run rescans the processes every interval until the ctx is done, so that the namespaces of new pods are usually
+// indexed before the first Request for them
+func (r *NetNSResolver) Run(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.rescan(r.scanCount(), false)
+			}
+		}
+	}()
+}
+
+// Resolve returns the path of a /proc/<pid>/ns/net file of the network namespace with the inode
+func (r *NetNSResolver) Resolve(inode uint64) (string, error) {
+	filename, ok, scans := r.lookup(inode)
+	if ok {
+		return filename, nil
+	}
+	if r.missed(inode) {
+		return "", errors.New("not found")
+	}
+	if r.rescan(scans, false) {
+		if filename, ok, scans = r.lookup(inode); ok {
+			return filename, nil
+		}
+	}
+	// An indexed process may have moved to the namespace with unshare or setns
+	if r.rescan(scans, true) {
+		if filename, ok, _ = r.lookup(inode); ok {
+			return filename, nil
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for missed, expiry := range r.misses {
+		if now.After(expiry) {
+			delete(r.misses, missed)
+		}
+	}
+	r.misses[inode] = now.Add(missTTL)
+	return "", errors.New("not found")
+}
+
+// Remember adds the namespace file of a process to the index, when its inode is learnt by other means
+func (r *NetNSResolver) Remember(inode uint64, filename string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pid, ok := r.pid(filename); ok {
+		r.byPID[pid] = inode
+	}
+	r.byInode[inode] = filename
+}
+
+// pid returns the pid of a <procDir>/<pid>/ns/net filename
+func (r *NetNSResolver) pid(filename string) (string, bool) {
+	dir, file := path.Split(filename)
+	if file != "net" {
+		return "", false
+	}
+	dir, file = path.Split(path.Clean(dir))
+	if file != "ns" {
+		return "", false
+	}
+	dir, pid := path.Split(path.Clean(dir))
+	if path.Clean(dir) != path.Clean(r.procDir) || !isDigits(pid) {
+		return "", false
+	}
+	return pid, true
+}
+
+// lookup returns the indexed path of the network namespace with the inode, along with the number of scans the
+// index has been updated with
+func (r *NetNSResolver) lookup(inode uint64) (filename string, ok bool, scans uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filename, ok = r.lookupLocked(inode)
+	return filename, ok, r.scans
+}
+
+// missed reports whether a lookup of the inode has missed recently
+func (r *NetNSResolver) missed(inode uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	expiry, ok := r.misses[inode]
+	return ok && time.Now().Before(expiry)
+}
+
+func (r *NetNSResolver) scanCount() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.scans
+}
+
+func (r *NetNSResolver) lookupLocked(inode uint64) (string, bool) {
+	filename, ok := r.byInode[inode]
+	if !ok {
+		return "", false
+	}
+	if tryInode, err := GetInode(filename); err == nil && tryInode == inode {
+		return filename, true
+	}
+	delete(r.byInode, inode)
+	return "", false
+}
+
+// rescan updates the index with the processes started since the previous scan, or with all of them if full is set,
+// and drops the processes that have exited. The incremental scan is skipped if the index has been updated since the
+// caller read the scan count since, which coalesces the scans of concurrent misses. The full scan is skipped if
+// another one has run within the fullRescanInterval. It reports whether the index may have been updated.
+func (r *NetNSResolver) rescan(since uint64, full bool) bool {
+	r.scanMu.Lock()
+	defer r.scanMu.Unlock()
+
+	r.mu.Lock()
+	if !full && r.scans != since {
+		r.mu.Unlock()
+		return true
+	}
+	if full {
+		if time.Since(r.lastFull) < fullRescanInterval {
+			r.mu.Unlock()
+			return false
+		}
+		r.lastFull = time.Now()
+	}
+	known := make(map[string]uint64, len(r.byPID))
+	if !full {
+		for pid, inode := range r.byPID {
+			known[pid] = inode
+		}
+	}
+	r.mu.Unlock()
+
+	dir, err := os.Open(r.procDir)
+	if err != nil {
+		logrus.Errorf("Can't read %s directory: %v", r.procDir, err)
+		return false
+	}
+	names, err := dir.Readdirnames(-1)
+	_ = dir.Close()
+	if err != nil {
+		logrus.Errorf("Can't read %s directory: %v", r.procDir, err)
+		return false
+	}
+
+	scanned := make(map[string]uint64, len(names))
+	for _, pid := range names {
+		if !isDigits(pid) {
+			continue
+		}
+		if inode, ok := known[pid]; ok {
+			scanned[pid] = inode
+			continue
+		}
+		inode, err := GetInode(path.Join(r.procDir, pid, "ns", "net"))
+		if err != nil {
+			// The process has exited since the directory was read
+			continue
+		}
+		scanned[pid] = inode
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.updateLocked(scanned, full)
+	r.scans++
+	return true
+}
+
+// updateLocked replaces the processes of the index with the scanned ones, and points the namespaces whose indexed
+// process has exited, or which have not been indexed yet, to one of the processes left in them
+func (r *NetNSResolver) updateLocked(scanned map[string]uint64, full bool) {
+	r.byPID = scanned
+	for pid, inode := range r.byPID {
+		filename := path.Join(r.procDir, pid, "ns", "net")
+		if indexed, ok := r.byInode[inode]; !ok || full {
+			r.byInode[inode] = filename
+		} else if p, ok := r.pid(indexed); ok {
+			if _, ok := r.byPID[p]; !ok {
+				r.byInode[inode] = filename
+			}
+		}
+	}
+	for inode, filename := range r.byInode {
+		if p, ok := r.pid(filename); ok {
+			if _, ok := r.byPID[p]; !ok {
+				delete(r.byInode, inode)
+			}
+		}
+	}
+}
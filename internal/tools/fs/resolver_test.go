@@ -0,0 +1,92 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// addProcess creates the network namespace file of the process pid in procDir, replacing the previous one, and
+// returns its inode. The previous file is kept aside, so that its inode is not reused.
+func addProcess(t *testing.T, procDir, pid string) uint64 {
+	t.Helper()
+	dir := filepath.Join(procDir, pid, "ns")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, "net")
+	if _, err := os.Stat(filename); err == nil {
+		if err = os.Rename(filename, filepath.Join(t.TempDir(), "net")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filename, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	inode, err := GetInode(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inode
+}
+
+func TestNetNSResolver(t *testing.T) {
+	procDir := t.TempDir()
+	r := NewNetNSResolver(procDir)
+
+	inode := addProcess(t, procDir, "100")
+	filename, err := r.Resolve(inode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(procDir, "100", "ns", "net"); filename != want {
+		t.Errorf("got %s, want %s", filename, want)
+	}
+
+	// A new process is found by the incremental rescan
+	inode = addProcess(t, procDir, "200")
+	if _, err = r.Resolve(inode); err != nil {
+		t.Fatal(err)
+	}
+
+	// An indexed process moving to another namespace is only found by the full rescan
+	inode = addProcess(t, procDir, "100")
+	if _, err = r.Resolve(inode); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next full rescan is rate-limited, and the miss is cached
+	inode = addProcess(t, procDir, "200")
+	if _, err = r.Resolve(inode); err == nil {
+		t.Fatal("expected the full rescan to be rate-limited")
+	}
+	if !r.missed(inode) {
+		t.Error("expected the miss to be cached")
+	}
+
+	// The lookups of the indexed namespaces are not affected by the cached misses
+	r.Remember(inode, filepath.Join(procDir, "200", "ns", "net"))
+	if _, err = r.Resolve(inode); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNetNSResolverExited(t *testing.T) {
+	procDir := t.TempDir()
+	r := NewNetNSResolver(procDir)
+
+	inode := addProcess(t, procDir, "100")
+	if _, err := r.Resolve(inode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(procDir, "100")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Resolve(inode); err == nil {
+		t.Error("expected the namespace of the exited process not to be found")
+	}
+	r.rescan(r.scanCount(), false)
+	if len(r.byPID) != 0 || len(r.byInode) != 0 {
+		t.Errorf("expected the exited process to be dropped from the index, got %v and %v", r.byPID, r.byInode)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package health reports the liveness and readiness of the forwarder over HTTP, on /healthz and /readyz, and over
+// the gRPC health protocol.
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Check returns an error if the checked condition does not hold
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name     string
+	check    Check
+	liveness bool
+}
+
+// Checker evaluates the liveness and readiness checks of the forwarder. The liveness checks are part of the readiness
+// too. Besides the checks evaluated on demand, conditions can be reported by the components owning them with Set.
+type Checker struct {
+	mu     sync.Mutex
+	checks []*namedCheck
+	states map[string]error
+}
+
+// NewChecker returns a Checker without any check, which reports live and ready
+func NewChecker() *Checker {
+	return &Checker{states: make(map[string]error)}
+}
+
+// AddLivenessCheck adds a check the forwarder is restarted on failure of
+func (c *Checker) AddLivenessCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, &namedCheck{name: name, check: check, liveness: true})
+}
+
+// AddReadinessCheck adds a check the forwarder is not ready on failure of
+func (c *Checker) AddReadinessCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, &namedCheck{name: name, check: check})
+}
+
+// Set reports the state of the readiness condition name, err being nil once it holds
+func (c *Checker) Set(name string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.states[name] = err
+}
+
+// Live evaluates the liveness checks and returns the failed ones, by name
+func (c *Checker) Live(ctx context.Context) map[string]error {
+	return c.evaluate(ctx, true)
+}
+
+// Ready evaluates all the checks and returns the failed ones, by name
+func (c *Checker) Ready(ctx context.Context) map[string]error {
+	return c.evaluate(ctx, false)
+}
+
+func (c *Checker) evaluate(ctx context.Context, livenessOnly bool) map[string]error {
+	c.mu.Lock()
+	checks := append([]*namedCheck(nil), c.checks...)
+	failed := make(map[string]error)
+	if !livenessOnly {
+		for name, err := range c.states {
+			if err != nil {
+				failed[name] = err
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	for _, nc := range checks {
+		if livenessOnly && !nc.liveness {
+			continue
+		}
+		if err := nc.check(ctx); err != nil {
+			failed[nc.name] = err
+		}
+	}
+	return failed
+}
+
+// summary returns an error listing the failed checks, or nil if there is none
+func summary(failed map[string]error) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(failed))
+	for name := range failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msg := ""
+	for _, name := range names {
+		if msg != "" {
+			msg += "; "
+		}
+		msg += name + ": " + failed[name].Error()
+	}
+	return errors.New(msg)
+}
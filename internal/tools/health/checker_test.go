@@ -0,0 +1,148 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package health
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func failing(msg string) Check {
+	return func(ctx context.Context) error {
+		return errors.New(msg)
+	}
+}
+
+func passing(ctx context.Context) error {
+	return nil
+}
+
+func TestChecker(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		setup     func(c *Checker)
+		wantLive  []string
+		wantReady []string
+	}{
+		{name: "no checks"},
+		{
+			name: "passing checks",
+			setup: func(c *Checker) {
+				c.AddLivenessCheck("netlink", passing)
+				c.AddReadinessCheck("svid", passing)
+			},
+		},
+		{
+			name:      "failed liveness check",
+			setup:     func(c *Checker) { c.AddLivenessCheck("netlink", failing("no socket")) },
+			wantLive:  []string{"netlink"},
+			wantReady: []string{"netlink"},
+		},
+		{
+			name:      "failed readiness check",
+			setup:     func(c *Checker) { c.AddReadinessCheck("svid", failing("expired")) },
+			wantReady: []string{"svid"},
+		},
+		{
+			name:      "failed condition",
+			setup:     func(c *Checker) { c.Set("registration", errors.New("not registered")) },
+			wantReady: []string{"registration"},
+		},
+		{
+			name: "condition holding again",
+			setup: func(c *Checker) {
+				c.Set("registration", errors.New("not registered"))
+				c.Set("registration", nil)
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewChecker()
+			if tc.setup != nil {
+				tc.setup(c)
+			}
+			for _, check := range []struct {
+				name   string
+				failed map[string]error
+				want   []string
+			}{
+				{name: "live", failed: c.Live(context.Background()), want: tc.wantLive},
+				{name: "ready", failed: c.Ready(context.Background()), want: tc.wantReady},
+			} {
+				if len(check.failed) != len(check.want) {
+					t.Errorf("%s: got failed checks %v, want %v", check.name, check.failed, check.want)
+					continue
+				}
+				for _, name := range check.want {
+					if _, ok := check.failed[name]; !ok {
+						t.Errorf("%s: got failed checks %v, want %v", check.name, check.failed, check.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	c := NewChecker()
+	c.AddLivenessCheck("netlink", passing)
+	c.AddReadinessCheck("svid", failing("expired"))
+	c.Set("registration", errors.New("not registered"))
+	server := httptest.NewServer(c.Handler())
+	defer server.Close()
+
+	for _, tc := range []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/healthz", wantStatus: http.StatusOK, wantBody: "ok\n"},
+		{path: "/readyz", wantStatus: http.StatusServiceUnavailable, wantBody: "registration: not registered; svid: expired\n"},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			resp, err := http.Get(server.URL + tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.wantStatus || string(body) != tc.wantBody {
+				t.Errorf("got %d %q, want %d %q", resp.StatusCode, body, tc.wantStatus, tc.wantBody)
+			}
+		})
+	}
+}
+
+func TestServingStatus(t *testing.T) {
+	if got := servingStatus(nil); got != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("got %v without failed checks, want SERVING", got)
+	}
+	if got := servingStatus(map[string]error{"svid": errors.New("expired")}); got != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("got %v with a failed check, want NOT_SERVING", got)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package health
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/vishvananda/netlink"
+)
+
+// SVIDCheck fails unless source provides an x509 SVID that is valid now
+func SVIDCheck(source x509svid.Source) Check {
+	return func(ctx context.Context) error {
+		svid, err := source.GetX509SVID()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if len(svid.Certificates) == 0 {
+			return errors.New("x509 SVID without certificate")
+		}
+		now := time.Now()
+		if cert := svid.Certificates[0]; now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+			return errors.Errorf("x509 SVID %s is not valid now, valid from %s to %s", svid.ID, cert.NotBefore, cert.NotAfter)
+		}
+		return nil
+	}
+}
+
+// ListenerCheck fails unless the listener at u accepts connections
+func ListenerCheck(u *url.URL) Check {
+	return func(ctx context.Context) error {
+		network, address := "tcp", u.Host
+		if u.Scheme == "unix" {
+			network, address = "unix", u.Path
+		}
+		dialer := net.Dialer{Timeout: time.Second}
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return conn.Close()
+	}
+}
+
+// NetlinkCheck fails unless a netlink socket can be opened in the forwarder network namespace
+func NetlinkCheck() Check {
+	return func(ctx context.Context) error {
+		handle, err := netlink.NewHandle()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		handle.Delete()
+		return nil
+	}
+}
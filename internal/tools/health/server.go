@@ -0,0 +1,146 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package health
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// LivenessService is the gRPC health service reporting the liveness, the empty service reports the readiness
+	LivenessService = "liveness"
+
+	grpcUpdateInterval = 5 * time.Second
+	checkTimeout       = 3 * time.Second
+)
+
+// Handler returns the http.Handler serving /healthz and /readyz, which fail with the list of the failed checks
+func (c *Checker) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", c.handler(c.Live))
+	mux.Handle("/readyz", c.handler(c.Ready))
+	return mux
+}
+
+func (c *Checker) handler(evaluate func(ctx context.Context) map[string]error) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := summary(evaluate(ctx)); err != nil {
+			http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = rw.Write([]byte("ok\n"))
+	})
+}
+
+// ListenAndServe serves the Handler on http://listenOn until ctx is done. Errors are returned on the channel, which
+// is closed when the server stops.
+func ListenAndServe(ctx context.Context, listenOn string, c *Checker) <-chan error {
+	errCh := make(chan error, 1)
+
+	server := &http.Server{
+		Handler:           c.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ln, err := net.Listen("tcp", listenOn)
+	if err != nil {
+		errCh <- errors.Wrapf(err, "failed to listen on %s", listenOn)
+		close(errCh)
+		return errCh
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		defer close(errCh)
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			errCh <- errors.WithStack(err)
+		}
+	}()
+
+	return errCh
+}
+
+// ListenAndServeGRPC serves the gRPC health protocol on listenOn until ctx is done. The statuses of the readiness,
+// under the empty service name, and of the liveness, under LivenessService, are updated every few seconds. Errors
+// are returned on the channel, which is closed when the server stops.
+func ListenAndServeGRPC(ctx context.Context, listenOn string, c *Checker) <-chan error {
+	errCh := make(chan error, 1)
+
+	healthServer := grpchealth.NewServer()
+	server := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+
+	ln, err := net.Listen("tcp", listenOn)
+	if err != nil {
+		errCh <- errors.Wrapf(err, "failed to listen on %s", listenOn)
+		close(errCh)
+		return errCh
+	}
+
+	update := func() {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		defer cancel()
+		healthServer.SetServingStatus("", servingStatus(c.Ready(checkCtx)))
+		healthServer.SetServingStatus(LivenessService, servingStatus(c.Live(checkCtx)))
+	}
+	update()
+	go func() {
+		ticker := time.NewTicker(grpcUpdateInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				healthServer.Shutdown()
+				server.Stop()
+				return
+			case <-ticker.C:
+				update()
+			}
+		}
+	}()
+	go func() {
+		defer close(errCh)
+		if err := server.Serve(ln); err != nil && err != grpc.ErrServerStopped {
+			errCh <- errors.WithStack(err)
+		}
+	}()
+
+	return errCh
+}
+
+func servingStatus(failed map[string]error) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if len(failed) == 0 {
+		return grpc_health_v1.HealthCheckResponse_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+}
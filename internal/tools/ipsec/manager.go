@@ -0,0 +1,346 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package ipsec protects the underlay of the vxlan tunnels between two nodes with XFRM transport mode SAs and
+// policies. Each forwarder has an X25519 key pair whose public key is exchanged through the vxlan mechanism
+// parameters along with random nonces contributed by both forwarders to each connection; the SA keys and SPIs of both
+// directions are derived from the shared secret and the nonces, so that both forwarders install matching SAs without
+// further negotiation.
+package ipsec
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"net"
+	"sync"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/keyfile"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+type nodeKey struct {
+	local  string
+	remote string
+}
+
+// connSA is the inbound SA of a connection to a remote node
+type connSA struct {
+	nonces Nonces
+	in     *netlink.XfrmState
+	// owned is set once the outbound SA has been derived from the nonces of the connection, possibly by a previous
+	// forwarder instance, so that it is never derived from them again
+	owned bool
+}
+
+// node holds the SAs and policies installed towards a remote node. Each connection to the node has its own inbound
+// SA, while the outbound traffic is protected by the SA derived from the nonces of one of them, the owner. The owner
+// only changes when it is closed, for a connection which has never been the owner.
+type node struct {
+	peerKey  string
+	port     uint16
+	secret   []byte
+	conns    map[string]*connSA
+	owner    string
+	out      *netlink.XfrmState
+	policies []*netlink.XfrmPolicy
+}
+
+// Manager installs the XFRM SAs and policies protecting the vxlan traffic towards a remote node when the first
+// tunnel to that node is created, and removes them when the last one is deleted.
+type Manager struct {
+	privateKey *ecdh.PrivateKey
+
+	mu    sync.Mutex
+	nodes map[nodeKey]*node
+}
+
+// Option configures the Manager
+type Option func(o *managerOptions)
+
+type managerOptions struct {
+	keyFile string
+}
+
+// WithKeyFile keeps the private key of the forwarder in the file at path, so that the SAs in place, derived from it,
+// keep matching the ones of the remote forwarders after a restart. A key is generated if the file does not exist yet.
+func WithKeyFile(path string) Option {
+	return func(o *managerOptions) {
+		o.keyFile = path
+	}
+}
+
+// NewManager loads or generates the key pair of the forwarder
+func NewManager(options ...Option) (*Manager, error) {
+	opts := &managerOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	privateKey, err := keyfile.LoadOrGenerate(opts.keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load the ipsec key")
+	}
+	return &Manager{
+		privateKey: privateKey,
+		nodes:      make(map[nodeKey]*node),
+	}, nil
+}
+
+// PublicKey returns the public key of the forwarder
+func (m *Manager) PublicKey() []byte {
+	return m.privateKey.PublicKey().Bytes()
+}
+
+// Nonce returns the nonce the forwarder contributes to the connection connID: the current one if the SAs of the
+// connection are derived from it, a new random one otherwise.
+func (m *Manager) Nonce(connID string, current []byte) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, n := range m.nodes {
+		if c, ok := n.conns[connID]; ok && len(current) == NonceLen &&
+			(bytes.Equal(c.nonces.Src, current) || bytes.Equal(c.nonces.Dst, current)) {
+			return current, nil
+		}
+	}
+	return newNonce()
+}
+
+// Acquire makes sure the vxlan traffic of the connection connID between local and remote to the UDP port is
+// protected with SAs derived from the nonces of the connection, installing them through handle. The policies are
+// installed along with the first connection to the remote node. The peerKey is the public key of the remote
+// forwarder.
+func (m *Manager) Acquire(ctx context.Context, connID string, handle nlhandle.Handle, local, remote net.IP, port uint16, peerKey []byte, nonces Nonces) error {
+	if !nonces.valid() {
+		return errors.New("ipsec nonces not provided")
+	}
+	key := nodeKey{local: local.String(), remote: remote.String()}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.nodes[key]
+	// A different peer key means the remote forwarder has a new key, the SAs have to be replaced
+	if ok && (n.peerKey != string(peerKey) || n.port != port) {
+		m.uninstall(ctx, handle, n)
+		delete(m.nodes, key)
+		ok = false
+	}
+	if !ok {
+		secret, err := m.sharedSecret(peerKey)
+		if err != nil {
+			return err
+		}
+		n = &node{
+			peerKey:  string(peerKey),
+			port:     port,
+			secret:   secret,
+			conns:    make(map[string]*connSA),
+			policies: xfrmPolicies(local, remote, port),
+		}
+	}
+
+	if c, ok := n.conns[connID]; !ok || !c.nonces.equal(nonces) {
+		in := xfrmState(n.secret, remote, local, port, nonces)
+		if err := handle.XfrmStateAdd(in); err != nil {
+			return errors.Wrapf(err, "failed to add the xfrm state %s -> %s", in.Src, in.Dst)
+		}
+		if ok {
+			deleteState(ctx, handle, c.in)
+		}
+		n.conns[connID] = &connSA{nonces: nonces, in: in}
+		// The outbound SA follows the new nonces of its owner
+		if n.owner == connID {
+			n.owner = ""
+		}
+	}
+	_, registered := m.nodes[key]
+	if n.owner == "" {
+		if err := m.elect(ctx, handle, n, connID); err != nil {
+			if !registered {
+				m.uninstall(ctx, handle, n)
+				return err
+			}
+			log.FromContext(ctx).WithField("ipsec", "Acquire").Errorf("the vxlan traffic from %s to %s is dropped: %v", local, remote, err)
+		}
+	}
+	if !registered {
+		for _, policy := range n.policies {
+			if err := handle.XfrmPolicyUpdate(policy); err != nil {
+				m.uninstall(ctx, handle, n)
+				return errors.Wrapf(err, "failed to add the xfrm policy %s -> %s", policy.Src, policy.Dst)
+			}
+		}
+		log.FromContext(ctx).WithField("ipsec", "Acquire").Infof("protected vxlan traffic between %s and %s", local, remote)
+		m.nodes[key] = n
+	}
+
+	log.FromContext(ctx).
+		WithField("ipsec", "Acquire").
+		WithField("local", key.local).
+		WithField("remote", key.remote).
+		Debugf("refs: %d", len(n.conns))
+	return nil
+}
+
+// Restore registers the connection connID of the previous forwarder instance as protected by the SAs and policies
+// it installed between local and remote, without installing them again, so that they are removed once the
+// connection is closed. The connection is not registered if its inbound SA is not found through handle: it gets new
+// nonces when it is refreshed. The connection whose outbound SA is found is the owner. The key of the forwarder must be the one of the previous instance, see WithKeyFile.
+func (m *Manager) Restore(connID string, handle nlhandle.Handle, local, remote net.IP, port uint16, peerKey []byte, nonces Nonces) error {
+	if !nonces.valid() {
+		return errors.New("ipsec nonces not provided")
+	}
+	key := nodeKey{local: local.String(), remote: remote.String()}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.nodes[key]
+	if !ok {
+		secret, err := m.sharedSecret(peerKey)
+		if err != nil {
+			return err
+		}
+		n = &node{
+			peerKey:  string(peerKey),
+			port:     port,
+			secret:   secret,
+			conns:    make(map[string]*connSA),
+			policies: xfrmPolicies(local, remote, port),
+		}
+	}
+	in := xfrmState(n.secret, remote, local, port, nonces)
+	if _, err := handle.XfrmStateGet(in); err != nil {
+		return nil
+	}
+	c := &connSA{nonces: nonces, in: in}
+	out := xfrmState(n.secret, local, remote, port, nonces)
+	if _, err := handle.XfrmStateGet(out); err == nil {
+		c.owned = true
+		// The previous instance may have stopped while replacing the outbound SA, keep a single one
+		if n.out == nil {
+			n.out, n.owner = out, connID
+		} else {
+			_ = handle.XfrmStateDel(out)
+		}
+	}
+	n.conns[connID] = c
+	m.nodes[key] = n
+	return nil
+}
+
+// Release drops the SA of the connection connID between local and remote, if any, and removes the SAs and policies
+// towards the remote node if no other connection uses them. The outbound SA is derived again from the nonces of
+// another connection if it was derived from the ones of connID.
+func (m *Manager) Release(ctx context.Context, connID string, handle nlhandle.Handle, local, remote net.IP) {
+	if m == nil {
+		return
+	}
+	key := nodeKey{local: local.String(), remote: remote.String()}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, ok := m.nodes[key]
+	if !ok {
+		return
+	}
+	c, ok := n.conns[connID]
+	if !ok {
+		return
+	}
+	delete(n.conns, connID)
+	log.FromContext(ctx).
+		WithField("ipsec", "Release").
+		WithField("local", key.local).
+		WithField("remote", key.remote).
+		Debugf("refs: %d", len(n.conns))
+	deleteState(ctx, handle, c.in)
+	if len(n.conns) == 0 {
+		m.uninstall(ctx, handle, n)
+		delete(m.nodes, key)
+		return
+	}
+	if n.owner == connID {
+		n.owner = ""
+		if err := m.elect(ctx, handle, n, ""); err != nil {
+			log.FromContext(ctx).WithField("ipsec", "Release").Errorf("the vxlan traffic from %s to %s is dropped: %v", local, remote, err)
+		}
+	}
+}
+
+// elect derives the outbound SA of the node from the nonces of a connection which has never been the owner,
+// preferably the connection connID, and replaces the previous outbound SA with it. The previous SA is removed
+// after the new one is added, so that the traffic keeps flowing.
+func (m *Manager) elect(ctx context.Context, handle nlhandle.Handle, n *node, connID string) error {
+	candidates := make([]string, 0, len(n.conns))
+	if _, ok := n.conns[connID]; ok {
+		candidates = append(candidates, connID)
+	}
+	for id := range n.conns {
+		if id != connID {
+			candidates = append(candidates, id)
+		}
+	}
+	err := errors.New("no connection to derive the outbound ipsec SA from")
+	for _, id := range candidates {
+		c := n.conns[id]
+		if c.owned {
+			continue
+		}
+		// A failed attempt may still have installed the SA, it is not derived from the same nonces again
+		c.owned = true
+		out := xfrmState(n.secret, c.in.Dst, c.in.Src, n.port, c.nonces)
+		if err = handle.XfrmStateAdd(out); err != nil {
+			err = errors.Wrapf(err, "failed to add the xfrm state %s -> %s", out.Src, out.Dst)
+			continue
+		}
+		if n.out != nil {
+			deleteState(ctx, handle, n.out)
+		}
+		n.out, n.owner = out, id
+		return nil
+	}
+	return err
+}
+
+func (m *Manager) uninstall(ctx context.Context, handle nlhandle.Handle, n *node) {
+	logger := log.FromContext(ctx).WithField("ipsec", "uninstall")
+	for _, policy := range n.policies {
+		if err := handle.XfrmPolicyDel(policy); err != nil {
+			logger.Debugf("failed to delete the xfrm policy %s -> %s: %v", policy.Src, policy.Dst, err)
+		}
+	}
+	if n.out != nil {
+		deleteState(ctx, handle, n.out)
+	}
+	for _, c := range n.conns {
+		deleteState(ctx, handle, c.in)
+	}
+}
+
+func deleteState(ctx context.Context, handle nlhandle.Handle, state *netlink.XfrmState) {
+	if err := handle.XfrmStateDel(state); err != nil {
+		log.FromContext(ctx).WithField("ipsec", "deleteState").Debugf("failed to delete the xfrm state %s -> %s: %v", state.Src, state.Dst, err)
+	}
+}
@@ -0,0 +1,273 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package ipsec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+// recordingHandle records the SAs added through it
+type recordingHandle struct {
+	nlhandle.Handle
+	added map[string]int
+}
+
+func (h *recordingHandle) XfrmStateAdd(state *netlink.XfrmState) error {
+	h.added[fmt.Sprintf("%s/%d/%x", state.Dst, state.Spi, state.Aead.Key)]++
+	return h.Handle.XfrmStateAdd(state)
+}
+
+func newHandle(t *testing.T) (*nlhandle.Fake, *recordingHandle) {
+	t.Helper()
+	fake := nlhandle.NewFake()
+	handle, err := fake.Host()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, &recordingHandle{Handle: handle, added: make(map[string]int)}
+}
+
+func newNonces(t *testing.T) Nonces {
+	t.Helper()
+	src, err := newNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := newNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Nonces{Src: src, Dst: dst}
+}
+
+func findState(states []netlink.XfrmState, src, dst net.IP) []netlink.XfrmState {
+	var rv []netlink.XfrmState
+	for i := range states {
+		if states[i].Src.Equal(src) && states[i].Dst.Equal(dst) {
+			rv = append(rv, states[i])
+		}
+	}
+	return rv
+}
+
+func TestManagerMatchingSAs(t *testing.T) {
+	local, remote := net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")
+	m, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake, handle := newHandle(t)
+	peerFake, peerHandle := newHandle(t)
+	ctx := context.Background()
+
+	nonces := newNonces(t)
+	if err = m.Acquire(ctx, "conn-1", handle, local, remote, 4789, peer.PublicKey(), nonces); err != nil {
+		t.Fatal(err)
+	}
+	if err = peer.Acquire(ctx, "conn-1", peerHandle, remote, local, 4789, m.PublicKey(), nonces); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []struct {
+		src, dst net.IP
+	}{
+		{src: local, dst: remote},
+		{src: remote, dst: local},
+	} {
+		got, want := findState(fake.XfrmStates(""), dir.src, dir.dst), findState(peerFake.XfrmStates(""), dir.src, dir.dst)
+		if len(got) != 1 || len(want) != 1 {
+			t.Fatalf("%s -> %s: got %d and %d xfrm states, want 1", dir.src, dir.dst, len(got), len(want))
+		}
+		if got[0].Spi != want[0].Spi || !bytes.Equal(got[0].Aead.Key, want[0].Aead.Key) {
+			t.Errorf("%s -> %s: the SAs of both forwarders do not match", dir.src, dir.dst)
+		}
+		if !got[0].ESN {
+			t.Errorf("%s -> %s: ESN is not enabled", dir.src, dir.dst)
+		}
+	}
+	if got := len(fake.XfrmPolicies("")); got != 2 {
+		t.Errorf("got %d xfrm policies, want 2", got)
+	}
+}
+
+func TestManagerNonce(t *testing.T) {
+	local, remote := net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")
+	m, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, handle := newHandle(t)
+	ctx := context.Background()
+
+	nonce, err := m.Nonce("conn-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nonce) != NonceLen {
+		t.Fatalf("got a nonce of %d bytes, want %d", len(nonce), NonceLen)
+	}
+	// The nonce is only kept while the SAs derived from it are in place
+	if again, _ := m.Nonce("conn-1", nonce); bytes.Equal(again, nonce) {
+		t.Error("the nonce of a connection without SAs is kept")
+	}
+	nonces := newNonces(t)
+	nonces.Src = nonce
+	if err = m.Acquire(ctx, "conn-1", handle, local, remote, 4789, peer.PublicKey(), nonces); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := m.Nonce("conn-1", nonce); !bytes.Equal(again, nonce) {
+		t.Error("the nonce of a protected connection is not kept")
+	}
+	m.Release(ctx, "conn-1", handle, local, remote)
+	if again, _ := m.Nonce("conn-1", nonce); bytes.Equal(again, nonce) {
+		t.Error("the nonce of a released connection is kept")
+	}
+}
+
+func TestManagerNeverReinstalls(t *testing.T) {
+	local, remote := net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")
+	m, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake, handle := newHandle(t)
+	ctx := context.Background()
+
+	acquire := func(connID string, nonces Nonces) {
+		t.Helper()
+		if err := m.Acquire(ctx, connID, handle, local, remote, 4789, peer.PublicKey(), nonces); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn1, conn2 := newNonces(t), newNonces(t)
+	acquire("conn-1", conn1)
+	acquire("conn-2", conn2)
+	// A refresh with the same nonces keeps the SAs in place
+	acquire("conn-1", conn1)
+	acquire("conn-2", conn2)
+	if got := len(fake.XfrmStates("")); got != 3 {
+		t.Fatalf("got %d xfrm states, want 2 inbound and 1 outbound", got)
+	}
+
+	// The outbound SA is derived again from the nonces of conn-2 when conn-1, its owner, is closed
+	m.Release(ctx, "conn-1", handle, local, remote)
+	if got := findState(fake.XfrmStates(""), local, remote); len(got) != 1 {
+		t.Fatalf("got %d outbound xfrm states, want 1", len(got))
+	}
+	// conn-1 comes back with new nonces, conn-2 is refreshed with new ones
+	acquire("conn-1", newNonces(t))
+	acquire("conn-2", newNonces(t))
+	m.Release(ctx, "conn-2", handle, local, remote)
+	if got := findState(fake.XfrmStates(""), local, remote); len(got) != 1 {
+		t.Fatalf("got %d outbound xfrm states, want 1", len(got))
+	}
+	if got := findState(fake.XfrmStates(""), remote, local); len(got) != 1 {
+		t.Fatalf("got %d inbound xfrm states, want 1", len(got))
+	}
+
+	for state, n := range handle.added {
+		if n > 1 {
+			t.Errorf("the xfrm state %s was installed %d times", state, n)
+		}
+	}
+}
+
+func TestManagerRelease(t *testing.T) {
+	local, remote := net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")
+	peer, err := NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "ipsec.key")
+	m, err := NewManager(WithKeyFile(keyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake, handle := newHandle(t)
+	ctx := context.Background()
+
+	nonces := map[string]Nonces{"conn-1": newNonces(t), "conn-2": newNonces(t)}
+	for _, connID := range []string{"conn-1", "conn-2"} {
+		if err = m.Acquire(ctx, connID, handle, local, remote, 4789, peer.PublicKey(), nonces[connID]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The forwarder restarts: the SAs stay in place and the connections are restored from their records
+	m, err = NewManager(WithKeyFile(keyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, connID := range []string{"conn-1", "conn-2"} {
+		if err = m.Restore(connID, handle, local, remote, 4789, peer.PublicKey(), nonces[connID]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A connection whose SA is not in place is not restored
+	if err = m.Restore("conn-3", handle, local, remote, 4789, peer.PublicKey(), newNonces(t)); err != nil {
+		t.Fatal(err)
+	}
+	if nonce, _ := m.Nonce("conn-1", nonces["conn-1"].Src); !bytes.Equal(nonce, nonces["conn-1"].Src) {
+		t.Error("the nonce of a restored connection is not kept")
+	}
+
+	m.Release(ctx, "conn-1", handle, local, remote)
+	// A second Release of the same connection must not drop the SAs of the other one
+	m.Release(ctx, "conn-1", handle, local, remote)
+	m.Release(ctx, "conn-3", handle, local, remote)
+	if got := findState(fake.XfrmStates(""), local, remote); len(got) != 1 {
+		t.Fatalf("got %d outbound xfrm states after the first release, want 1", len(got))
+	}
+	if got := findState(fake.XfrmStates(""), remote, local); len(got) != 1 {
+		t.Fatalf("got %d inbound xfrm states after the first release, want 1", len(got))
+	}
+	m.Release(ctx, "conn-2", handle, local, remote)
+	if got := len(fake.XfrmStates("")); got != 0 {
+		t.Errorf("got %d xfrm states after the last release, want 0", got)
+	}
+	if got := len(fake.XfrmPolicies("")); got != 0 {
+		t.Errorf("got %d xfrm policies after the last release, want 0", got)
+	}
+	for state, n := range handle.added {
+		if n > 1 {
+			t.Errorf("the xfrm state %s was installed %d times", state, n)
+		}
+	}
+}
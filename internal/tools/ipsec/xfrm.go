@@ -0,0 +1,173 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package ipsec
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"net"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// aeadAlgo is AES-256-GCM, its key is made of the 32 bytes AES key and a 4 bytes salt
+	aeadAlgo   = "rfc4106(gcm(aes))"
+	aeadKeyLen = 36
+	aeadICVLen = 128
+
+	replayWindow = 32
+	kdfLabel     = "kubeslice vxlan ipsec"
+
+	// reqid ties the outbound policies to the SAs of the manager. The template of the inbound policies matches any
+	// reqid, so that the traffic of each connection is accepted with its own SA.
+	reqid = 0x6b73
+
+	// NonceLen is the length of the nonces of the connections
+	NonceLen = 16
+)
+
+// Nonces are the random values contributed by the forwarders at both ends of a connection, the source and the
+// destination of its mechanism, to the derivation of the SAs protecting its tunnel. A forwarder keeps its nonce only
+// as long as the SAs derived from it are in place, so that a key and SPI are never installed twice and the AES-GCM
+// nonces are never reused.
+type Nonces struct {
+	Src []byte
+	Dst []byte
+}
+
+func (n Nonces) valid() bool {
+	return len(n.Src) == NonceLen && len(n.Dst) == NonceLen
+}
+
+func (n Nonces) equal(o Nonces) bool {
+	return bytes.Equal(n.Src, o.Src) && bytes.Equal(n.Dst, o.Dst)
+}
+
+func newNonce() ([]byte, error) {
+	nonce := make([]byte, NonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to generate an ipsec nonce")
+	}
+	return nonce, nil
+}
+
+// sharedSecret returns the secret shared with the remote forwarder of public key peerKey
+func (m *Manager) sharedSecret(peerKey []byte) ([]byte, error) {
+	peer, err := ecdh.X25519().NewPublicKey(peerKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid ipsec peer key")
+	}
+	secret, err := m.privateKey.ECDH(peer)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compute the ipsec shared secret")
+	}
+	return secret, nil
+}
+
+// xfrmState returns the SA for the vxlan traffic of a connection from src to dst. Its key and SPI are derived from
+// the shared secret, the direction, the UDP port and the nonces of the connection. The sequence numbers are 64 bits
+// long, so that they never wrap during the life of the SA.
+func xfrmState(secret []byte, src, dst net.IP, port uint16, nonces Nonces) *netlink.XfrmState {
+	key, spi := deriveSA(secret, src, dst, port, nonces)
+	return &netlink.XfrmState{
+		Src:          src,
+		Dst:          dst,
+		Proto:        netlink.XFRM_PROTO_ESP,
+		Mode:         netlink.XFRM_MODE_TRANSPORT,
+		Spi:          spi,
+		Reqid:        reqid,
+		ReplayWindow: replayWindow,
+		ESN:          true,
+		Aead: &netlink.XfrmStateAlgo{
+			Name:   aeadAlgo,
+			Key:    key,
+			ICVLen: aeadICVLen,
+		},
+	}
+}
+
+// xfrmPolicies returns the policies requiring the vxlan traffic between local and remote to the UDP port to be
+// protected
+func xfrmPolicies(local, remote net.IP, port uint16) []*netlink.XfrmPolicy {
+	var policies []*netlink.XfrmPolicy
+	for _, dir := range []struct {
+		src, dst net.IP
+		dir      netlink.Dir
+		reqid    int
+	}{
+		{src: local, dst: remote, dir: netlink.XFRM_DIR_OUT, reqid: reqid},
+		{src: remote, dst: local, dir: netlink.XFRM_DIR_IN},
+	} {
+		policies = append(policies, &netlink.XfrmPolicy{
+			Src:     hostNet(dir.src),
+			Dst:     hostNet(dir.dst),
+			Proto:   unix.IPPROTO_UDP,
+			DstPort: int(port),
+			Dir:     dir.dir,
+			Tmpls: []netlink.XfrmPolicyTmpl{{
+				Src:   dir.src,
+				Dst:   dir.dst,
+				Proto: netlink.XFRM_PROTO_ESP,
+				Mode:  netlink.XFRM_MODE_TRANSPORT,
+				Reqid: dir.reqid,
+			}},
+		})
+	}
+	return policies
+}
+
+// deriveSA derives the key and SPI of the SA for the traffic from src to dst with HKDF-SHA256
+func deriveSA(secret []byte, src, dst net.IP, port uint16, nonces Nonces) (key []byte, spi int) {
+	extract := hmac.New(sha256.New, []byte(kdfLabel))
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	info := append(append(append([]byte{}, src.To16()...), dst.To16()...), byte(port>>8), byte(port))
+	info = append(append(info, nonces.Src...), nonces.Dst...)
+	var okm, t []byte
+	for i := byte(1); len(okm) < aeadKeyLen+4; i++ {
+		expand := hmac.New(sha256.New, prk)
+		expand.Write(t)
+		expand.Write(info)
+		expand.Write([]byte{i})
+		t = expand.Sum(nil)
+		okm = append(okm, t...)
+	}
+
+	// SPIs below 256 are reserved
+	spi = int(binary.BigEndian.Uint32(okm[aeadKeyLen:aeadKeyLen+4]) & 0x7fffffff)
+	if spi < 256 {
+		spi += 256
+	}
+	return okm[:aeadKeyLen], spi
+}
+
+func hostNet(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
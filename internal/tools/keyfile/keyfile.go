@@ -0,0 +1,70 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package keyfile keeps the X25519 private keys of the forwarder in files, so that they survive its restarts and the
+// peers of the tunnels in place keep working with them.
+package keyfile
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LoadOrGenerate returns the X25519 private key stored base64 encoded in the file at path, like the wg tool does. The
+// key is generated, and stored if path is set, if there is none.
+func LoadOrGenerate(path string) (*ecdh.PrivateKey, error) {
+	if path != "" {
+		data, err := os.ReadFile(filepath.Clean(path))
+		switch {
+		case err == nil:
+			key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid key in %s", path)
+			}
+			privateKey, err := ecdh.X25519().NewPrivateKey(key)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid key in %s", path)
+			}
+			return privateKey, nil
+		case !os.IsNotExist(err):
+			return nil, errors.Wrapf(err, "failed to read the key from %s", path)
+		}
+	}
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate the key")
+	}
+	if path == "" {
+		return privateKey, nil
+	}
+	// Replace the file atomically, so that a crash never leaves a partially written key behind
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, []byte(base64.StdEncoding.EncodeToString(privateKey.Bytes())+"\n"), 0o600); err != nil {
+		return nil, errors.Wrapf(err, "failed to store the key to %s", path)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return nil, errors.Wrapf(err, "failed to store the key to %s", path)
+	}
+	return privateKey, nil
+}
@@ -0,0 +1,60 @@
+package link
+
+import (
+	"context"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/connstate"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+)
+
+// ifAliasMax is the length of the longest alias the kernel accepts, IFALIASZ less the terminating NUL
+const ifAliasMax = 255
+
+// Alias returns the alias of the links created for a connection: the name of the network service client pod requesting
+// them, for the operators, truncated to the longest alias the kernel accepts. It is empty if the pod name is not
+// known.
+func Alias(podName string) string {
+	if len(podName) > ifAliasMax {
+		return podName[:ifAliasMax]
+	}
+	return podName
+}
+
+type recordedKey struct{}
+
+// WithRecorded returns ctx carrying the links persisted for its connection by the forwarder, see Owned
+func WithRecorded(ctx context.Context, links []connstate.Link) context.Context {
+	return context.WithValue(ctx, recordedKey{}, links)
+}
+
+// Owned reports whether l, in the network namespace netNSURL, was created by the forwarder for the connection of ctx:
+// it is in the group of the forwarder links and it is one of the links recorded for the connection, see WithRecorded.
+// The links of the forwarder releases which did not persist the state of the connections are never owned, they are
+// created again instead of being adopted on the first upgrade.
+func Owned(ctx context.Context, l netlink.Link, netNSURL string) bool {
+	if l.Attrs().Group != Group {
+		return false
+	}
+	links, _ := ctx.Value(recordedKey{}).([]connstate.Link)
+	for _, recorded := range links {
+		if recorded.NetNSURL == netNSURL && recorded.Name == l.Attrs().Name {
+			return true
+		}
+	}
+	return false
+}
+
+// Adopt stores the existing link l in per Connection.Id metadata, as if it had been created by the Request. The
+// links of a connection are adopted after a forwarder restart, so that the connection keeps working across the
+// restart instead of its links being deleted and created again.
+func Adopt(ctx context.Context, isClient bool, l netlink.Link) {
+	Store(ctx, isClient, l)
+	metrics.AdoptedLinks.Inc(l.Type())
+	log.FromContext(ctx).
+		WithField("link.Name", l.Attrs().Name).
+		WithField("link", "Adopt").Info("completed")
+}
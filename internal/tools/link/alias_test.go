@@ -0,0 +1,44 @@
+package link
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/connstate"
+)
+
+func TestAlias(t *testing.T) {
+	if got := Alias("nsc-1"); got != "nsc-1" {
+		t.Errorf("Alias() = %q, want the pod name", got)
+	}
+	if got := Alias(strings.Repeat("a", 300)); len(got) != ifAliasMax {
+		t.Errorf("len(Alias()) = %d, want %d", len(got), ifAliasMax)
+	}
+}
+
+func TestOwned(t *testing.T) {
+	const netNSURL = "file:///proc/1/fd/1"
+	ctx := WithRecorded(context.Background(), []connstate.Link{{NetNSURL: netNSURL, Name: "nsm-1"}})
+	for _, tc := range []struct {
+		name     string
+		ctx      context.Context
+		attrs    netlink.LinkAttrs
+		netNSURL string
+		want     bool
+	}{
+		{name: "recorded", ctx: ctx, attrs: netlink.LinkAttrs{Name: "nsm-1", Group: Group}, netNSURL: netNSURL, want: true},
+		{name: "other group", ctx: ctx, attrs: netlink.LinkAttrs{Name: "nsm-1"}, netNSURL: netNSURL},
+		{name: "other name", ctx: ctx, attrs: netlink.LinkAttrs{Name: "nsm-2", Group: Group}, netNSURL: netNSURL},
+		{name: "other namespace", ctx: ctx, attrs: netlink.LinkAttrs{Name: "nsm-1", Group: Group}, netNSURL: "file:///proc/2/fd/1"},
+		{name: "not recorded", ctx: context.Background(), attrs: netlink.LinkAttrs{Name: "nsm-1", Group: Group}, netNSURL: netNSURL},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Owned(tc.ctx, &netlink.Dummy{LinkAttrs: tc.attrs}, tc.netNSURL); got != tc.want {
+				t.Errorf("Owned() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+package link
+
+// Group is the netlink link group assigned to every interface created by the forwarder. It allows the
+// forwarder to find the interfaces it created after a restart, when the per Connection.Id metadata is lost.
+const Group = 0x6b73
@@ -6,18 +6,28 @@ import (
 	"github.com/vishvananda/netlink"
 
 	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
 )
 
 type key struct{}
 
 // Store sets the netlink.Link stored in per Connection.Id metadata.
+// The metrics.ActiveLinks gauge counts the links stored per link type.
 func Store(ctx context.Context, isClient bool, link netlink.Link) {
-	metadata.Map(ctx, isClient).Store(key{}, link)
+	rawPrev, loaded := metadata.Map(ctx, isClient).Swap(key{}, link)
+	if prev, ok := rawPrev.(netlink.Link); loaded && ok {
+		if prev.Type() == link.Type() {
+			return
+		}
+		metrics.ActiveLinks.Dec(prev.Type())
+	}
+	metrics.ActiveLinks.Inc(link.Type())
 }
 
 // Delete deletes the netlink.Link stored in per Connection.Id metadata
 func Delete(ctx context.Context, isClient bool) {
-	metadata.Map(ctx, isClient).Delete(key{})
+	LoadAndDelete(ctx, isClient)
 }
 
 // Load returns the netlink.Link stored in per Connection.Id metadata, or nil if no
@@ -38,6 +48,7 @@ func Load(ctx context.Context, isClient bool) (value netlink.Link, ok bool) {
 func LoadOrStore(ctx context.Context, isClient bool, link netlink.Link) (value netlink.Link, ok bool) {
 	rawValue, ok := metadata.Map(ctx, isClient).LoadOrStore(key{}, link)
 	if !ok {
+		metrics.ActiveLinks.Inc(link.Type())
 		return
 	}
 	value, ok = rawValue.(netlink.Link)
@@ -52,5 +63,8 @@ func LoadAndDelete(ctx context.Context, isClient bool) (value netlink.Link, ok b
 		return
 	}
 	value, ok = rawValue.(netlink.Link)
+	if ok {
+		metrics.ActiveLinks.Dec(value.Type())
+	}
 	return value, ok
 }
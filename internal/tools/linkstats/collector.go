@@ -0,0 +1,251 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package linkstats periodically reads the kernel statistics of the links created for every connection and
+// exports them as metrics and in the path segment metrics of the connection.
+package linkstats
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/common/monitor"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const defaultInterval = 30 * time.Second
+
+// Interface identifies a link created for a connection
+type Interface struct {
+	NetNSURL string
+	Name     string
+}
+
+type trackedConn struct {
+	conn          *networkservice.Connection
+	ifaces        []Interface
+	eventConsumer monitor.EventConsumer
+}
+
+// Collector keeps track of the links of the connections handled by the forwarder and collects their statistics
+type Collector struct {
+	nl       nlhandle.Provider
+	interval time.Duration
+
+	mu    sync.Mutex
+	conns map[string]*trackedConn
+}
+
+// NewCollector returns a Collector reading the statistics of the tracked links every interval until ctx is done
+func NewCollector(ctx context.Context, options ...Option) *Collector {
+	c := &Collector{
+		nl:       nlhandle.NewProvider(),
+		interval: defaultInterval,
+		conns:    make(map[string]*trackedConn),
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.collectAll(ctx)
+			}
+		}
+	}()
+
+	return c
+}
+
+// Track starts collecting the statistics of ifaces for conn. The statistics are read right away and stored in
+// the metrics of the current path segment of conn. Subsequent readings are sent to the monitor event consumer
+// found in ctx as connection updates.
+func (c *Collector) Track(ctx context.Context, conn *networkservice.Connection, ifaces ...Interface) {
+	if c == nil {
+		return
+	}
+	eventConsumer, _ := monitor.LoadEventConsumer(ctx, false)
+
+	c.mu.Lock()
+	if prev, ok := c.conns[conn.GetId()]; ok {
+		deleteMetrics(prev)
+		delete(c.conns, conn.GetId())
+	}
+	c.mu.Unlock()
+
+	tc := &trackedConn{
+		conn:          conn,
+		ifaces:        ifaces,
+		eventConsumer: eventConsumer,
+	}
+	c.collect(ctx, tc)
+	// The returned conn carries the statistics, keep a copy to update for the monitor events
+	tc.conn = conn.Clone()
+
+	c.mu.Lock()
+	c.conns[conn.GetId()] = tc
+	c.mu.Unlock()
+}
+
+// Untrack stops collecting the statistics of the links of the connection and removes its metrics
+func (c *Collector) Untrack(connID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if tc, ok := c.conns[connID]; ok {
+		deleteMetrics(tc)
+		delete(c.conns, connID)
+	}
+}
+
+func (c *Collector) collectAll(ctx context.Context) {
+	c.mu.Lock()
+	conns := make([]*trackedConn, 0, len(c.conns))
+	for _, tc := range c.conns {
+		conns = append(conns, tc)
+	}
+	c.mu.Unlock()
+
+	for _, tc := range conns {
+		c.mu.Lock()
+		tracked := c.conns[tc.conn.GetId()] == tc
+		c.mu.Unlock()
+		if !tracked {
+			continue
+		}
+		if !c.collect(ctx, tc) || tc.eventConsumer == nil {
+			continue
+		}
+		c.mu.Lock()
+		event := &networkservice.ConnectionEvent{
+			Type:        networkservice.ConnectionEventType_UPDATE,
+			Connections: map[string]*networkservice.Connection{tc.conn.GetId(): tc.conn.Clone()},
+		}
+		c.mu.Unlock()
+		if err := tc.eventConsumer.Send(event); err != nil {
+			log.FromContext(ctx).WithField("linkstats", "Send").Debugf("failed to send connection update: %v", err)
+		}
+	}
+}
+
+// collect reads the statistics of the links of tc and updates the metrics. It returns true if any statistics were read.
+func (c *Collector) collect(ctx context.Context, tc *trackedConn) bool {
+	collected := false
+	for _, iface := range tc.ifaces {
+		stats, err := c.readStatistics(iface)
+		if err != nil {
+			log.FromContext(ctx).
+				WithField("link.Name", iface.Name).
+				WithField("err", err).
+				WithField("linkstats", "collect").Debug("error")
+			continue
+		}
+		collected = true
+
+		labels := labelValues(tc.conn, iface)
+		metrics.ConnectionBytes.Set(float64(stats.RxBytes), append(labels, "rx")...)
+		metrics.ConnectionBytes.Set(float64(stats.TxBytes), append(labels, "tx")...)
+		metrics.ConnectionPackets.Set(float64(stats.RxPackets), append(labels, "rx")...)
+		metrics.ConnectionPackets.Set(float64(stats.TxPackets), append(labels, "tx")...)
+		metrics.ConnectionDrops.Set(float64(stats.RxDropped), append(labels, "rx")...)
+		metrics.ConnectionDrops.Set(float64(stats.TxDropped), append(labels, "tx")...)
+		metrics.ConnectionErrors.Set(float64(stats.RxErrors), append(labels, "rx")...)
+		metrics.ConnectionErrors.Set(float64(stats.TxErrors), append(labels, "tx")...)
+
+		c.mu.Lock()
+		setPathMetrics(tc.conn, iface.Name, stats)
+		c.mu.Unlock()
+	}
+	return collected
+}
+
+func (c *Collector) readStatistics(iface Interface) (*netlink.LinkStatistics, error) {
+	handle, err := c.nl.FromURL(iface.NetNSURL)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Close()
+
+	l, err := handle.LinkByName(iface.Name)
+	if err != nil {
+		return nil, err
+	}
+	if l.Attrs().Statistics == nil {
+		return nil, errors.Errorf("no statistics reported for %s", iface.Name)
+	}
+	return l.Attrs().Statistics, nil
+}
+
+// setPathMetrics stores the statistics in the metrics of the path segment of this forwarder, prefixed by
+// the interface name as a local connection has two interfaces
+func setPathMetrics(conn *networkservice.Connection, ifaceName string, stats *netlink.LinkStatistics) {
+	segments := conn.GetPath().GetPathSegments()
+	index := int(conn.GetPath().GetIndex())
+	if index >= len(segments) {
+		return
+	}
+	segment := segments[index]
+	if segment.Metrics == nil {
+		segment.Metrics = make(map[string]string)
+	}
+	for key, value := range map[string]uint64{
+		"rx_bytes":   stats.RxBytes,
+		"tx_bytes":   stats.TxBytes,
+		"rx_packets": stats.RxPackets,
+		"tx_packets": stats.TxPackets,
+		"rx_drops":   stats.RxDropped,
+		"tx_drops":   stats.TxDropped,
+		"rx_errors":  stats.RxErrors,
+		"tx_errors":  stats.TxErrors,
+	} {
+		segment.Metrics[ifaceName+"_"+key] = strconv.FormatUint(value, 10)
+	}
+}
+
+func labelValues(conn *networkservice.Connection, iface Interface) []string {
+	return []string{conn.GetId(), conn.GetNetworkService(), conn.GetLabels()["podName"], iface.Name}
+}
+
+func deleteMetrics(tc *trackedConn) {
+	for _, iface := range tc.ifaces {
+		for _, direction := range []string{"rx", "tx"} {
+			labels := append(labelValues(tc.conn, iface), direction)
+			metrics.ConnectionBytes.Delete(labels...)
+			metrics.ConnectionPackets.Delete(labels...)
+			metrics.ConnectionDrops.Delete(labels...)
+			metrics.ConnectionErrors.Delete(labels...)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package linkstats
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/networkservice/utils/metadata"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	connID   = "linkstats-conn"
+	netNSURL = "inode://4/1001"
+)
+
+type ctxServer struct {
+	ctx context.Context
+}
+
+func (s *ctxServer) Request(ctx context.Context, request *networkservice.NetworkServiceRequest) (*networkservice.Connection, error) {
+	s.ctx = ctx
+	return request.GetConnection(), nil
+}
+
+func (s *ctxServer) Close(ctx context.Context, conn *networkservice.Connection) (*empty.Empty, error) {
+	return &empty.Empty{}, nil
+}
+
+// metadataContext returns a context carrying the per connection metadata of connID, as the elements following the
+// metadata server in a chain get it
+func metadataContext(t *testing.T) context.Context {
+	t.Helper()
+	s := &ctxServer{}
+	request := &networkservice.NetworkServiceRequest{Connection: &networkservice.Connection{Id: connID}}
+	if _, err := chain.NewNetworkServiceServer(metadata.NewServer(), s).Request(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+	return s.ctx
+}
+
+// recordingConsumer records the connection events sent by the Collector, it stands for the monitor server
+type recordingConsumer struct {
+	mu     sync.Mutex
+	events []*networkservice.ConnectionEvent
+}
+
+func (r *recordingConsumer) Send(event *networkservice.ConnectionEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func newFake(t *testing.T) *nlhandle.Fake {
+	t.Helper()
+	fake := nlhandle.NewFake()
+	fake.AddNamespace(netNSURL)
+	handle, err := fake.FromURL(netNSURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	if err = handle.LinkAdd(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "nsm-1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = fake.SetStatistics(netNSURL, "nsm-1", &netlink.LinkStatistics{RxBytes: 100, TxBytes: 200, RxPackets: 1, TxPackets: 2}); err != nil {
+		t.Fatal(err)
+	}
+	return fake
+}
+
+func newConn() *networkservice.Connection {
+	return &networkservice.Connection{
+		Id: connID,
+		Path: &networkservice.Path{
+			Index:        1,
+			PathSegments: []*networkservice.PathSegment{{Name: "nsc"}, {Name: "forwarder"}},
+		},
+	}
+}
+
+// series returns the lines of the exported metrics of the connection
+func series() []string {
+	var b bytes.Buffer
+	metrics.DefaultRegistry.Write(&b)
+	var rv []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.Contains(line, `"`+connID+`"`) {
+			rv = append(rv, line)
+		}
+	}
+	return rv
+}
+
+func hasSeries(name, direction, value string) bool {
+	for _, line := range series() {
+		if strings.HasPrefix(line, name+"{") && strings.HasSuffix(line, `"`+direction+`"} `+value) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTrack(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFake(t)
+	c := NewCollector(ctx, WithNetlinkProvider(fake), WithInterval(time.Hour))
+
+	conn := newConn()
+	c.Track(metadataContext(t), conn, Interface{NetNSURL: netNSURL, Name: "nsm-1"}, Interface{NetNSURL: netNSURL, Name: "missing"})
+
+	// The statistics are read right away into the metrics of the path segment of the forwarder
+	segmentMetrics := conn.GetPath().GetPathSegments()[1].GetMetrics()
+	if segmentMetrics["nsm-1_rx_bytes"] != "100" || segmentMetrics["nsm-1_tx_packets"] != "2" {
+		t.Errorf("got path segment metrics %v, want the statistics of nsm-1", segmentMetrics)
+	}
+	if _, ok := segmentMetrics["missing_rx_bytes"]; ok {
+		t.Error("got path segment metrics for a missing link")
+	}
+	if len(conn.GetPath().GetPathSegments()[0].GetMetrics()) != 0 {
+		t.Error("got metrics in the path segment of another element")
+	}
+	if !hasSeries("forwarder_connection_bytes_total", "rx", "100") || !hasSeries("forwarder_connection_bytes_total", "tx", "200") {
+		t.Errorf("got series %v, want the byte counters of nsm-1", series())
+	}
+
+	c.Untrack(connID)
+	if got := series(); len(got) != 0 {
+		t.Errorf("got series %v after Untrack, want none", got)
+	}
+}
+
+func TestCollectAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFake(t)
+	c := NewCollector(ctx, WithNetlinkProvider(fake), WithInterval(time.Hour))
+	defer c.Untrack(connID)
+
+	c.Track(metadataContext(t), newConn(), Interface{NetNSURL: netNSURL, Name: "nsm-1"})
+	consumer := &recordingConsumer{}
+	c.mu.Lock()
+	c.conns[connID].eventConsumer = consumer
+	c.mu.Unlock()
+
+	// The next readings update the metrics and are sent as connection updates
+	if err := fake.SetStatistics(netNSURL, "nsm-1", &netlink.LinkStatistics{RxBytes: 300, TxBytes: 400}); err != nil {
+		t.Fatal(err)
+	}
+	c.collectAll(ctx)
+
+	if !hasSeries("forwarder_connection_bytes_total", "rx", "300") {
+		t.Errorf("got series %v, want the new byte counters of nsm-1", series())
+	}
+	if len(consumer.events) != 1 {
+		t.Fatalf("got %d events, want 1", len(consumer.events))
+	}
+	event := consumer.events[0]
+	if event.GetType() != networkservice.ConnectionEventType_UPDATE {
+		t.Errorf("got event type %v, want UPDATE", event.GetType())
+	}
+	if got := event.GetConnections()[connID].GetPath().GetPathSegments()[1].GetMetrics()["nsm-1_rx_bytes"]; got != "300" {
+		t.Errorf("got rx bytes %q in the event, want 300", got)
+	}
+
+	// Nothing is sent once the link is gone, nor for the untracked connections
+	fake.DeleteNamespace(netNSURL)
+	c.collectAll(ctx)
+	c.Untrack(connID)
+	c.collectAll(ctx)
+	if len(consumer.events) != 1 {
+		t.Errorf("got %d events, want no more", len(consumer.events))
+	}
+}
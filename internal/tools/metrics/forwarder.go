@@ -0,0 +1,85 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package metrics
+
+// Label values of the forwarder metrics
+const (
+	ConnLocal   = "LOCAL"
+	ConnRemote  = "REMOTE"
+	ConnUnknown = "UNKNOWN"
+
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// connectionLabels are the labels of the per connection link statistics, the direction is either "rx" or "tx"
+var connectionLabels = []string{"connection_id", "network_service", "pod_name", "interface", "direction"}
+
+var (
+	// XconnectRequests counts the xconnect Requests by connection type and result
+	XconnectRequests = NewCounterVec("forwarder_xconnect_requests_total",
+		"Number of cross-connect Requests handled by the forwarder.", "type", "result")
+	// XconnectRequestDuration observes the duration of the xconnect Requests
+	XconnectRequestDuration = NewHistogramVec("forwarder_xconnect_request_duration_seconds",
+		"Duration of the cross-connect Requests handled by the forwarder.", DefaultBuckets, "type", "result")
+	// XconnectCloses counts the xconnect Closes by connection type and result
+	XconnectCloses = NewCounterVec("forwarder_xconnect_closes_total",
+		"Number of cross-connect Closes handled by the forwarder.", "type", "result")
+	// NetlinkOperationDuration observes the duration of every netlink operation performed by the forwarder
+	NetlinkOperationDuration = NewHistogramVec("forwarder_netlink_operation_duration_seconds",
+		"Duration of the netlink operations performed by the forwarder.", DefaultBuckets, "operation", "result")
+	// ActiveLinks is the number of links created by the forwarder and not deleted yet, by link kind
+	ActiveLinks = NewGaugeVec("forwarder_active_links",
+		"Number of links created by the forwarder that are currently in use.", "kind")
+	// StaleLinkDeletions counts the links of unknown origin deleted before creating a link with the same name
+	StaleLinkDeletions = NewCounterVec("forwarder_stale_link_deletions_total",
+		"Number of stale links deleted by the forwarder before creating a link with the same name.", "kind")
+	// AdoptedLinks counts the links found in place of the ones to create and taken over by the forwarder, instead of
+	// being deleted and created again, typically after a forwarder restart
+	AdoptedLinks = NewCounterVec("forwarder_adopted_links_total",
+		"Number of existing links taken over by the forwarder instead of being created again.", "kind")
+	// OrphanLinkDeletions counts the links left behind by a previous forwarder instance and deleted by the reconciler
+	OrphanLinkDeletions = NewCounterVec("forwarder_orphan_link_deletions_total",
+		"Number of links left behind by a previous forwarder instance deleted after the grace period.")
+	// ConnectionExpirations counts the connections persisted by a previous forwarder instance torn down at their
+	// expiration
+	ConnectionExpirations = NewCounterVec("forwarder_restored_connection_expirations_total",
+		"Number of connections persisted by a previous forwarder instance torn down because they were not refreshed.")
+
+	// ConnectionBytes mirrors the kernel byte counters of the links of every connection
+	ConnectionBytes = NewCounterVec("forwarder_connection_bytes_total",
+		"Number of bytes received or transmitted on the links of a connection.", connectionLabels...)
+	// ConnectionPackets mirrors the kernel packet counters of the links of every connection
+	ConnectionPackets = NewCounterVec("forwarder_connection_packets_total",
+		"Number of packets received or transmitted on the links of a connection.", connectionLabels...)
+	// ConnectionDrops mirrors the kernel drop counters of the links of every connection
+	ConnectionDrops = NewCounterVec("forwarder_connection_dropped_packets_total",
+		"Number of packets dropped on receive or transmit on the links of a connection.", connectionLabels...)
+	// ConnectionErrors mirrors the kernel error counters of the links of every connection
+	ConnectionErrors = NewCounterVec("forwarder_connection_errors_total",
+		"Number of receive or transmit errors on the links of a connection.", connectionLabels...)
+)
+
+// Result returns the result label value for err
+func Result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultSuccess
+}
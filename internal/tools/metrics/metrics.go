@@ -0,0 +1,305 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package metrics implements the counters, gauges and histograms exported by the forwarder in the
+// Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the histogram buckets, in seconds, used for the latency metrics
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type collector interface {
+	write(w io.Writer)
+}
+
+// Registry is a set of metrics exported together
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// DefaultRegistry is the registry the metric constructors of this package register to
+var DefaultRegistry = &Registry{}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Write writes all the metrics of the registry in the Prometheus text format
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// Handler returns the http.Handler serving the metrics of the registry
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(rw)
+		r.Write(bw)
+		_ = bw.Flush()
+	})
+}
+
+// vec holds the series of a metric, one per distinct set of label values
+type vec struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// histograms only
+	buckets []uint64
+	count   uint64
+}
+
+func newVec(name, help, typ string, labels []string) *vec {
+	return &vec{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		series: make(map[string]*series),
+	}
+}
+
+// with returns the series for labelValues, creating it if needed. Must be called with v.mu held.
+func (v *vec) with(labelValues []string, nBuckets int) *series {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metric %s: expected %d label values, got %d", v.name, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if nBuckets > 0 {
+			s.buckets = make([]uint64, nBuckets)
+		}
+		v.series[key] = s
+	}
+	return s
+}
+
+// Delete removes the series for labelValues
+func (v *vec) Delete(labelValues ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.series, strings.Join(labelValues, "\xff"))
+}
+
+func (v *vec) sorted() []*series {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	rv := make([]*series, 0, len(keys))
+	for _, k := range keys {
+		s := *v.series[k]
+		s.buckets = append([]uint64(nil), s.buckets...)
+		rv = append(rv, &s)
+	}
+	return rv
+}
+
+func (v *vec) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.name, strings.NewReplacer("\\", `\\`, "\n", `\n`).Replace(v.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, v.typ)
+}
+
+// labelValueEscaper escapes the label values as the Prometheus text format expects, which is not the Go quoting
+var labelValueEscaper = strings.NewReplacer("\\", `\\`, "\"", `\"`, "\n", `\n`)
+
+func (v *vec) labelString(labelValues []string, extraName, extraValue string) string {
+	var pairs []string
+	for i, name := range v.labels {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, labelValueEscaper.Replace(labelValues[i])))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extraName, labelValueEscaper.Replace(extraValue)))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// CounterVec is a monotonically increasing metric partitioned by labels
+type CounterVec struct {
+	*vec
+}
+
+// NewCounterVec creates a CounterVec and registers it to the DefaultRegistry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec: newVec(name, help, "counter", labels)}
+	DefaultRegistry.register(c)
+	return c
+}
+
+// Inc increments the counter for labelValues by 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for labelValues by delta, which must not be negative
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.with(labelValues, 0).value += delta
+}
+
+// Set sets the counter for labelValues to value. It is meant for counters maintained outside of the
+// forwarder, like the kernel link statistics, which are mirrored periodically.
+func (c *CounterVec) Set(value float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.with(labelValues, 0).value = value
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.writeHeader(w)
+	for _, s := range c.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelString(s.labelValues, "", ""), formatFloat(s.value))
+	}
+}
+
+// GaugeVec is a metric that can go up and down, partitioned by labels
+type GaugeVec struct {
+	*vec
+}
+
+// NewGaugeVec creates a GaugeVec and registers it to the DefaultRegistry
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{vec: newVec(name, help, "gauge", labels)}
+	DefaultRegistry.register(g)
+	return g
+}
+
+// Set sets the gauge for labelValues to value
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.with(labelValues, 0).value = value
+}
+
+// Add adds delta, which may be negative, to the gauge for labelValues
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.with(labelValues, 0).value += delta
+}
+
+// Inc increments the gauge for labelValues by 1
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec decrements the gauge for labelValues by 1
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.writeHeader(w)
+	for _, s := range g.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelString(s.labelValues, "", ""), formatFloat(s.value))
+	}
+}
+
+// HistogramVec counts observations in buckets, partitioned by labels
+type HistogramVec struct {
+	*vec
+	upperBounds []float64
+}
+
+// NewHistogramVec creates a HistogramVec with the given bucket upper bounds and registers it to the DefaultRegistry
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	upperBounds := append([]float64(nil), buckets...)
+	sort.Float64s(upperBounds)
+	h := &HistogramVec{vec: newVec(name, help, "histogram", labels), upperBounds: upperBounds}
+	DefaultRegistry.register(h)
+	return h
+}
+
+// Observe adds a single observation to the histogram for labelValues
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.with(labelValues, len(h.upperBounds))
+	for i, bound := range h.upperBounds {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.value += value
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.writeHeader(w)
+	for _, s := range h.sorted() {
+		for i, bound := range h.upperBounds {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(s.labelValues, "le", formatFloat(bound)), s.buckets[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(s.labelValues, "", ""), formatFloat(s.value))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(s.labelValues, "", ""), s.count)
+	}
+}
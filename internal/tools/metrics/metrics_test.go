@@ -0,0 +1,60 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLabelString(t *testing.T) {
+	v := newVec("test_total", "help", "counter", []string{"value"})
+	for _, tc := range []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "plain", value: "veth", want: `{value="veth"}`},
+		{name: "backslash", value: `a\b`, want: `{value="a\\b"}`},
+		{name: "quote", value: `a"b`, want: `{value="a\"b"}`},
+		{name: "newline", value: "a\nb", want: `{value="a\nb"}`},
+		// Go quoting would write \t and é, which the Prometheus parsers reject
+		{name: "unescaped", value: "a\tbé", want: "{value=\"a\tbé\"}"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := v.labelString([]string{tc.value}, "", ""); got != tc.want {
+				t.Errorf("labelString(%q) = %s, want %s", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCounterVecWrite(t *testing.T) {
+	c := &CounterVec{vec: newVec("test_total", "Test counter.", "counter", []string{"kind"})}
+	c.Inc("veth")
+	c.Add(2, "veth")
+	c.Add(-1, "veth")
+
+	var sb strings.Builder
+	c.write(&sb)
+	want := "# HELP test_total Test counter.\n# TYPE test_total counter\ntest_total{kind=\"veth\"} 3\n"
+	if sb.String() != want {
+		t.Errorf("write() = %q, want %q", sb.String(), want)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package metrics
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ListenAndServe serves the metrics of the DefaultRegistry on http://listenOn/metrics until ctx is done.
+// Errors are returned on the channel, which is closed when the server stops.
+func ListenAndServe(ctx context.Context, listenOn string) <-chan error {
+	errCh := make(chan error, 1)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", DefaultRegistry.Handler())
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ln, err := net.Listen("tcp", listenOn)
+	if err != nil {
+		errCh <- errors.Wrapf(err, "failed to listen on %s", listenOn)
+		close(errCh)
+		return errCh
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		defer close(errCh)
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			errCh <- errors.WithStack(err)
+		}
+	}()
+
+	return errCh
+}
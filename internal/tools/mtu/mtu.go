@@ -0,0 +1,77 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package mtu discovers the MTU of the underlay interface and publishes the MTU of the links created by the
+// forwarder in the connection context, where the connectioncontextkernel elements pick it up.
+package mtu
+
+import (
+	"net"
+
+	"github.com/networkservicemesh/api/pkg/api/networkservice"
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+// DefaultUnderlay is the MTU assumed for the underlay when the interface owning the tunnel IP is not known
+const DefaultUnderlay = 1500
+
+// Discover returns the MTU of the interface of the forwarder network namespace the ip is assigned to
+func Discover(nl nlhandle.Provider, ip net.IP) (int, error) {
+	handle, err := nl.Host()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer handle.Close()
+
+	addrs, err := handle.AddrList(nil, netlink.FAMILY_ALL)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	for i := range addrs {
+		if !addrs[i].IP.Equal(ip) {
+			continue
+		}
+		links, err := handle.LinkList()
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		for _, l := range links {
+			if l.Attrs().Index == addrs[i].LinkIndex {
+				return l.Attrs().MTU, nil
+			}
+		}
+	}
+	return 0, errors.Errorf("no interface with the address %s", ip)
+}
+
+// Set publishes mtu in the context of the conn, unless a lower MTU is already set there, by the network service
+// endpoint or by the forwarder at the other end of the tunnel
+func Set(conn *networkservice.Connection, mtu int) {
+	if mtu <= 0 {
+		return
+	}
+	if conn.GetContext() == nil {
+		conn.Context = &networkservice.ConnectionContext{}
+	}
+	if current := conn.GetContext().GetMTU(); current == 0 || current > uint32(mtu) {
+		conn.GetContext().MTU = uint32(mtu)
+	}
+}
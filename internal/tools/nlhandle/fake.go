@@ -0,0 +1,754 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package nlhandle
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const hostNetNS = ""
+
+// Fake is an in-memory Provider that models network namespaces and the links in them. It needs no
+// privileges and is meant to be used in tests of the mechanism packages and the xconnect chain.
+type Fake struct {
+	mu         sync.Mutex
+	namespaces map[string]map[string]netlink.Link
+	peers      map[int]int
+	addrs      map[int][]netlink.Addr
+	wireguard  map[int]*WireguardDevice
+	qdiscs     map[int]map[uint32]netlink.Qdisc
+	filters    map[int]map[string]netlink.Filter
+	sysctls    map[string]map[string]string
+	features   map[int]map[string]bool
+	states     map[string]map[string]netlink.XfrmState
+	policies   map[string]map[string]netlink.XfrmPolicy
+	lastIndex  int
+}
+
+// NewFake returns a Fake with only the host network namespace present
+func NewFake() *Fake {
+	return &Fake{
+		namespaces: map[string]map[string]netlink.Link{hostNetNS: {}},
+		peers:      make(map[int]int),
+		addrs:      make(map[int][]netlink.Addr),
+		wireguard:  make(map[int]*WireguardDevice),
+		qdiscs:     make(map[int]map[uint32]netlink.Qdisc),
+		filters:    make(map[int]map[string]netlink.Filter),
+		sysctls:    make(map[string]map[string]string),
+		features:   make(map[int]map[string]bool),
+		states:     make(map[string]map[string]netlink.XfrmState),
+		policies:   make(map[string]map[string]netlink.XfrmPolicy),
+	}
+}
+
+// AddNamespace creates an empty network namespace addressed by netNSURL
+func (f *Fake) AddNamespace(netNSURL string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.namespaces[netNSURL]; !ok {
+		f.namespaces[netNSURL] = make(map[string]netlink.Link)
+	}
+}
+
+// DeleteNamespace removes the network namespace along with the links in it. Veth peers of the
+// removed links are removed as well, like the kernel does.
+func (f *Fake) DeleteNamespace(netNSURL string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, l := range f.namespaces[netNSURL] {
+		f.deleteLocked(l.Attrs().Index)
+	}
+	delete(f.namespaces, netNSURL)
+	delete(f.sysctls, netNSURL)
+}
+
+// SetStatistics sets the statistics reported for the link name in the network namespace netNSURL
+func (f *Fake) SetStatistics(netNSURL, name string, statistics *netlink.LinkStatistics) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, err := f.lookupLocked(netNSURL, &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}})
+	if err != nil {
+		return err
+	}
+	stats := *statistics
+	stored.Attrs().Statistics = &stats
+	return nil
+}
+
+// AddAddr assigns the address to the link name in the network namespace netNSURL
+func (f *Fake) AddAddr(netNSURL, name string, addr *net.IPNet) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, err := f.lookupLocked(netNSURL, &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}})
+	if err != nil {
+		return err
+	}
+	index := stored.Attrs().Index
+	f.addrs[index] = append(f.addrs[index], netlink.Addr{IPNet: addr, LinkIndex: index})
+	return nil
+}
+
+// Wireguard returns the configuration of the wireguard link name in the network namespace netNSURL
+func (f *Fake) Wireguard(netNSURL, name string) (*WireguardDevice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, err := f.lookupLocked(netNSURL, &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}})
+	if err != nil {
+		return nil, err
+	}
+	device, ok := f.wireguard[stored.Attrs().Index]
+	if !ok {
+		return nil, errors.Errorf("wireguard device not configured: %s", name)
+	}
+	return device, nil
+}
+
+// Qdiscs returns the qdiscs and the filters attached to the link name in the network namespace netNSURL
+func (f *Fake) Qdiscs(netNSURL, name string) ([]netlink.Qdisc, []netlink.Filter, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, err := f.lookupLocked(netNSURL, &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}})
+	if err != nil {
+		return nil, nil, err
+	}
+	var qdiscs []netlink.Qdisc
+	for _, qdisc := range f.qdiscs[stored.Attrs().Index] {
+		qdiscs = append(qdiscs, qdisc)
+	}
+	var filters []netlink.Filter
+	for _, filter := range f.filters[stored.Attrs().Index] {
+		filters = append(filters, filter)
+	}
+	return qdiscs, filters, nil
+}
+
+// Sysctls returns the sysctls written in the network namespace netNSURL, by path
+func (f *Fake) Sysctls(netNSURL string) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rv := make(map[string]string)
+	for path, value := range f.sysctls[netNSURL] {
+		rv[path] = value
+	}
+	return rv
+}
+
+// Features returns the ethtool features set on the link name in the network namespace netNSURL
+func (f *Fake) Features(netNSURL, name string) (map[string]bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stored, err := f.lookupLocked(netNSURL, &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}})
+	if err != nil {
+		return nil, err
+	}
+	rv := make(map[string]bool)
+	for feature, value := range f.features[stored.Attrs().Index] {
+		rv[feature] = value
+	}
+	return rv, nil
+}
+
+// XfrmStates returns the XFRM states of the network namespace netNSURL
+func (f *Fake) XfrmStates(netNSURL string) []netlink.XfrmState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var rv []netlink.XfrmState
+	for _, state := range f.states[netNSURL] {
+		rv = append(rv, state)
+	}
+	return rv
+}
+
+// XfrmPolicies returns the XFRM policies of the network namespace netNSURL
+func (f *Fake) XfrmPolicies(netNSURL string) []netlink.XfrmPolicy {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var rv []netlink.XfrmPolicy
+	for _, policy := range f.policies[netNSURL] {
+		rv = append(rv, policy)
+	}
+	return rv
+}
+
+// Host returns a handle to the host network namespace
+func (f *Fake) Host() (Handle, error) {
+	return &fakeHandle{fake: f, netNS: hostNetNS}, nil
+}
+
+// FromURL returns a handle to the network namespace previously created with AddNamespace
+func (f *Fake) FromURL(netNSURL string) (Handle, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.namespaces[netNSURL]; !ok {
+		return nil, errors.Errorf("network namespace not found: %s", netNSURL)
+	}
+	return &fakeHandle{fake: f, netNS: netNSURL}, nil
+}
+
+func (f *Fake) lookupLocked(netNS string, l netlink.Link) (netlink.Link, error) {
+	links, ok := f.namespaces[netNS]
+	if !ok {
+		return nil, errors.Errorf("network namespace not found: %s", netNS)
+	}
+	if index := l.Attrs().Index; index != 0 {
+		for _, stored := range links {
+			if stored.Attrs().Index == index {
+				return stored, nil
+			}
+		}
+		return nil, errors.Errorf("Link not found: index %d", index)
+	}
+	if stored, ok := links[l.Attrs().Name]; ok {
+		return stored, nil
+	}
+	return nil, errors.Errorf("Link not found: %s", l.Attrs().Name)
+}
+
+func (f *Fake) addLocked(netNS string, l netlink.Link) (netlink.Link, error) {
+	links := f.namespaces[netNS]
+	name := l.Attrs().Name
+	if name == "" {
+		return nil, errors.New("link name not provided")
+	}
+	if _, ok := links[name]; ok {
+		return nil, errors.Errorf("file exists: %s", name)
+	}
+	stored := cloneLink(l)
+	f.lastIndex++
+	stored.Attrs().Index = f.lastIndex
+	links[name] = stored
+	return stored, nil
+}
+
+func (f *Fake) vlanInUseLocked(vlan *netlink.Vlan) bool {
+	for _, links := range f.namespaces {
+		for _, l := range links {
+			if other, ok := l.(*netlink.Vlan); ok && other.ParentIndex == vlan.ParentIndex && other.VlanId == vlan.VlanId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *Fake) deleteLocked(index int) {
+	for _, links := range f.namespaces {
+		for name, l := range links {
+			if l.Attrs().Index == index {
+				delete(links, name)
+			}
+		}
+	}
+	delete(f.wireguard, index)
+	delete(f.addrs, index)
+	delete(f.qdiscs, index)
+	delete(f.filters, index)
+	delete(f.features, index)
+	if peer, ok := f.peers[index]; ok {
+		delete(f.peers, index)
+		delete(f.peers, peer)
+		f.deleteLocked(peer)
+	}
+}
+
+// cloneLink returns a shallow copy of the concrete link struct, so that callers can't modify the
+// state of the fake through the links they were handed.
+func cloneLink(l netlink.Link) netlink.Link {
+	v := reflect.ValueOf(l).Elem()
+	c := reflect.New(v.Type())
+	c.Elem().Set(v)
+	return c.Interface().(netlink.Link)
+}
+
+type fakeHandle struct {
+	fake  *Fake
+	netNS string
+}
+
+func (h *fakeHandle) LinkAdd(l netlink.Link) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	veth, isVeth := l.(*netlink.Veth)
+	if isVeth {
+		if _, ok := h.fake.namespaces[h.netNS][veth.PeerName]; ok || veth.PeerName == veth.Name {
+			return errors.Errorf("file exists: %s", veth.PeerName)
+		}
+	}
+	// Like the kernel, allow a single sub-interface per VLAN of a parent, whichever namespace it was moved to
+	if vlan, ok := l.(*netlink.Vlan); ok && h.fake.vlanInUseLocked(vlan) {
+		return errors.Wrapf(unix.EEXIST, "vlan %d of link %d", vlan.VlanId, vlan.ParentIndex)
+	}
+	stored, err := h.fake.addLocked(h.netNS, l)
+	if err != nil {
+		return err
+	}
+	if isVeth {
+		peer, err := h.fake.addLocked(h.netNS, &netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Name: veth.PeerName, MTU: veth.MTU},
+			PeerName:  veth.Name,
+		})
+		if err != nil {
+			return err
+		}
+		h.fake.peers[stored.Attrs().Index] = peer.Attrs().Index
+		h.fake.peers[peer.Attrs().Index] = stored.Attrs().Index
+		// Like the kernel, report the index of the peer as the parent of each end
+		stored.Attrs().ParentIndex = peer.Attrs().Index
+		peer.Attrs().ParentIndex = stored.Attrs().Index
+	}
+	l.Attrs().Index = stored.Attrs().Index
+	return nil
+}
+
+func (h *fakeHandle) LinkDel(l netlink.Link) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, l)
+	if err != nil {
+		return err
+	}
+	h.fake.deleteLocked(stored.Attrs().Index)
+	return nil
+}
+
+func (h *fakeHandle) LinkByName(name string) (netlink.Link, error) {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}})
+	if err != nil {
+		return nil, err
+	}
+	return cloneLink(stored), nil
+}
+
+func (h *fakeHandle) LinkList() ([]netlink.Link, error) {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	links, ok := h.fake.namespaces[h.netNS]
+	if !ok {
+		return nil, errors.Errorf("network namespace not found: %s", h.netNS)
+	}
+	var rv []netlink.Link
+	for _, l := range links {
+		rv = append(rv, cloneLink(l))
+	}
+	return rv, nil
+}
+
+func (h *fakeHandle) LinkSetName(l netlink.Link, name string) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, l)
+	if err != nil {
+		return err
+	}
+	links := h.fake.namespaces[h.netNS]
+	if stored.Attrs().Name == name {
+		return nil
+	}
+	if _, ok := links[name]; ok {
+		return errors.Errorf("file exists: %s", name)
+	}
+	delete(links, stored.Attrs().Name)
+	stored.Attrs().Name = name
+	links[name] = stored
+	return nil
+}
+
+func (h *fakeHandle) LinkSetAlias(l netlink.Link, alias string) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, l)
+	if err != nil {
+		return err
+	}
+	stored.Attrs().Alias = alias
+	return nil
+}
+
+func (h *fakeHandle) LinkSetUp(l netlink.Link) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, l)
+	if err != nil {
+		return err
+	}
+	stored.Attrs().Flags |= net.FlagUp
+	stored.Attrs().OperState = netlink.OperUp
+	return nil
+}
+
+func (h *fakeHandle) LinkSetGroup(l netlink.Link, group int) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, l)
+	if err != nil {
+		return err
+	}
+	stored.Attrs().Group = uint32(group)
+	return nil
+}
+
+func (h *fakeHandle) LinkSetARPOff(l netlink.Link) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, l)
+	if err != nil {
+		return err
+	}
+	stored.Attrs().RawFlags |= unix.IFF_NOARP
+	return nil
+}
+
+func (h *fakeHandle) LinkSetHardwareAddr(l netlink.Link, hwaddr net.HardwareAddr) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, l)
+	if err != nil {
+		return err
+	}
+	stored.Attrs().HardwareAddr = append(net.HardwareAddr(nil), hwaddr...)
+	return nil
+}
+
+func (h *fakeHandle) Sysctl(path, value string) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	if _, ok := h.fake.namespaces[h.netNS]; !ok {
+		return errors.Errorf("network namespace not found: %s", h.netNS)
+	}
+	sysctls, ok := h.fake.sysctls[h.netNS]
+	if !ok {
+		sysctls = make(map[string]string)
+		h.fake.sysctls[h.netNS] = sysctls
+	}
+	sysctls[path] = value
+	return nil
+}
+
+func (h *fakeHandle) EthtoolChange(name string, features map[string]bool) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}})
+	if err != nil {
+		return err
+	}
+	index := stored.Attrs().Index
+	if _, ok := h.fake.features[index]; !ok {
+		h.fake.features[index] = make(map[string]bool)
+	}
+	for feature, value := range features {
+		h.fake.features[index][feature] = value
+	}
+	return nil
+}
+
+func (h *fakeHandle) AddrList(l netlink.Link, family int) ([]netlink.Addr, error) {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	links, ok := h.fake.namespaces[h.netNS]
+	if !ok {
+		return nil, errors.Errorf("network namespace not found: %s", h.netNS)
+	}
+	if l != nil {
+		stored, err := h.fake.lookupLocked(h.netNS, l)
+		if err != nil {
+			return nil, err
+		}
+		links = map[string]netlink.Link{stored.Attrs().Name: stored}
+	}
+	var rv []netlink.Addr
+	for _, stored := range links {
+		for _, addr := range h.fake.addrs[stored.Attrs().Index] {
+			isV4 := addr.IP.To4() != nil
+			if (family == netlink.FAMILY_V4 && !isV4) || (family == netlink.FAMILY_V6 && isV4) {
+				continue
+			}
+			rv = append(rv, addr)
+		}
+	}
+	return rv, nil
+}
+
+func (h *fakeHandle) LinkSetNs(l netlink.Link, netNSURL string) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, l)
+	if err != nil {
+		return err
+	}
+	target, ok := h.fake.namespaces[netNSURL]
+	if !ok {
+		return errors.Errorf("network namespace not found: %s", netNSURL)
+	}
+	if _, ok := target[stored.Attrs().Name]; ok {
+		return errors.Errorf("file exists: %s", stored.Attrs().Name)
+	}
+	delete(h.fake.namespaces[h.netNS], stored.Attrs().Name)
+	target[stored.Attrs().Name] = stored
+	return nil
+}
+
+func (h *fakeHandle) WireguardSetDevice(l netlink.Link, device *WireguardDevice) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, l)
+	if err != nil {
+		return err
+	}
+	if stored.Type() != "wireguard" {
+		return errors.Errorf("not a wireguard link: %s", stored.Attrs().Name)
+	}
+	configured := *device
+	configured.Peers = append([]WireguardPeer(nil), device.Peers...)
+	h.fake.wireguard[stored.Attrs().Index] = &configured
+	return nil
+}
+
+// Qdiscs are identified by their link and parent, filters by their link, parent, priority and handle. Deleting a
+// qdisc deletes the filters attached to it, like the kernel does.
+func filterKey(filter netlink.Filter) string {
+	return fmt.Sprintf("%d/%d/%d", filter.Attrs().Parent, filter.Attrs().Priority, filter.Attrs().Handle)
+}
+
+func (h *fakeHandle) lookupIndexLocked(index int) error {
+	_, err := h.fake.lookupLocked(h.netNS, &netlink.Device{LinkAttrs: netlink.LinkAttrs{Index: index}})
+	return err
+}
+
+func (h *fakeHandle) QdiscReplace(qdisc netlink.Qdisc) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	index := qdisc.Attrs().LinkIndex
+	if err := h.lookupIndexLocked(index); err != nil {
+		return err
+	}
+	qdiscs, ok := h.fake.qdiscs[index]
+	if !ok {
+		qdiscs = make(map[uint32]netlink.Qdisc)
+		h.fake.qdiscs[index] = qdiscs
+	}
+	qdiscs[qdisc.Attrs().Parent] = qdisc
+	return nil
+}
+
+func (h *fakeHandle) QdiscDel(qdisc netlink.Qdisc) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	index := qdisc.Attrs().LinkIndex
+	if err := h.lookupIndexLocked(index); err != nil {
+		return err
+	}
+	stored, ok := h.fake.qdiscs[index][qdisc.Attrs().Parent]
+	if !ok {
+		return errors.Errorf("invalid argument: no qdisc attached to %d", qdisc.Attrs().Parent)
+	}
+	delete(h.fake.qdiscs[index], qdisc.Attrs().Parent)
+	for key, filter := range h.fake.filters[index] {
+		if filter.Attrs().Parent == stored.Attrs().Handle {
+			delete(h.fake.filters[index], key)
+		}
+	}
+	return nil
+}
+
+func (h *fakeHandle) QdiscList(l netlink.Link) ([]netlink.Qdisc, error) {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, l)
+	if err != nil {
+		return nil, err
+	}
+	var rv []netlink.Qdisc
+	for _, qdisc := range h.fake.qdiscs[stored.Attrs().Index] {
+		rv = append(rv, qdisc)
+	}
+	return rv, nil
+}
+
+func (h *fakeHandle) FilterReplace(filter netlink.Filter) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	index := filter.Attrs().LinkIndex
+	if err := h.lookupIndexLocked(index); err != nil {
+		return err
+	}
+	attached := false
+	for _, qdisc := range h.fake.qdiscs[index] {
+		attached = attached || qdisc.Attrs().Handle == filter.Attrs().Parent
+	}
+	if !attached {
+		return errors.Errorf("invalid argument: no qdisc with handle %d", filter.Attrs().Parent)
+	}
+	filters, ok := h.fake.filters[index]
+	if !ok {
+		filters = make(map[string]netlink.Filter)
+		h.fake.filters[index] = filters
+	}
+	filters[filterKey(filter)] = filter
+	return nil
+}
+
+func (h *fakeHandle) FilterDel(filter netlink.Filter) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	index := filter.Attrs().LinkIndex
+	if err := h.lookupIndexLocked(index); err != nil {
+		return err
+	}
+	key := filterKey(filter)
+	if _, ok := h.fake.filters[index][key]; !ok {
+		return errors.Errorf("no such file or directory: filter %s", key)
+	}
+	delete(h.fake.filters[index], key)
+	return nil
+}
+
+func (h *fakeHandle) FilterList(l netlink.Link, parent uint32) ([]netlink.Filter, error) {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	stored, err := h.fake.lookupLocked(h.netNS, l)
+	if err != nil {
+		return nil, err
+	}
+	var rv []netlink.Filter
+	for _, filter := range h.fake.filters[stored.Attrs().Index] {
+		if filter.Attrs().Parent == parent {
+			rv = append(rv, filter)
+		}
+	}
+	return rv, nil
+}
+
+// States are identified by their destination, protocol and SPI, policies by their selector and direction, like
+// in the kernel.
+func xfrmStateKey(state *netlink.XfrmState) string {
+	return fmt.Sprintf("%s/%d/%d", state.Dst, state.Proto, state.Spi)
+}
+
+func xfrmPolicyKey(policy *netlink.XfrmPolicy) string {
+	return fmt.Sprintf("%s/%s/%d/%d/%d/%d", policy.Src, policy.Dst, policy.Proto, policy.SrcPort, policy.DstPort, policy.Dir)
+}
+
+func (h *fakeHandle) XfrmStateAdd(state *netlink.XfrmState) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	states, ok := h.fake.states[h.netNS]
+	if !ok {
+		states = make(map[string]netlink.XfrmState)
+		h.fake.states[h.netNS] = states
+	}
+	key := xfrmStateKey(state)
+	if _, ok := states[key]; ok {
+		return errors.Errorf("file exists: xfrm state %s", key)
+	}
+	states[key] = *state
+	return nil
+}
+
+func (h *fakeHandle) XfrmStateGet(state *netlink.XfrmState) (*netlink.XfrmState, error) {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	key := xfrmStateKey(state)
+	found, ok := h.fake.states[h.netNS][key]
+	if !ok {
+		return nil, errors.Errorf("no such process: xfrm state %s", key)
+	}
+	return &found, nil
+}
+
+func (h *fakeHandle) XfrmStateDel(state *netlink.XfrmState) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	key := xfrmStateKey(state)
+	if _, ok := h.fake.states[h.netNS][key]; !ok {
+		return errors.Errorf("no such process: xfrm state %s", key)
+	}
+	delete(h.fake.states[h.netNS], key)
+	return nil
+}
+
+func (h *fakeHandle) XfrmPolicyUpdate(policy *netlink.XfrmPolicy) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	policies, ok := h.fake.policies[h.netNS]
+	if !ok {
+		policies = make(map[string]netlink.XfrmPolicy)
+		h.fake.policies[h.netNS] = policies
+	}
+	policies[xfrmPolicyKey(policy)] = *policy
+	return nil
+}
+
+func (h *fakeHandle) XfrmPolicyDel(policy *netlink.XfrmPolicy) error {
+	h.fake.mu.Lock()
+	defer h.fake.mu.Unlock()
+
+	key := xfrmPolicyKey(policy)
+	if _, ok := h.fake.policies[h.netNS][key]; !ok {
+		return errors.Errorf("no such file or directory: xfrm policy %s", key)
+	}
+	delete(h.fake.policies[h.netNS], key)
+	return nil
+}
+
+func (h *fakeHandle) Close() {}
@@ -0,0 +1,156 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package nlhandle
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/tools/nshandle"
+)
+
+// The geneve options of the tunnel_key action, see include/uapi/linux/tc_act/tc_tunnel_key.h
+const (
+	tunnelKeyEncOptsGeneve = 1
+
+	tunnelKeyEncOptGeneveClass = 1
+	tunnelKeyEncOptGeneveType  = 2
+	tunnelKeyEncOptGeneveData  = 3
+)
+
+// GeneveOption is a TLV option of the geneve header
+type GeneveOption struct {
+	Class uint16
+	Type  uint8
+	// Data is a multiple of 4 bytes long
+	Data []byte
+}
+
+// GeneveEncap is a matchall filter redirecting all the frames to the external (flow based) geneve link Redirect,
+// with the tunnel key and the TLV options the link encapsulates them with. The netlink package does not encode the
+// geneve options of the tunnel_key action, so the kernel handles encode the filter themselves.
+type GeneveEncap struct {
+	netlink.FilterAttrs
+	Redirect int
+	ID       uint32
+	Src      net.IP
+	Dst      net.IP
+	Port     uint16
+	Options  []GeneveOption
+}
+
+// Attrs returns the attributes of the filter
+func (f *GeneveEncap) Attrs() *netlink.FilterAttrs {
+	return &f.FilterAttrs
+}
+
+// Type returns the kind of the filter
+func (f *GeneveEncap) Type() string {
+	return "matchall"
+}
+
+func (h *kernelHandle) FilterReplace(filter netlink.Filter) error {
+	encap, ok := filter.(*GeneveEncap)
+	if !ok {
+		return errors.WithStack(h.Handle.FilterReplace(filter))
+	}
+	if h.host {
+		return geneveEncapReplace(encap)
+	}
+
+	// The request is sent on a socket of the package, which is in the namespace of the thread opening it
+	current, err := nshandle.Current()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = current.Close() }()
+	target, err := nshandle.FromURL(h.netNSURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = target.Close() }()
+
+	return nshandle.RunIn(current, target, func() error {
+		return geneveEncapReplace(encap)
+	})
+}
+
+// geneveEncapReplace adds the filter like tc filter replace ... matchall action tunnel_key set ... geneve_opts ...
+// action mirred egress redirect dev ... does
+func geneveEncapReplace(encap *GeneveEncap) error {
+	src, dst := encap.Src.To4(), encap.Dst.To4()
+	srcType, dstType := nl.TCA_TUNNEL_KEY_ENC_IPV4_SRC, nl.TCA_TUNNEL_KEY_ENC_IPV4_DST
+	if src == nil || dst == nil {
+		src, dst = encap.Src.To16(), encap.Dst.To16()
+		srcType, dstType = nl.TCA_TUNNEL_KEY_ENC_IPV6_SRC, nl.TCA_TUNNEL_KEY_ENC_IPV6_DST
+	}
+	if src == nil || dst == nil {
+		return errors.Errorf("invalid tunnel key addresses %s and %s", encap.Src, encap.Dst)
+	}
+
+	req := nl.NewNetlinkRequest(unix.RTM_NEWTFILTER, unix.NLM_F_CREATE|unix.NLM_F_ACK)
+	req.AddData(&nl.TcMsg{
+		Family:  nl.FAMILY_ALL,
+		Ifindex: int32(encap.LinkIndex),
+		Handle:  encap.Handle,
+		Parent:  encap.Parent,
+		Info:    netlink.MakeHandle(encap.Priority, nl.Swap16(encap.Protocol)),
+	})
+	req.AddData(nl.NewRtAttr(nl.TCA_KIND, nl.ZeroTerminated(encap.Type())))
+
+	options := nl.NewRtAttr(nl.TCA_OPTIONS, nil)
+	actions := options.AddRtAttr(nl.TCA_MATCHALL_ACT, nil)
+
+	tunnelKey := actions.AddRtAttr(nl.TCA_ACT_TAB, nil)
+	tunnelKey.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("tunnel_key"))
+	tunnelKeyOptions := tunnelKey.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
+	tunnelKeyParms := nl.TcTunnelKey{TcGen: nl.TcGen{Action: int32(netlink.TC_ACT_PIPE)}, Action: int32(netlink.TCA_TUNNEL_KEY_SET)}
+	tunnelKeyOptions.AddRtAttr(nl.TCA_TUNNEL_KEY_PARMS, tunnelKeyParms.Serialize())
+	tunnelKeyOptions.AddRtAttr(nl.TCA_TUNNEL_KEY_ENC_KEY_ID, binary.BigEndian.AppendUint32(nil, encap.ID))
+	tunnelKeyOptions.AddRtAttr(srcType, src)
+	tunnelKeyOptions.AddRtAttr(dstType, dst)
+	tunnelKeyOptions.AddRtAttr(nl.TCA_TUNNEL_KEY_ENC_DST_PORT, binary.BigEndian.AppendUint16(nil, encap.Port))
+	if len(encap.Options) > 0 {
+		encOpts := tunnelKeyOptions.AddRtAttr(nl.TCA_TUNNEL_KEY_ENC_OPTS|unix.NLA_F_NESTED, nil)
+		for _, opt := range encap.Options {
+			geneveOpt := encOpts.AddRtAttr(tunnelKeyEncOptsGeneve|unix.NLA_F_NESTED, nil)
+			geneveOpt.AddRtAttr(tunnelKeyEncOptGeneveClass, binary.BigEndian.AppendUint16(nil, opt.Class))
+			geneveOpt.AddRtAttr(tunnelKeyEncOptGeneveType, nl.Uint8Attr(opt.Type))
+			geneveOpt.AddRtAttr(tunnelKeyEncOptGeneveData, opt.Data)
+		}
+	}
+
+	mirred := actions.AddRtAttr(nl.TCA_ACT_TAB+1, nil)
+	mirred.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("mirred"))
+	mirredParms := nl.TcMirred{
+		TcGen:   nl.TcGen{Action: int32(netlink.TC_ACT_STOLEN)},
+		Eaction: int32(netlink.TCA_EGRESS_REDIR),
+		Ifindex: uint32(encap.Redirect),
+	}
+	mirred.AddRtAttr(nl.TCA_ACT_OPTIONS, nil).AddRtAttr(nl.TCA_MIRRED_PARMS, mirredParms.Serialize())
+
+	req.AddData(options)
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return errors.WithStack(err)
+}
@@ -0,0 +1,170 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package nlhandle abstracts the netlink operations the forwarder performs on kernel links, so that the
+// mechanism packages can be driven either by the kernel or by an in-memory fake.
+package nlhandle
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/safchain/ethtool"
+	"github.com/vishvananda/netlink"
+
+	kernellink "github.com/networkservicemesh/sdk-kernel/pkg/kernel"
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/tools/nshandle"
+)
+
+// Handle - netlink link operations scoped to a single network namespace
+type Handle interface {
+	LinkAdd(link netlink.Link) error
+	LinkDel(link netlink.Link) error
+	LinkByName(name string) (netlink.Link, error)
+	LinkList() ([]netlink.Link, error)
+	LinkSetName(link netlink.Link, name string) error
+	LinkSetAlias(link netlink.Link, alias string) error
+	LinkSetUp(link netlink.Link) error
+	LinkSetGroup(link netlink.Link, group int) error
+	LinkSetARPOff(link netlink.Link) error
+	LinkSetHardwareAddr(link netlink.Link, hwaddr net.HardwareAddr) error
+	// Sysctl writes value to the sysctl at path, relative to /proc/sys, in the network namespace of this handle
+	Sysctl(path, value string) error
+	// EthtoolChange sets the features of the link name, by ethtool feature name, in the network namespace of this handle
+	EthtoolChange(name string, features map[string]bool) error
+	// AddrList returns the addresses of the link, or of all the links of the namespace if link is nil
+	AddrList(link netlink.Link, family int) ([]netlink.Addr, error)
+	// LinkSetNs moves the link from the namespace of this handle to the network namespace referred to by netNSURL
+	LinkSetNs(link netlink.Link, netNSURL string) error
+	// QdiscReplace adds the qdisc to its link, replacing the qdisc already attached to the same parent if any
+	QdiscReplace(qdisc netlink.Qdisc) error
+	QdiscDel(qdisc netlink.Qdisc) error
+	QdiscList(link netlink.Link) ([]netlink.Qdisc, error)
+	// FilterReplace adds the filter, replacing the filter with the same parent, priority and handle if any. The
+	// filter may be a GeneveEncap.
+	FilterReplace(filter netlink.Filter) error
+	FilterDel(filter netlink.Filter) error
+	// FilterList returns the filters of the link attached to the qdisc with the handle parent
+	FilterList(link netlink.Link, parent uint32) ([]netlink.Filter, error)
+	// WireguardSetDevice configures the wireguard link, replacing all of its peers
+	WireguardSetDevice(link netlink.Link, device *WireguardDevice) error
+	XfrmStateAdd(state *netlink.XfrmState) error
+	// XfrmStateGet returns the state with the destination, protocol and SPI of state
+	XfrmStateGet(state *netlink.XfrmState) (*netlink.XfrmState, error)
+	XfrmStateDel(state *netlink.XfrmState) error
+	XfrmPolicyUpdate(policy *netlink.XfrmPolicy) error
+	XfrmPolicyDel(policy *netlink.XfrmPolicy) error
+	Close()
+}
+
+// Provider - returns netlink handles for the forwarder (host) network namespace and for the
+// network namespaces referred to by the inodeURL mechanism parameter
+type Provider interface {
+	Host() (Handle, error)
+	FromURL(netNSURL string) (Handle, error)
+}
+
+type kernelProvider struct{}
+
+// NewProvider returns a Provider backed by the kernel netlink sockets
+func NewProvider() Provider {
+	return &kernelProvider{}
+}
+
+func (p *kernelProvider) Host() (Handle, error) {
+	// The zero value handle uses the package level netlink sockets of the current namespace
+	return &kernelHandle{Handle: &netlink.Handle{}, host: true}, nil
+}
+
+func (p *kernelProvider) FromURL(netNSURL string) (Handle, error) {
+	handle, err := kernellink.GetNetlinkHandle(netNSURL)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &kernelHandle{Handle: handle, netNSURL: netNSURL}, nil
+}
+
+type kernelHandle struct {
+	*netlink.Handle
+	host     bool
+	netNSURL string
+}
+
+func (h *kernelHandle) LinkSetNs(link netlink.Link, netNSURL string) error {
+	nsHandle, err := nshandle.FromURL(netNSURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = nsHandle.Close() }()
+
+	return h.Handle.LinkSetNsFd(link, int(nsHandle))
+}
+
+func (h *kernelHandle) Sysctl(path, value string) error {
+	write := func() error {
+		return errors.WithStack(os.WriteFile(filepath.Join("/proc/sys", path), []byte(value), 0o600))
+	}
+	if h.host {
+		return write()
+	}
+
+	// The sysctls of the network namespace are the ones of the namespace of the thread opening them
+	current, err := nshandle.Current()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = current.Close() }()
+	target, err := nshandle.FromURL(h.netNSURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = target.Close() }()
+
+	return nshandle.RunIn(current, target, write)
+}
+
+func (h *kernelHandle) EthtoolChange(name string, features map[string]bool) error {
+	change := func() error {
+		// The ethtool socket addresses the links of the namespace of the thread opening it
+		ethHandle, err := ethtool.NewEthtool()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer ethHandle.Close()
+
+		return errors.WithStack(ethHandle.Change(name, features))
+	}
+	if h.host {
+		return change()
+	}
+
+	current, err := nshandle.Current()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = current.Close() }()
+	target, err := nshandle.FromURL(h.netNSURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = target.Close() }()
+
+	return nshandle.RunIn(current, target, change)
+}
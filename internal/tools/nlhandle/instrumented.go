@@ -0,0 +1,244 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package nlhandle
+
+import (
+	"net"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+)
+
+type instrumentedProvider struct {
+	Provider
+}
+
+// WithMetrics returns a Provider whose handles record the latency and result of every netlink
+// operation in metrics.NetlinkOperationDuration
+func WithMetrics(p Provider) Provider {
+	return &instrumentedProvider{Provider: p}
+}
+
+func (p *instrumentedProvider) Host() (Handle, error) {
+	h, err := p.Provider.Host()
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedHandle{Handle: h}, nil
+}
+
+func (p *instrumentedProvider) FromURL(netNSURL string) (Handle, error) {
+	h, err := p.Provider.FromURL(netNSURL)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedHandle{Handle: h}, nil
+}
+
+type instrumentedHandle struct {
+	Handle
+}
+
+func observe(operation string, start time.Time, err error) {
+	metrics.NetlinkOperationDuration.Observe(time.Since(start).Seconds(), operation, metrics.Result(err))
+}
+
+func (h *instrumentedHandle) LinkByName(name string) (netlink.Link, error) {
+	start := time.Now()
+	l, err := h.Handle.LinkByName(name)
+	observe("LinkByName", start, err)
+	return l, err
+}
+
+func (h *instrumentedHandle) LinkList() ([]netlink.Link, error) {
+	start := time.Now()
+	links, err := h.Handle.LinkList()
+	observe("LinkList", start, err)
+	return links, err
+}
+
+func (h *instrumentedHandle) AddrList(link netlink.Link, family int) ([]netlink.Addr, error) {
+	start := time.Now()
+	addrs, err := h.Handle.AddrList(link, family)
+	observe("AddrList", start, err)
+	return addrs, err
+}
+
+func (h *instrumentedHandle) LinkAdd(link netlink.Link) error {
+	start := time.Now()
+	err := h.Handle.LinkAdd(link)
+	observe("LinkAdd", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) LinkDel(link netlink.Link) error {
+	start := time.Now()
+	err := h.Handle.LinkDel(link)
+	observe("LinkDel", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) LinkSetName(link netlink.Link, name string) error {
+	start := time.Now()
+	err := h.Handle.LinkSetName(link, name)
+	observe("LinkSetName", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) LinkSetAlias(link netlink.Link, alias string) error {
+	start := time.Now()
+	err := h.Handle.LinkSetAlias(link, alias)
+	observe("LinkSetAlias", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) LinkSetUp(link netlink.Link) error {
+	start := time.Now()
+	err := h.Handle.LinkSetUp(link)
+	observe("LinkSetUp", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) LinkSetGroup(link netlink.Link, group int) error {
+	start := time.Now()
+	err := h.Handle.LinkSetGroup(link, group)
+	observe("LinkSetGroup", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) LinkSetARPOff(link netlink.Link) error {
+	start := time.Now()
+	err := h.Handle.LinkSetARPOff(link)
+	observe("LinkSetARPOff", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) LinkSetHardwareAddr(link netlink.Link, hwaddr net.HardwareAddr) error {
+	start := time.Now()
+	err := h.Handle.LinkSetHardwareAddr(link, hwaddr)
+	observe("LinkSetHardwareAddr", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) Sysctl(path, value string) error {
+	start := time.Now()
+	err := h.Handle.Sysctl(path, value)
+	observe("Sysctl", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) EthtoolChange(name string, features map[string]bool) error {
+	start := time.Now()
+	err := h.Handle.EthtoolChange(name, features)
+	observe("EthtoolChange", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) LinkSetNs(link netlink.Link, netNSURL string) error {
+	start := time.Now()
+	err := h.Handle.LinkSetNs(link, netNSURL)
+	observe("LinkSetNs", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) QdiscReplace(qdisc netlink.Qdisc) error {
+	start := time.Now()
+	err := h.Handle.QdiscReplace(qdisc)
+	observe("QdiscReplace", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) QdiscDel(qdisc netlink.Qdisc) error {
+	start := time.Now()
+	err := h.Handle.QdiscDel(qdisc)
+	observe("QdiscDel", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) QdiscList(link netlink.Link) ([]netlink.Qdisc, error) {
+	start := time.Now()
+	qdiscs, err := h.Handle.QdiscList(link)
+	observe("QdiscList", start, err)
+	return qdiscs, err
+}
+
+func (h *instrumentedHandle) FilterReplace(filter netlink.Filter) error {
+	start := time.Now()
+	err := h.Handle.FilterReplace(filter)
+	observe("FilterReplace", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) FilterDel(filter netlink.Filter) error {
+	start := time.Now()
+	err := h.Handle.FilterDel(filter)
+	observe("FilterDel", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) FilterList(link netlink.Link, parent uint32) ([]netlink.Filter, error) {
+	start := time.Now()
+	filters, err := h.Handle.FilterList(link, parent)
+	observe("FilterList", start, err)
+	return filters, err
+}
+
+func (h *instrumentedHandle) WireguardSetDevice(link netlink.Link, device *WireguardDevice) error {
+	start := time.Now()
+	err := h.Handle.WireguardSetDevice(link, device)
+	observe("WireguardSetDevice", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) XfrmStateAdd(state *netlink.XfrmState) error {
+	start := time.Now()
+	err := h.Handle.XfrmStateAdd(state)
+	observe("XfrmStateAdd", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) XfrmStateGet(state *netlink.XfrmState) (*netlink.XfrmState, error) {
+	start := time.Now()
+	s, err := h.Handle.XfrmStateGet(state)
+	observe("XfrmStateGet", start, err)
+	return s, err
+}
+
+func (h *instrumentedHandle) XfrmStateDel(state *netlink.XfrmState) error {
+	start := time.Now()
+	err := h.Handle.XfrmStateDel(state)
+	observe("XfrmStateDel", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) XfrmPolicyUpdate(policy *netlink.XfrmPolicy) error {
+	start := time.Now()
+	err := h.Handle.XfrmPolicyUpdate(policy)
+	observe("XfrmPolicyUpdate", start, err)
+	return err
+}
+
+func (h *instrumentedHandle) XfrmPolicyDel(policy *netlink.XfrmPolicy) error {
+	start := time.Now()
+	err := h.Handle.XfrmPolicyDel(policy)
+	observe("XfrmPolicyDel", start, err)
+	return err
+}
@@ -0,0 +1,160 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package nlhandle
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/networkservicemesh/sdk-kernel/pkg/kernel/tools/nshandle"
+)
+
+// The wireguard generic netlink API, see include/uapi/linux/wireguard.h
+const (
+	wgGenlName    = "wireguard"
+	wgGenlVersion = 1
+
+	wgCmdSetDevice = 1
+
+	wgDeviceAIfindex    = 1
+	wgDeviceAPrivateKey = 3
+	wgDeviceAFlags      = 5
+	wgDeviceAListenPort = 6
+	wgDeviceAPeers      = 8
+
+	wgDeviceFReplacePeers = 1
+
+	wgPeerAPublicKey                   = 1
+	wgPeerAFlags                       = 3
+	wgPeerAEndpoint                    = 4
+	wgPeerAPersistentKeepaliveInterval = 5
+	wgPeerAAllowedIPs                  = 9
+
+	wgPeerFReplaceAllowedIPs = 2
+
+	wgAllowedIPAFamily   = 1
+	wgAllowedIPAIPAddr   = 2
+	wgAllowedIPACidrMask = 3
+)
+
+// WireguardDevice is the configuration of a wireguard link
+type WireguardDevice struct {
+	PrivateKey []byte
+	ListenPort uint16
+	Peers      []WireguardPeer
+}
+
+// WireguardPeer is the configuration of a peer of a wireguard link
+type WireguardPeer struct {
+	PublicKey           []byte
+	Endpoint            *net.UDPAddr
+	AllowedIPs          []net.IPNet
+	PersistentKeepalive time.Duration
+}
+
+// WireguardSetDevice configures the link through the wireguard generic netlink family, in the network namespace of
+// the handle. Wireguard links keep their UDP socket in the namespace they were created in when moved, the forwarder
+// network namespace, so they can be reconfigured once moved to the pods.
+func (h *kernelHandle) WireguardSetDevice(link netlink.Link, device *WireguardDevice) error {
+	if h.host {
+		return h.wireguardSetDevice(link, device)
+	}
+
+	// The generic netlink socket addresses the links of the namespace of the thread opening it
+	current, err := nshandle.Current()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = current.Close() }()
+	target, err := nshandle.FromURL(h.netNSURL)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() { _ = target.Close() }()
+
+	return nshandle.RunIn(current, target, func() error {
+		return h.wireguardSetDevice(link, device)
+	})
+}
+
+func (h *kernelHandle) wireguardSetDevice(link netlink.Link, device *WireguardDevice) error {
+	family, err := netlink.GenlFamilyGet(wgGenlName)
+	if err != nil {
+		return errors.Wrap(err, "wireguard generic netlink family not found")
+	}
+
+	req := nl.NewNetlinkRequest(int(family.ID), unix.NLM_F_ACK)
+	req.AddData(&nl.Genlmsg{Command: wgCmdSetDevice, Version: wgGenlVersion})
+	req.AddData(nl.NewRtAttr(wgDeviceAIfindex, nl.Uint32Attr(uint32(link.Attrs().Index))))
+	req.AddData(nl.NewRtAttr(wgDeviceAFlags, nl.Uint32Attr(wgDeviceFReplacePeers)))
+	if device.PrivateKey != nil {
+		req.AddData(nl.NewRtAttr(wgDeviceAPrivateKey, device.PrivateKey))
+	}
+	req.AddData(nl.NewRtAttr(wgDeviceAListenPort, nl.Uint16Attr(device.ListenPort)))
+
+	peers := nl.NewRtAttr(wgDeviceAPeers|unix.NLA_F_NESTED, nil)
+	for i := range device.Peers {
+		peer := &device.Peers[i]
+		peerAttr := peers.AddRtAttr(i|unix.NLA_F_NESTED, nil)
+		peerAttr.AddRtAttr(wgPeerAPublicKey, peer.PublicKey)
+		peerAttr.AddRtAttr(wgPeerAFlags, nl.Uint32Attr(wgPeerFReplaceAllowedIPs))
+		if peer.Endpoint != nil {
+			peerAttr.AddRtAttr(wgPeerAEndpoint, sockaddr(peer.Endpoint))
+		}
+		peerAttr.AddRtAttr(wgPeerAPersistentKeepaliveInterval, nl.Uint16Attr(uint16(peer.PersistentKeepalive/time.Second)))
+		allowedIPs := peerAttr.AddRtAttr(wgPeerAAllowedIPs|unix.NLA_F_NESTED, nil)
+		for j, ipNet := range peer.AllowedIPs {
+			family, ip := uint16(unix.AF_INET6), ipNet.IP.To16()
+			if ip4 := ipNet.IP.To4(); ip4 != nil {
+				family, ip = unix.AF_INET, ip4
+			}
+			ones, _ := ipNet.Mask.Size()
+			allowedIP := allowedIPs.AddRtAttr(j|unix.NLA_F_NESTED, nil)
+			allowedIP.AddRtAttr(wgAllowedIPAFamily, nl.Uint16Attr(family))
+			allowedIP.AddRtAttr(wgAllowedIPAIPAddr, ip)
+			allowedIP.AddRtAttr(wgAllowedIPACidrMask, nl.Uint8Attr(uint8(ones)))
+		}
+	}
+	req.AddData(peers)
+
+	_, err = req.Execute(unix.NETLINK_GENERIC, 0)
+	return errors.WithStack(err)
+}
+
+// sockaddr returns the struct sockaddr_in or sockaddr_in6 the wireguard API expects for peer endpoints
+func sockaddr(addr *net.UDPAddr) []byte {
+	if ip4 := addr.IP.To4(); ip4 != nil {
+		b := make([]byte, unix.SizeofSockaddrInet4)
+		nl.NativeEndian().PutUint16(b[0:2], unix.AF_INET)
+		binary.BigEndian.PutUint16(b[2:4], uint16(addr.Port))
+		copy(b[4:8], ip4)
+		return b
+	}
+	b := make([]byte, unix.SizeofSockaddrInet6)
+	nl.NativeEndian().PutUint16(b[0:2], unix.AF_INET6)
+	binary.BigEndian.PutUint16(b[2:4], uint16(addr.Port))
+	copy(b[8:24], addr.IP.To16())
+	return b
+}
@@ -0,0 +1,52 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package orphans
+
+import (
+	"time"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+// Option is an option pattern for NewReconciler
+type Option func(r *Reconciler)
+
+// WithNetlinkProvider sets the provider of the netlink handles used to list and delete the links
+func WithNetlinkProvider(nl nlhandle.Provider) Option {
+	return func(r *Reconciler) {
+		r.nl = nl
+	}
+}
+
+// WithGracePeriod sets the time the orphaned interfaces are kept around waiting for their connection
+// to be refreshed before they are deleted
+func WithGracePeriod(gracePeriod time.Duration) Option {
+	return func(r *Reconciler) {
+		r.gracePeriod = gracePeriod
+	}
+}
+
+// WithNetNS sets the functions used to enumerate the pod network namespaces (keyed by inode, excluding
+// the forwarder namespace) and to resolve the inode of the namespace a mechanism inodeURL refers to
+func WithNetNS(listNetNS func() (map[uint64]string, error), netNSInode func(netNSURL string) (uint64, error)) Option {
+	return func(r *Reconciler) {
+		r.listNetNS = listNetNS
+		r.netNSInode = netNSInode
+	}
+}
@@ -0,0 +1,213 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package orphans reconciles the interfaces left behind by a previous instance of the forwarder.
+package orphans
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/fs"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const defaultGracePeriod = 15 * time.Minute
+
+type orphanKey struct {
+	netNS uint64
+	name  string
+}
+
+type orphan struct {
+	netNSURL string
+	link     netlink.Link
+}
+
+// Reconciler finds the interfaces marked with link.Group in the pod network namespaces when the forwarder
+// starts. The per connection link metadata of a previous forwarder instance is lost on restart, so these
+// interfaces are held as orphans until either a Request for their connection claims them back, or the grace
+// period expires and they are deleted.
+type Reconciler struct {
+	nl          nlhandle.Provider
+	gracePeriod time.Duration
+	listNetNS   func() (map[uint64]string, error)
+	netNSInode  func(netNSURL string) (uint64, error)
+
+	mu      sync.Mutex
+	orphans map[orphanKey]*orphan
+}
+
+// NewReconciler scans the network namespaces for orphaned interfaces and schedules their deletion after the
+// grace period. The scan is done before returning, so that it is not racing with the first Requests.
+func NewReconciler(ctx context.Context, options ...Option) *Reconciler {
+	r := &Reconciler{
+		nl:          nlhandle.NewProvider(),
+		gracePeriod: defaultGracePeriod,
+		listNetNS:   listPodNetNS,
+		netNSInode:  fs.GetNetnsInodeFromURL,
+		orphans:     make(map[orphanKey]*orphan),
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+
+	r.scan(ctx)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-time.After(r.gracePeriod):
+			r.sweep(ctx)
+		}
+	}()
+
+	return r
+}
+
+// Claim removes the interface name in the network namespace referred to by netNSURL from the orphans, if
+// it is one. It is called for every interface a Request is about to create.
+func (r *Reconciler) Claim(ctx context.Context, netNSURL, name string) {
+	if r == nil || netNSURL == "" || name == "" {
+		return
+	}
+	inode, err := r.netNSInode(netNSURL)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := orphanKey{netNS: inode, name: name}
+	if _, ok := r.orphans[key]; !ok {
+		return
+	}
+	delete(r.orphans, key)
+	log.FromContext(ctx).
+		WithField("link.Name", name).
+		WithField("netns", netNSURL).
+		WithField("orphans", "Claim").Info("adopted")
+}
+
+func (r *Reconciler) scan(ctx context.Context) {
+	logger := log.FromContext(ctx).WithField("orphans", "scan")
+
+	// Interfaces marked with the forwarder group in the forwarder namespace are leftovers of a Request that
+	// was interrupted before the link could be moved to the pod. No connection can be using them.
+	if hostHandle, err := r.nl.Host(); err == nil {
+		for _, l := range r.forwarderLinks(ctx, hostHandle) {
+			if err := hostHandle.LinkDel(l); err != nil {
+				logger.WithField("link.Name", l.Attrs().Name).Errorf("failed to delete: %v", err)
+				continue
+			}
+			logger.WithField("link.Name", l.Attrs().Name).WithField("netlink", "LinkDel").Info("completed")
+		}
+		hostHandle.Close()
+	} else {
+		logger.Errorf("failed to get the forwarder netlink handle: %v", err)
+	}
+
+	netNSURLs, err := r.listNetNS()
+	if err != nil {
+		logger.Errorf("failed to list the network namespaces: %v", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for inode, netNSURL := range netNSURLs {
+		handle, err := r.nl.FromURL(netNSURL)
+		if err != nil {
+			// The process may have exited in the meantime
+			logger.WithField("netns", netNSURL).Debugf("failed to get the netlink handle: %v", err)
+			continue
+		}
+		for _, l := range r.forwarderLinks(ctx, handle) {
+			r.orphans[orphanKey{netNS: inode, name: l.Attrs().Name}] = &orphan{netNSURL: netNSURL, link: l}
+			logger.WithField("link.Name", l.Attrs().Name).WithField("netns", netNSURL).Info("found orphan")
+		}
+		handle.Close()
+	}
+	logger.Infof("found %d orphaned interfaces, grace period: %v", len(r.orphans), r.gracePeriod)
+}
+
+func (r *Reconciler) sweep(ctx context.Context) {
+	logger := log.FromContext(ctx).WithField("orphans", "sweep")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, o := range r.orphans {
+		delete(r.orphans, key)
+
+		handle, err := r.nl.FromURL(o.netNSURL)
+		if err != nil {
+			logger.WithField("netns", o.netNSURL).Debugf("failed to get the netlink handle: %v", err)
+			continue
+		}
+		// Make sure the interface is still the one found by the scan and not one created since
+		l, err := handle.LinkByName(key.name)
+		if err != nil || l.Attrs().Index != o.link.Attrs().Index || l.Attrs().Group != link.Group {
+			handle.Close()
+			continue
+		}
+		if err := handle.LinkDel(l); err != nil {
+			logger.WithField("link.Name", key.name).WithField("netns", o.netNSURL).Errorf("failed to delete: %v", err)
+		} else {
+			logger.WithField("link.Name", key.name).WithField("netns", o.netNSURL).WithField("netlink", "LinkDel").Info("completed")
+			metrics.OrphanLinkDeletions.Inc()
+		}
+		handle.Close()
+	}
+}
+
+func (r *Reconciler) forwarderLinks(ctx context.Context, handle nlhandle.Handle) []netlink.Link {
+	links, err := handle.LinkList()
+	if err != nil {
+		log.FromContext(ctx).
+			WithField("err", err).
+			WithField("netlink", "LinkList").Debug("error")
+		return nil
+	}
+	var rv []netlink.Link
+	for _, l := range links {
+		if l.Attrs().Group == link.Group {
+			rv = append(rv, l)
+		}
+	}
+	return rv
+}
+
+func listPodNetNS() (map[uint64]string, error) {
+	netNSURLs, err := fs.GetAllNetNsURLs()
+	if err != nil {
+		return nil, err
+	}
+	if self, err := fs.GetInode("/proc/self/ns/net"); err == nil {
+		delete(netNSURLs, self)
+	}
+	return netNSURLs, nil
+}
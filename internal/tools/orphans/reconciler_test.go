@@ -0,0 +1,208 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package orphans
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	podNetNSURL   = "inode://4/1001"
+	podNetNSInode = 1001
+)
+
+// newFake returns a fake with a forwarder link and a link of another owner in both the host and the pod network
+// namespaces
+func newFake(t *testing.T) *nlhandle.Fake {
+	t.Helper()
+	fake := nlhandle.NewFake()
+	fake.AddNamespace(podNetNSURL)
+	for _, netNSURL := range []string{"", podNetNSURL} {
+		var handle nlhandle.Handle
+		var err error
+		if netNSURL == "" {
+			handle, err = fake.Host()
+		} else {
+			handle, err = fake.FromURL(netNSURL)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, l := range []netlink.Link{
+			&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "nsm-1", Group: link.Group}},
+			&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "nsm-2", Group: link.Group}},
+			&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "eth0"}},
+		} {
+			if err = handle.LinkAdd(l); err != nil {
+				t.Fatal(err)
+			}
+		}
+		handle.Close()
+	}
+	return fake
+}
+
+func newReconciler(ctx context.Context, fake *nlhandle.Fake, gracePeriod time.Duration) *Reconciler {
+	return NewReconciler(ctx,
+		WithNetlinkProvider(fake),
+		WithGracePeriod(gracePeriod),
+		WithNetNS(
+			func() (map[uint64]string, error) { return map[uint64]string{podNetNSInode: podNetNSURL}, nil },
+			func(netNSURL string) (uint64, error) { return podNetNSInode, nil },
+		),
+	)
+}
+
+// links returns the names of the links of the network namespace netNSURL
+func links(t *testing.T, fake *nlhandle.Fake, netNSURL string) map[string]bool {
+	t.Helper()
+	var handle nlhandle.Handle
+	var err error
+	if netNSURL == "" {
+		handle, err = fake.Host()
+	} else {
+		handle, err = fake.FromURL(netNSURL)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	list, err := handle.LinkList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv := make(map[string]bool)
+	for _, l := range list {
+		rv[l.Attrs().Name] = true
+	}
+	return rv
+}
+
+func TestScanHostLeftovers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFake(t)
+
+	newReconciler(ctx, fake, time.Hour)
+
+	// The forwarder links of the host namespace are removed right away, those of the pods are kept
+	if got := links(t, fake, ""); got["nsm-1"] || got["nsm-2"] || !got["eth0"] {
+		t.Errorf("got host links %v, want the forwarder ones removed", got)
+	}
+	if got := links(t, fake, podNetNSURL); !got["nsm-1"] || !got["nsm-2"] || !got["eth0"] {
+		t.Errorf("got pod links %v, want all of them kept", got)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFake(t)
+	r := newReconciler(ctx, fake, time.Hour)
+
+	r.Claim(ctx, podNetNSURL, "nsm-1")
+	r.sweep(ctx)
+
+	// The claimed link survives the sweep, the unclaimed one does not
+	if got := links(t, fake, podNetNSURL); !got["nsm-1"] || got["nsm-2"] || !got["eth0"] {
+		t.Errorf("got pod links %v, want the unclaimed forwarder link removed", got)
+	}
+}
+
+func TestSweepChangedLinks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFake(t)
+	r := newReconciler(ctx, fake, time.Hour)
+
+	handle, err := fake.FromURL(podNetNSURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	// nsm-1 is created again since the scan, nsm-2 leaves the group of the forwarder links
+	l, err := handle.LinkByName("nsm-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = handle.LinkDel(l); err != nil {
+		t.Fatal(err)
+	}
+	if err = handle.LinkAdd(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "nsm-1", Group: link.Group}}); err != nil {
+		t.Fatal(err)
+	}
+	if l, err = handle.LinkByName("nsm-2"); err != nil {
+		t.Fatal(err)
+	}
+	if err = handle.LinkSetGroup(l, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	r.sweep(ctx)
+
+	if got := links(t, fake, podNetNSURL); !got["nsm-1"] || !got["nsm-2"] {
+		t.Errorf("got pod links %v, want the changed links left alone", got)
+	}
+}
+
+func TestGracePeriod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFake(t)
+
+	newReconciler(ctx, fake, 100*time.Millisecond)
+
+	if got := links(t, fake, podNetNSURL); !got["nsm-1"] {
+		t.Fatalf("got pod links %v, want the orphan kept during the grace period", got)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for links(t, fake, podNetNSURL)["nsm-1"] {
+		if time.Now().After(deadline) {
+			t.Fatal("the orphan was not removed after the grace period")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := links(t, fake, podNetNSURL); got["nsm-2"] || !got["eth0"] {
+		t.Errorf("got pod links %v, want only the forwarder links removed", got)
+	}
+}
+
+func TestClaimUnknown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFake(t)
+	r := newReconciler(ctx, fake, time.Hour)
+
+	// Claiming the links that are not orphans, or on a nil Reconciler, does nothing
+	r.Claim(ctx, podNetNSURL, "eth0")
+	r.Claim(ctx, "", "nsm-1")
+	(*Reconciler)(nil).Claim(ctx, podNetNSURL, "nsm-1")
+	r.sweep(ctx)
+
+	if got := links(t, fake, podNetNSURL); got["nsm-1"] || got["nsm-2"] || !got["eth0"] {
+		t.Errorf("got pod links %v, want the forwarder links removed", got)
+	}
+}
@@ -0,0 +1,260 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package qos limits the bandwidth of the connections with tc qdiscs on their interfaces.
+package qos
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/link"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const (
+	// IngressRateLabel is the connection label setting the rate of the traffic received by the client pod, in
+	// bits per second with an optional tc unit suffix: kbit, mbit, gbit or tbit
+	IngressRateLabel = "qosIngressRate"
+	// EgressRateLabel is the connection label setting the rate of the traffic sent by the client pod
+	EgressRateLabel = "qosEgressRate"
+
+	// The bursts hold 10ms of traffic at the configured rate, and at least a full GSO packet
+	burstTime = 10 * time.Millisecond
+	minBurst  = 64 * 1024
+	// The shaper queues up to 50ms of traffic before dropping packets
+	queueTime = 50 * time.Millisecond
+)
+
+var (
+	// rootHandle is the handle of the shaping qdisc, it tells the qdiscs added by the forwarder from the others
+	rootHandle = netlink.MakeHandle(link.Group, 0)
+	// ingressHandle is the handle of the ingress qdisc, the same for all of them
+	ingressHandle = netlink.MakeHandle(0xffff, 0)
+)
+
+// policerPriority is the priority of the filter policing the traffic received by the pod
+const policerPriority = 1
+
+// Limits are the bandwidth limits of a connection, in bits per second. A zero rate is unlimited.
+type Limits struct {
+	IngressRate uint64
+	EgressRate  uint64
+}
+
+// Unlimited reports whether the limits set no rate
+func (l Limits) Unlimited() bool {
+	return l.IngressRate == 0 && l.EgressRate == 0
+}
+
+// FromLabels returns the Limits set by the labels of a connection
+func FromLabels(labels map[string]string) (Limits, error) {
+	var limits Limits
+	var err error
+	if limits.IngressRate, err = parseRate(labels[IngressRateLabel]); err != nil {
+		return Limits{}, errors.Wrapf(err, "invalid %s label", IngressRateLabel)
+	}
+	if limits.EgressRate, err = parseRate(labels[EgressRateLabel]); err != nil {
+		return Limits{}, errors.Wrapf(err, "invalid %s label", EgressRateLabel)
+	}
+	return limits, nil
+}
+
+var rateUnits = []struct {
+	suffix     string
+	multiplier uint64
+}{
+	{"tbit", 1e12},
+	{"gbit", 1e9},
+	{"mbit", 1e6},
+	{"kbit", 1e3},
+	{"bit", 1},
+}
+
+// parseRate parses a rate in the tc syntax, in bits per second. An empty rate is 0.
+func parseRate(rate string) (uint64, error) {
+	s := strings.ToLower(strings.TrimSpace(rate))
+	if s == "" {
+		return 0, nil
+	}
+	multiplier := uint64(1)
+	for _, unit := range rateUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSuffix(s, unit.suffix), unit.multiplier
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, errors.Errorf("invalid rate: %q", rate)
+	}
+	return uint64(value * float64(multiplier)), nil
+}
+
+// Apply reconciles the qdiscs of the link l, in the network namespace of handle, with the limits. The traffic sent
+// by the pod is shaped by a tbf qdisc, and the traffic it receives is policed on ingress. The qdiscs and filters of a
+// removed limit are deleted if they are the ones added by Apply, the ones added by others, like a clsact qdisc of a
+// CNI plugin, are left alone. All of them go away with the link when the connection is closed.
+func Apply(ctx context.Context, handle nlhandle.Handle, l netlink.Link, limits Limits) error {
+	qdiscs, err := handle.QdiscList(l)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var root, ingress netlink.Qdisc
+	for _, qdisc := range qdiscs {
+		switch {
+		case qdisc.Attrs().Handle == rootHandle && qdisc.Type() == "tbf":
+			root = qdisc
+		// HANDLE_CLSACT is the same as HANDLE_INGRESS, the kind tells the ingress qdiscs from the clsact ones
+		case qdisc.Attrs().Parent == netlink.HANDLE_INGRESS && qdisc.Type() == "ingress":
+			ingress = qdisc
+		}
+	}
+	logger := log.FromContext(ctx).WithField("link.Name", l.Attrs().Name).WithField("qos", "Apply")
+
+	if limits.EgressRate > 0 {
+		if err = handle.QdiscReplace(newTbf(l.Attrs().Index, limits.EgressRate/8)); err != nil {
+			return errors.Wrapf(err, "failed to shape the traffic sent on %s", l.Attrs().Name)
+		}
+		logger.WithField("rate", strconv.FormatUint(limits.EgressRate, 10)).Debug("egress shaped")
+	} else if root != nil {
+		if err = handle.QdiscDel(root); err != nil {
+			return errors.WithStack(err)
+		}
+		logger.Debug("egress shaping removed")
+	}
+
+	if limits.IngressRate > 0 {
+		if limits.IngressRate/8 > math.MaxUint32 {
+			return errors.Errorf("ingress rate %d too high to be policed", limits.IngressRate)
+		}
+		ingressQdisc := &netlink.Ingress{QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: l.Attrs().Index,
+			Handle:    ingressHandle,
+			Parent:    netlink.HANDLE_INGRESS,
+		}}
+		if err = handle.QdiscReplace(ingressQdisc); err != nil {
+			return errors.Wrapf(err, "failed to add the ingress qdisc to %s", l.Attrs().Name)
+		}
+		if err = handle.FilterReplace(newPolicer(l.Attrs().Index, ingressQdisc.Handle, uint32(limits.IngressRate/8))); err != nil {
+			return errors.Wrapf(err, "failed to police the traffic received on %s", l.Attrs().Name)
+		}
+		logger.WithField("rate", strconv.FormatUint(limits.IngressRate, 10)).Debug("ingress policed")
+	} else if ingress != nil {
+		if err = removePolicer(handle, l, ingress); err != nil {
+			return err
+		}
+		logger.Debug("ingress policing removed")
+	}
+	return nil
+}
+
+// removePolicer deletes the filter policing the traffic received on the link l, and the ingress qdisc along with it
+// unless other filters are attached to the qdisc. Nothing is deleted if the qdisc has no such filter, it was not
+// added by Apply.
+func removePolicer(handle nlhandle.Handle, l netlink.Link, ingress netlink.Qdisc) error {
+	filters, err := handle.FilterList(l, ingress.Attrs().Handle)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	var policer netlink.Filter
+	for _, filter := range filters {
+		if isPolicer(filter) {
+			policer = filter
+		}
+	}
+	switch {
+	case policer == nil:
+		return nil
+	case len(filters) == 1:
+		return errors.WithStack(handle.QdiscDel(ingress))
+	default:
+		return errors.WithStack(handle.FilterDel(policer))
+	}
+}
+
+// isPolicer reports whether filter is the one added by newPolicer
+func isPolicer(filter netlink.Filter) bool {
+	matchAll, ok := filter.(*netlink.MatchAll)
+	if !ok || matchAll.Priority != policerPriority {
+		return false
+	}
+	for _, action := range matchAll.Actions {
+		if _, ok := action.(*netlink.PoliceAction); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// bytesIn returns the number of bytes sent in d at byteRate bytes per second, capped to fit a tc parameter
+func bytesIn(byteRate uint64, d time.Duration) uint32 {
+	b := float64(byteRate) * d.Seconds()
+	if b > math.MaxUint32/2 {
+		return math.MaxUint32 / 2
+	}
+	return uint32(b)
+}
+
+func burst(byteRate uint64) uint32 {
+	if b := bytesIn(byteRate, burstTime); b > minBurst {
+		return b
+	}
+	return minBurst
+}
+
+// newTbf returns the qdisc shaping the traffic sent on the link to byteRate bytes per second
+func newTbf(linkIndex int, byteRate uint64) *netlink.Tbf {
+	b := burst(byteRate)
+	return &netlink.Tbf{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: linkIndex,
+			Handle:    rootHandle,
+			Parent:    netlink.HANDLE_ROOT,
+		},
+		Rate:   byteRate,
+		Buffer: netlink.Xmittime(byteRate, b),
+		Limit:  bytesIn(byteRate, queueTime) + b,
+	}
+}
+
+// newPolicer returns the filter dropping the traffic received on the link above byteRate bytes per second
+func newPolicer(linkIndex int, parent, byteRate uint32) *netlink.MatchAll {
+	police := netlink.NewPoliceAction()
+	police.Rate = byteRate
+	police.Burst = burst(uint64(byteRate))
+	police.Mtu = math.MaxUint16
+	police.ExceedAction = netlink.TC_POLICE_SHOT
+	return &netlink.MatchAll{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: linkIndex,
+			Parent:    parent,
+			Priority:  policerPriority,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		Actions: []netlink.Action{police},
+	}
+}
@@ -0,0 +1,177 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package qos
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+)
+
+const testNetNS = "file:///proc/1/fd/1"
+
+// foreignFilter is a filter added to the ingress qdisc by someone else than the forwarder
+func foreignFilter(linkIndex int) *netlink.U32 {
+	return &netlink.U32{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: linkIndex,
+			Parent:    ingressHandle,
+			Priority:  2,
+			Protocol:  unix.ETH_P_ALL,
+		},
+	}
+}
+
+func TestApply(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// setup adds the qdiscs and filters found on the link before the limits are removed
+		setup       func(handle nlhandle.Handle, l netlink.Link) error
+		wantQdiscs  []string
+		wantFilters int
+	}{
+		{
+			name: "forwarder qdiscs",
+			setup: func(handle nlhandle.Handle, l netlink.Link) error {
+				return Apply(context.Background(), handle, l, Limits{IngressRate: 1e6, EgressRate: 1e6})
+			},
+		},
+		{
+			name: "foreign clsact",
+			setup: func(handle nlhandle.Handle, l netlink.Link) error {
+				return handle.QdiscReplace(&netlink.GenericQdisc{
+					QdiscAttrs: netlink.QdiscAttrs{LinkIndex: l.Attrs().Index, Handle: ingressHandle, Parent: netlink.HANDLE_CLSACT},
+					QdiscType:  "clsact",
+				})
+			},
+			wantQdiscs: []string{"clsact"},
+		},
+		{
+			name: "foreign ingress",
+			setup: func(handle nlhandle.Handle, l netlink.Link) error {
+				if err := handle.QdiscReplace(&netlink.Ingress{
+					QdiscAttrs: netlink.QdiscAttrs{LinkIndex: l.Attrs().Index, Handle: ingressHandle, Parent: netlink.HANDLE_INGRESS},
+				}); err != nil {
+					return err
+				}
+				return handle.FilterReplace(foreignFilter(l.Attrs().Index))
+			},
+			wantQdiscs:  []string{"ingress"},
+			wantFilters: 1,
+		},
+		{
+			name: "shared ingress",
+			setup: func(handle nlhandle.Handle, l netlink.Link) error {
+				if err := Apply(context.Background(), handle, l, Limits{IngressRate: 1e6}); err != nil {
+					return err
+				}
+				return handle.FilterReplace(foreignFilter(l.Attrs().Index))
+			},
+			wantQdiscs:  []string{"ingress"},
+			wantFilters: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := nlhandle.NewFake()
+			fake.AddNamespace(testNetNS)
+			handle, err := fake.FromURL(testNetNS)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer handle.Close()
+			if err = handle.LinkAdd(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "nsm0"}}); err != nil {
+				t.Fatal(err)
+			}
+			l, err := handle.LinkByName("nsm0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = tc.setup(handle, l); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = Apply(context.Background(), handle, l, Limits{}); err != nil {
+				t.Fatalf("Apply() failed: %v", err)
+			}
+
+			qdiscs, filters, err := fake.Qdiscs(testNetNS, "nsm0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var kinds []string
+			for _, qdisc := range qdiscs {
+				kinds = append(kinds, qdisc.Type())
+			}
+			if len(kinds) != len(tc.wantQdiscs) || (len(kinds) > 0 && kinds[0] != tc.wantQdiscs[0]) {
+				t.Errorf("qdiscs = %v, want %v", kinds, tc.wantQdiscs)
+			}
+			if len(filters) != tc.wantFilters {
+				t.Errorf("got %d filters, want %d", len(filters), tc.wantFilters)
+			}
+			for _, filter := range filters {
+				if isPolicer(filter) {
+					t.Errorf("the policer is still attached")
+				}
+			}
+		})
+	}
+}
+
+func TestApplyLimits(t *testing.T) {
+	fake := nlhandle.NewFake()
+	fake.AddNamespace(testNetNS)
+	handle, err := fake.FromURL(testNetNS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	if err = handle.LinkAdd(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "nsm0"}}); err != nil {
+		t.Fatal(err)
+	}
+	l, err := handle.LinkByName("nsm0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Apply(context.Background(), handle, l, Limits{IngressRate: 8e6, EgressRate: 16e6}); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+	qdiscs, filters, err := fake.Qdiscs(testNetNS, "nsm0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, qdisc := range qdiscs {
+		if tbf, ok := qdisc.(*netlink.Tbf); ok && tbf.Rate != 2e6 {
+			t.Errorf("tbf rate = %d, want %d", tbf.Rate, uint64(2e6))
+		}
+	}
+	if len(qdiscs) != 2 {
+		t.Errorf("got %d qdiscs, want 2", len(qdiscs))
+	}
+	if len(filters) != 1 || !isPolicer(filters[0]) {
+		t.Fatalf("filters = %v, want the policer", filters)
+	}
+	if rate := filters[0].(*netlink.MatchAll).Actions[0].(*netlink.PoliceAction).Rate; rate != 1e6 {
+		t.Errorf("police rate = %d, want %d", rate, uint32(1e6))
+	}
+}
@@ -0,0 +1,217 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package registration keeps the forwarder registered as a network service endpoint: the registration is refreshed
+// before it expires, retried with backoff when the registry is unavailable, which also re-registers the forwarder
+// after a registry restart, and withdrawn on shutdown.
+package registration
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/networkservicemesh/api/pkg/api/registry"
+	"github.com/networkservicemesh/sdk/pkg/registry/common/begin"
+	"github.com/networkservicemesh/sdk/pkg/registry/common/clientconn"
+	"github.com/networkservicemesh/sdk/pkg/registry/common/clienturl"
+	"github.com/networkservicemesh/sdk/pkg/registry/common/connect"
+	"github.com/networkservicemesh/sdk/pkg/registry/common/dial"
+	"github.com/networkservicemesh/sdk/pkg/registry/core/chain"
+	"github.com/networkservicemesh/sdk/pkg/tools/log"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultExpiration = time.Minute
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = 30 * time.Second
+	dialTimeout       = 5 * time.Second
+)
+
+// Registrar registers the network service endpoint of the forwarder and keeps the registration alive
+type Registrar struct {
+	client     registry.NetworkServiceEndpointRegistryClient
+	nse        *registry.NetworkServiceEndpoint
+	expiration time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	onState    func(err error)
+
+	mu         sync.Mutex
+	registered *registry.NetworkServiceEndpoint
+}
+
+// NewRegistrar returns a Registrar of nse with the registry at registryURL. The registry client lives until ctx is
+// done, which has to outlive the ctx of Run for Unregister to be possible on shutdown.
+func NewRegistrar(ctx context.Context, registryURL *url.URL, nse *registry.NetworkServiceEndpoint, options ...Option) *Registrar {
+	o := &registrarOptions{
+		expiration: defaultExpiration,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+		onState:    func(error) {},
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+	return &Registrar{
+		client: chain.NewNetworkServiceEndpointRegistryClient(
+			append([]registry.NetworkServiceEndpointRegistryClient{
+				begin.NewNetworkServiceEndpointRegistryClient(),
+				clienturl.NewNetworkServiceEndpointRegistryClient(registryURL),
+				clientconn.NewNetworkServiceEndpointRegistryClient(),
+				dial.NewNetworkServiceEndpointRegistryClient(ctx,
+					dial.WithDialOptions(o.dialOptions...),
+					dial.WithDialTimeout(dialTimeout),
+				),
+			}, append(o.additionalFunctionality, connect.NewNetworkServiceEndpointRegistryClient())...)...,
+		),
+		nse:        nse,
+		expiration: o.expiration,
+		minBackoff: o.minBackoff,
+		maxBackoff: o.maxBackoff,
+		onState:    o.onState,
+	}
+}
+
+// Run registers the endpoint and refreshes the registration when two thirds of its lifetime have elapsed, until ctx
+// is done. Failed registrations are retried with an exponential backoff. The state of the registration is reported
+// to the state func after every attempt.
+func (r *Registrar) Run(ctx context.Context) {
+	logger := log.FromContext(ctx).WithField("registration", "Run")
+	backoff := r.minBackoff
+	for {
+		expires, err := r.register(ctx)
+		r.onState(err)
+
+		var wait time.Duration
+		if err != nil {
+			logger.Errorf("failed to register %s, retrying in %v: %v", r.nse.GetName(), backoff, err)
+			wait = backoff
+			if backoff *= 2; backoff > r.maxBackoff {
+				backoff = r.maxBackoff
+			}
+		} else {
+			logger.Debugf("registered %s until %s", r.nse.GetName(), expires)
+			backoff = r.minBackoff
+			wait = time.Until(expires) * 2 / 3
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (r *Registrar) register(ctx context.Context) (time.Time, error) {
+	nse := r.nse.Clone()
+	nse.ExpirationTime = timestamppb.New(time.Now().Add(r.expiration))
+
+	registerCtx, cancel := context.WithTimeout(ctx, r.expiration/3)
+	defer cancel()
+	registered, err := r.client.Register(registerCtx, nse)
+	if err != nil {
+		return time.Time{}, errors.WithStack(err)
+	}
+
+	r.mu.Lock()
+	r.registered = registered
+	r.mu.Unlock()
+
+	// The registry may have shortened the expiration
+	return expirationTime(registered.GetExpirationTime(), nse.GetExpirationTime()), nil
+}
+
+// Unregister withdraws the registration, so that the network service managers stop selecting the forwarder right
+// away instead of when the registration expires. It is meant to be called on shutdown, once Run has returned, and
+// before the gRPC server of the forwarder stops.
+func (r *Registrar) Unregister(ctx context.Context) error {
+	r.mu.Lock()
+	registered := r.registered
+	r.registered = nil
+	r.mu.Unlock()
+
+	if registered == nil {
+		return nil
+	}
+	if _, err := r.client.Unregister(ctx, registered); err != nil {
+		return errors.Wrapf(err, "failed to unregister %s", registered.GetName())
+	}
+	log.FromContext(ctx).WithField("registration", "Unregister").Infof("unregistered %s", registered.GetName())
+	return nil
+}
+
+func expirationTime(returned, requested *timestamppb.Timestamp) time.Time {
+	if returned != nil && returned.AsTime().Before(requested.AsTime()) {
+		return returned.AsTime()
+	}
+	return requested.AsTime()
+}
+
+// Option is an option pattern for NewRegistrar
+type Option func(o *registrarOptions)
+
+type registrarOptions struct {
+	expiration              time.Duration
+	minBackoff              time.Duration
+	maxBackoff              time.Duration
+	onState                 func(err error)
+	dialOptions             []grpc.DialOption
+	additionalFunctionality []registry.NetworkServiceEndpointRegistryClient
+}
+
+// WithExpiration sets the lifetime requested for every registration. Defaults to 1 minute.
+func WithExpiration(expiration time.Duration) Option {
+	return func(o *registrarOptions) {
+		o.expiration = expiration
+	}
+}
+
+// WithBackoff sets the bounds of the exponential backoff between failed registrations. Defaults to 1 to 30 seconds.
+func WithBackoff(minBackoff, maxBackoff time.Duration) Option {
+	return func(o *registrarOptions) {
+		o.minBackoff = minBackoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithStateFunc sets the func the state of the registration is reported to, err being nil while registered
+func WithStateFunc(onState func(err error)) Option {
+	return func(o *registrarOptions) {
+		o.onState = onState
+	}
+}
+
+// WithDialOptions sets the options used to dial the registry
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *registrarOptions) {
+		o.dialOptions = dialOptions
+	}
+}
+
+// WithAdditionalFunctionality sets registry client chain elements inserted before the one connecting to the registry
+func WithAdditionalFunctionality(additionalFunctionality ...registry.NetworkServiceEndpointRegistryClient) Option {
+	return func(o *registrarOptions) {
+		o.additionalFunctionality = additionalFunctionality
+	}
+}
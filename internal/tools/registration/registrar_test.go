@@ -0,0 +1,234 @@
+/*
+ *  Copyright (c) 2026 Avesha, Inc. All rights reserved.
+ *
+ *  SPDX-License-Identifier: Apache-2.0
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package registration
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/networkservicemesh/api/pkg/api/registry"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeRegistry stands for the registry client chain, it fails the first registrations it is asked to
+type fakeRegistry struct {
+	mu            sync.Mutex
+	failures      int
+	registered    []time.Time
+	expirations   []time.Time
+	unregistered  []*registry.NetworkServiceEndpoint
+	maxExpiration time.Duration
+}
+
+func (f *fakeRegistry) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint, _ ...grpc.CallOption) (*registry.NetworkServiceEndpoint, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.registered = append(f.registered, time.Now())
+	if f.failures > 0 {
+		f.failures--
+		return nil, errors.New("registry unavailable")
+	}
+	f.expirations = append(f.expirations, nse.GetExpirationTime().AsTime())
+	nse = nse.Clone()
+	if f.maxExpiration > 0 {
+		nse.ExpirationTime = timestamppb.New(time.Now().Add(f.maxExpiration))
+	}
+	return nse, nil
+}
+
+func (f *fakeRegistry) Find(ctx context.Context, query *registry.NetworkServiceEndpointQuery, _ ...grpc.CallOption) (registry.NetworkServiceEndpointRegistry_FindClient, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeRegistry) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint, _ ...grpc.CallOption) (*empty.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.unregistered = append(f.unregistered, nse)
+	return &empty.Empty{}, nil
+}
+
+func (f *fakeRegistry) registrations() []time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Time(nil), f.registered...)
+}
+
+func newRegistrar(t *testing.T, client registry.NetworkServiceEndpointRegistryClient, options ...Option) *Registrar {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	r := NewRegistrar(ctx, &url.URL{Scheme: "unix", Path: "/registry.sock"}, &registry.NetworkServiceEndpoint{Name: "forwarder"}, options...)
+	r.client = client
+	return r
+}
+
+// waitFor waits for the registry to get n registrations
+func waitFor(t *testing.T, f *fakeRegistry, n int) []time.Time {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		if registered := f.registrations(); len(registered) >= n {
+			return registered
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d registrations, want %d", len(f.registrations()), n)
+		}
+	}
+}
+
+func TestRunRefresh(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		maxExpiration time.Duration
+		wantInterval  time.Duration
+	}{
+		{name: "requested expiration", wantInterval: 200 * time.Millisecond},
+		{name: "expiration shortened by the registry", maxExpiration: 150 * time.Millisecond, wantInterval: 100 * time.Millisecond},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &fakeRegistry{maxExpiration: tc.maxExpiration}
+			var mu sync.Mutex
+			var states []error
+			r := newRegistrar(t, f, WithExpiration(300*time.Millisecond), WithStateFunc(func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				states = append(states, err)
+			}))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			start := time.Now()
+			go r.Run(ctx)
+
+			// The registration is refreshed when two thirds of its lifetime have elapsed
+			registered := waitFor(t, f, 3)
+			for i := 1; i < len(registered); i++ {
+				if gap := registered[i].Sub(registered[i-1]); gap < tc.wantInterval*9/10 {
+					t.Errorf("registration %d refreshed after %v, want %v", i, gap, tc.wantInterval)
+				}
+			}
+			f.mu.Lock()
+			if requested := f.expirations[0].Sub(start); requested < 300*time.Millisecond || requested > time.Second {
+				t.Errorf("got an expiration %v after the start, want 300ms", requested)
+			}
+			f.mu.Unlock()
+			mu.Lock()
+			for _, err := range states {
+				if err != nil {
+					t.Errorf("got state %v, want registered", err)
+				}
+			}
+			mu.Unlock()
+		})
+	}
+}
+
+func TestRunBackoff(t *testing.T) {
+	f := &fakeRegistry{failures: 3}
+	var mu sync.Mutex
+	var states []error
+	r := newRegistrar(t, f, WithBackoff(20*time.Millisecond, 40*time.Millisecond), WithStateFunc(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, err)
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	// The failed registrations are retried after 20ms, then 40ms, the maximum
+	registered := waitFor(t, f, 4)
+	for i, want := range []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond} {
+		if gap := registered[i+1].Sub(registered[i]); gap < want {
+			t.Errorf("registration %d retried after %v, want %v", i+1, gap, want)
+		}
+	}
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(5 * time.Millisecond) {
+		mu.Lock()
+		n := len(states)
+		mu.Unlock()
+		if n >= 4 || time.Now().After(deadline) {
+			break
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(states) < 4 || states[0] == nil || states[1] == nil || states[2] == nil || states[3] != nil {
+		t.Errorf("got states %v, want 3 failures and a registration", states)
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	f := &fakeRegistry{}
+	r := newRegistrar(t, f)
+
+	// Nothing to withdraw before the first registration
+	if err := r.Unregister(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.unregistered) != 0 {
+		t.Fatal("got an unregistration without registration")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+	waitFor(t, f, 1)
+	cancel()
+	<-done
+
+	// The registration is withdrawn once Run has returned, and only once
+	for i := 0; i < 2; i++ {
+		if err := r.Unregister(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(f.unregistered) != 1 || f.unregistered[0].GetName() != "forwarder" {
+		t.Errorf("got unregistrations %v, want forwarder once", f.unregistered)
+	}
+}
+
+func TestExpirationTime(t *testing.T) {
+	now := time.Now()
+	requested := timestamppb.New(now.Add(time.Minute))
+	for _, tc := range []struct {
+		name     string
+		returned *timestamppb.Timestamp
+		want     time.Time
+	}{
+		{name: "none returned", want: requested.AsTime()},
+		{name: "shortened", returned: timestamppb.New(now.Add(time.Second)), want: now.Add(time.Second)},
+		{name: "extended", returned: timestamppb.New(now.Add(time.Hour)), want: requested.AsTime()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := expirationTime(tc.returned, requested); !got.Equal(tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"strings"
 	"syscall"
 	"time"
 
@@ -33,10 +34,16 @@ import (
 	"github.com/edwarnicke/grpcfd"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/chains/forwarder"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/drain"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/networkservice/mechanisms/geneve"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/admin"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/health"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/metrics"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/nlhandle"
+	"github.com/kubeslice/cmd-forwarder-kernel/internal/tools/registration"
 	registryapi "github.com/networkservicemesh/api/pkg/api/registry"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/chains/endpoint"
 	"github.com/networkservicemesh/sdk/pkg/networkservice/common/authorize"
-	registryclient "github.com/networkservicemesh/sdk/pkg/registry/chains/client"
 	"github.com/networkservicemesh/sdk/pkg/registry/common/sendfd"
 	"github.com/networkservicemesh/sdk/pkg/tools/grpcutils"
 	"github.com/networkservicemesh/sdk/pkg/tools/log"
@@ -46,6 +53,7 @@ import (
 	"github.com/networkservicemesh/sdk/pkg/tools/spire"
 	"github.com/networkservicemesh/sdk/pkg/tools/token"
 	"github.com/networkservicemesh/sdk/pkg/tools/tracing"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
 	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
@@ -54,17 +62,59 @@ import (
 	"google.golang.org/grpc/credentials"
 )
 
+const unregisterTimeout = 5 * time.Second
+
 // Config - configuration for cmd-forwarder-kernel
 type Config struct {
-	Name             string            `default:"forwarder" desc:"Name of Endpoint"`
-	Labels           map[string]string `default:"p2p:true" desc:"Labels related to this forwarder instance"`
-	NSName           string            `default:"forwarder" desc:"Name of Network Service to Register with Registry"`
-	TunnelIP         string            `desc:"IP or CIDR to use for vxlan tunnels" split_words:"true"`
-	TunnelPort       string            `desc:"Port number to use for vxlan tunnels" split_words:"true"`
-	ConnectTo        url.URL           `default:"unix:///connect.to.socket" desc:"url to connect to" split_words:"true"`
-	LogLevel         string            `default:"INFO" desc:"Log level" split_words:"true"`
-	MaxTokenLifetime time.Duration     `default:"24h" desc:"maximum lifetime of tokens" split_words:"true"`
-	DialTimeout      time.Duration     `default:"50ms" desc:"Timeout for the dial the next endpoint" split_words:"true"`
+	Name                   string            `default:"forwarder" desc:"Name of Endpoint"`
+	Labels                 map[string]string `default:"p2p:true" desc:"Labels related to this forwarder instance"`
+	NSName                 string            `default:"forwarder" desc:"Name of Network Service to Register with Registry"`
+	TunnelIP               string            `desc:"IP or CIDR to use for vxlan tunnels, or an IPv4 and an IPv6 one separated by a comma on dual-stack nodes" split_words:"true"`
+	TunnelPort             uint16            `default:"4789" desc:"Port number to use for vxlan tunnels" split_words:"true"`
+	ConnectTo              url.URL           `default:"unix:///connect.to.socket" desc:"url to connect to" split_words:"true"`
+	LogLevel               string            `default:"INFO" desc:"Log level" split_words:"true"`
+	MaxTokenLifetime       time.Duration     `default:"24h" desc:"maximum lifetime of tokens" split_words:"true"`
+	DialTimeout            time.Duration     `default:"50ms" desc:"Timeout for the dial the next endpoint" split_words:"true"`
+	OrphanGracePeriod      time.Duration     `default:"15m" desc:"Time to wait for a refresh before deleting interfaces left behind by a previous forwarder instance" split_words:"true"`
+	MetricsListenOn        string            `desc:"host:port to serve the Prometheus /metrics endpoint on, disabled if empty" split_words:"true"`
+	RemoteMechanisms       []string          `default:"VXLAN" desc:"Remote mechanisms to enable (VXLAN, GENEVE, GRETAP for Ethernet payloads, GRE, WIREGUARD for IP payloads), in order of preference" split_words:"true"`
+	GenevePort             uint16            `default:"6081" desc:"Port number to use for geneve tunnels, the same on all the nodes" split_words:"true"`
+	GeneveOptions          string            `desc:"TLV options to set in the geneve header of the packets sent, as comma separated CLASS:TYPE:DATA hexadecimal triplets like the geneve_opts of tc tunnel_key, none if empty" split_words:"true"`
+	VxlanIPsec             bool              `default:"false" desc:"Protect the vxlan tunnels to the nodes whose forwarder enables it as well with IPsec" envconfig:"VXLAN_IPSEC"`
+	VLANParentInterface    string            `desc:"Host interface to create the 802.1Q sub-interfaces of the VLAN mechanism on, the VLAN mechanism is disabled if empty" envconfig:"VLAN_PARENT_INTERFACE"`
+	ChildParentInterface   string            `desc:"Host interface to create the macvlan and ipvlan children of the local connections with the linkType parameter or label set to macvlan, ipvlan-l2 or ipvlan-l3 on" split_words:"true"`
+	VxlanChecksumOffload   string            `default:"enable" desc:"Set to disable to turn off the tx checksum offload of the vxlan interfaces" split_words:"true"`
+	ConnectionRefresh      bool              `default:"false" desc:"Refresh the outgoing connections from the forwarder instead of relying on the refreshes of the incoming ones" split_words:"true"`
+	HealthListenOn         string            `desc:"host:port to serve the /healthz and /readyz HTTP probes on, disabled if empty" split_words:"true"`
+	HealthGRPCListenOn     string            `desc:"host:port to serve the gRPC health protocol on, disabled if empty" envconfig:"HEALTH_GRPC_LISTEN_ON"`
+	AdminSocket            string            `default:"/run/forwarder-kernel/admin.sock" desc:"Unix socket to serve the read-only admin API on, disabled if empty" split_words:"true"`
+	AdminTokenFile         string            `desc:"File holding the bearer token of the admin API force-close operation, served on the admin socket only, disabled if empty" split_words:"true"`
+	AdminListenOn          string            `desc:"host:port to serve the read-only admin API on over HTTPS, to the clients with an SVID of the trust domain, disabled if empty" split_words:"true"`
+	StateDir               string            `desc:"Directory, on a hostPath volume, to persist the state of the connections and the wireguard and ipsec keys to for their cleanup and reuse after a restart, disabled if empty" split_words:"true"`
+	DrainTimeout           time.Duration     `default:"20s" desc:"Time to keep serving the existing connections after a shutdown signal, while the new ones are rejected; must be lower than the terminationGracePeriodSeconds of the pod" split_words:"true"`
+	RegistrationExpiration time.Duration     `default:"1m" desc:"Lifetime of the registration of the forwarder, which is renewed when two thirds of it have elapsed" split_words:"true"`
+
+	geneveOptions []nlhandle.GeneveOption
+}
+
+func (c *Config) validate() error {
+	if c.TunnelPort == 0 {
+		return errors.New("NSM_TUNNEL_PORT must not be 0")
+	}
+	if c.GenevePort == 0 {
+		return errors.New("NSM_GENEVE_PORT must not be 0")
+	}
+	var err error
+	if c.geneveOptions, err = geneve.ParseOptions(c.GeneveOptions); err != nil {
+		return errors.Wrap(err, "invalid NSM_GENEVE_OPTIONS")
+	}
+	if c.VxlanChecksumOffload != "enable" && c.VxlanChecksumOffload != "disable" {
+		return errors.Errorf("NSM_VXLAN_CHECKSUM_OFFLOAD must be enable or disable, got %q", c.VxlanChecksumOffload)
+	}
+	if c.RegistrationExpiration < time.Second {
+		return errors.Errorf("NSM_REGISTRATION_EXPIRATION must be at least 1s, got %v", c.RegistrationExpiration)
+	}
+	return nil
 }
 
 func main() {
@@ -87,6 +137,11 @@ func main() {
 	logrus.SetFormatter(&nested.Formatter{})
 	ctx = log.WithLog(ctx, logruslogger.New(ctx, map[string]interface{}{"cmd": os.Args[0]}))
 
+	// The servers and the endpoint outlive ctx, so that the forwarder can drain its connections before they stop.
+	// The kernel links are not deleted on shutdown, the next forwarder instance takes them over.
+	runCtx, runCancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer runCancel()
+
 	starttime := time.Now()
 
 	// ********************************************************************************
@@ -100,6 +155,9 @@ func main() {
 	if err := envconfig.Process("nsm", config); err != nil {
 		logrus.Fatalf("error processing config from env: %+v", err)
 	}
+	if err := config.validate(); err != nil {
+		logrus.Fatalf("invalid config: %+v", err)
+	}
 
 	log.FromContext(ctx).Infof("Config: %#v", config)
 	level, err := logrus.ParseLevel(config.LogLevel)
@@ -108,6 +166,18 @@ func main() {
 	}
 	logrus.SetLevel(level)
 	log.EnableTracing(level == logrus.TraceLevel)
+
+	// The probes are served from the start, the forwarder is not ready until it is registered
+	healthChecker := health.NewChecker()
+	healthChecker.Set("registration", errors.New("not registered yet"))
+	if config.HealthListenOn != "" {
+		exitOnErrCh(ctx, cancel, health.ListenAndServe(runCtx, config.HealthListenOn, healthChecker))
+		log.FromContext(ctx).Infof("serving the health probes on http://%s", config.HealthListenOn)
+	}
+	if config.HealthGRPCListenOn != "" {
+		exitOnErrCh(ctx, cancel, health.ListenAndServeGRPC(runCtx, config.HealthGRPCListenOn, healthChecker))
+		log.FromContext(ctx).Infof("serving the gRPC health protocol on %s", config.HealthGRPCListenOn)
+	}
 	log.FromContext(ctx).WithField("duration", time.Since(now)).Infof("completed phase 1: get config from environment")
 
 	// ********************************************************************************
@@ -124,6 +194,7 @@ func main() {
 		logrus.Fatalf("error getting x509 svid: %+v", err)
 	}
 	logrus.Infof("SVID: %q", svid.ID)
+	healthChecker.AddReadinessCheck("svid", health.SVIDCheck(source))
 
 	log.FromContext(ctx).WithField("duration", time.Since(now)).Info("completed phase 2: retrieving svid")
 
@@ -135,7 +206,9 @@ func main() {
 	tlsServerConfig := tlsconfig.MTLSServerConfig(source, source, tlsconfig.AuthorizeAny())
 	tlsServerConfig.MinVersion = tls.VersionTLS12
 
-	xConnectEndpoint, err := createKernelForwarderEndpoint(ctx, config, tlsClientConfig, source)
+	adminRegistry := admin.NewRegistry()
+	drainer := drain.NewDrainer()
+	xConnectEndpoint, err := createKernelForwarderEndpoint(runCtx, config, tlsClientConfig, source, adminRegistry, drainer)
 	if err != nil {
 		logrus.Fatalf("error configuring forwarder endpoint: %+v", err)
 	}
@@ -158,27 +231,71 @@ func main() {
 		),
 	)...)
 	xConnectEndpoint.Register(server)
-	srvErrCh := grpcutils.ListenAndServe(ctx, listenOn, server)
+	srvErrCh := grpcutils.ListenAndServe(runCtx, listenOn, server)
 	exitOnErrCh(ctx, cancel, srvErrCh)
+	healthChecker.AddLivenessCheck("grpc", health.ListenerCheck(listenOn))
+	healthChecker.AddReadinessCheck("netlink", health.NetlinkCheck())
+	if config.MetricsListenOn != "" {
+		exitOnErrCh(ctx, cancel, metrics.ListenAndServe(runCtx, config.MetricsListenOn))
+		log.FromContext(ctx).Infof("serving metrics on http://%s/metrics", config.MetricsListenOn)
+	}
+	var adminOptions []admin.Option
+	if config.AdminTokenFile != "" {
+		adminToken, err := os.ReadFile(config.AdminTokenFile)
+		if err != nil {
+			log.FromContext(ctx).Fatalf("error reading the admin token: %+v", err)
+		}
+		adminOptions = append(adminOptions, admin.WithToken(strings.TrimSpace(string(adminToken))))
+	}
+	adminHandler := admin.NewHandler(runCtx, adminRegistry, nlhandle.NewProvider(), adminOptions...)
+	// Force-closing is only served on the socket, which is not reachable from outside of the node
+	tcpAdminHandler := admin.NewHandler(runCtx, adminRegistry, nlhandle.NewProvider())
+	if config.AdminSocket != "" {
+		exitOnErrCh(ctx, cancel, admin.ListenAndServe(runCtx, "unix", config.AdminSocket, adminHandler, nil))
+		log.FromContext(ctx).Infof("serving the admin API on unix://%s", config.AdminSocket)
+	}
+	if config.AdminListenOn != "" {
+		// Unlike the socket, the TCP address is reachable from outside of the node: only the workloads with an SVID of
+		// the trust domain are served
+		exitOnErrCh(ctx, cancel, admin.ListenAndServe(runCtx, "tcp", config.AdminListenOn, tcpAdminHandler, tlsServerConfig.Clone()))
+		log.FromContext(ctx).Infof("serving the admin API on https://%s", config.AdminListenOn)
+	}
 	log.FromContext(ctx).WithField("duration", time.Since(now)).Info("completed phase 4: create grpc server and register xconnect")
 
 	// ********************************************************************************
 	log.FromContext(ctx).Infof("executing phase 5: register %s with the registry (time since start: %s)", config.NSName, time.Since(starttime))
 	// ********************************************************************************
-	err = registerEndpoint(ctx, config, tlsClientConfig, listenOn)
-	if err != nil {
-		log.FromContext(ctx).Fatalf("failed to connect to registry: %+v", err)
-	}
+	registrar := newRegistrar(ctx, config, tlsClientConfig, listenOn, healthChecker)
+	registrationDone := make(chan struct{})
+	go func() {
+		defer close(registrationDone)
+		registrar.Run(ctx)
+	}()
 	log.FromContext(ctx).WithField("duration", time.Since(now)).Infof("completed phase 5: register %s with the registry", config.NSName)
 
 	log.FromContext(ctx).Infof("Startup completed in %v", time.Since(starttime))
 
 	<-ctx.Done()
+	drainer.Start(ctx)
+	healthChecker.Set("drain", errors.New("draining"))
+	<-registrationDone
+	unregisterCtx, unregisterCancel := context.WithTimeout(runCtx, unregisterTimeout)
+	if err := registrar.Unregister(unregisterCtx); err != nil {
+		log.FromContext(ctx).Errorf("%+v", err)
+	}
+	unregisterCancel()
+	select {
+	case <-drainer.Idle():
+		log.FromContext(ctx).Info("drained all the connections")
+	case <-time.After(config.DrainTimeout):
+		log.FromContext(ctx).Infof("drain timeout expired, leaving %d connections in place", len(adminRegistry.List()))
+	}
+	runCancel()
 	<-srvErrCh
-
 }
 
-func createKernelForwarderEndpoint(ctx context.Context, config *Config, tlsClientConfig *tls.Config, source x509svid.Source) (xConnectEndpoint endpoint.Endpoint, err error) {
+func createKernelForwarderEndpoint(ctx context.Context, config *Config, tlsClientConfig *tls.Config, source x509svid.Source,
+	adminRegistry *admin.Registry, drainer *drain.Drainer) (xConnectEndpoint endpoint.Endpoint, err error) {
 	var spiffeidmap spire.SpiffeIDConnectionMap
 	return forwarder.NewServer(
 		ctx,
@@ -189,18 +306,34 @@ func createKernelForwarderEndpoint(ctx context.Context, config *Config, tlsClien
 		&config.ConnectTo,
 		config.TunnelIP,
 		config.DialTimeout,
-		grpc.WithBlock(),
-		grpc.WithTransportCredentials(
-			grpcfd.TransportCredentials(credentials.NewTLS(tlsClientConfig))),
-		grpc.WithDefaultCallOptions(
-			grpc.PerRPCCredentials(token.NewPerRPCCredentials(spiffejwt.TokenGeneratorFunc(source, config.MaxTokenLifetime))),
+		forwarder.WithOrphanGracePeriod(config.OrphanGracePeriod),
+		forwarder.WithRemoteMechanisms(config.RemoteMechanisms...),
+		forwarder.WithTunnelPort(config.TunnelPort),
+		forwarder.WithGenevePort(config.GenevePort),
+		forwarder.WithGeneveOptions(config.geneveOptions),
+		forwarder.WithVXLANChecksumOffload(config.VxlanChecksumOffload == "enable"),
+		forwarder.WithVXLANIPsec(config.VxlanIPsec),
+		forwarder.WithVLANParent(config.VLANParentInterface),
+		forwarder.WithChildParent(config.ChildParentInterface),
+		forwarder.WithStateDir(config.StateDir),
+		forwarder.WithRefresh(config.ConnectionRefresh),
+		forwarder.WithAdminRegistry(adminRegistry),
+		forwarder.WithDrainer(drainer),
+		forwarder.WithDialOptions(
+			grpc.WithBlock(),
+			grpc.WithTransportCredentials(
+				grpcfd.TransportCredentials(credentials.NewTLS(tlsClientConfig))),
+			grpc.WithDefaultCallOptions(
+				grpc.PerRPCCredentials(token.NewPerRPCCredentials(spiffejwt.TokenGeneratorFunc(source, config.MaxTokenLifetime))),
+			),
+			grpcfd.WithChainStreamInterceptor(),
+			grpcfd.WithChainUnaryInterceptor(),
 		),
-		grpcfd.WithChainStreamInterceptor(),
-		grpcfd.WithChainUnaryInterceptor(),
 	)
 }
 
-func registerEndpoint(ctx context.Context, cfg *Config, tlsClientConfig *tls.Config, listenOn *url.URL) error {
+func newRegistrar(ctx context.Context, cfg *Config, tlsClientConfig *tls.Config, listenOn *url.URL,
+	healthChecker *health.Checker) *registration.Registrar {
 	clientOptions := append(
 		tracing.WithTracingDial(),
 		grpc.WithBlock(),
@@ -212,27 +345,27 @@ func registerEndpoint(ctx context.Context, cfg *Config, tlsClientConfig *tls.Con
 		),
 	)
 
-	registryClient := registryclient.NewNetworkServiceEndpointRegistryClient(ctx, registryclient.WithClientURL(&cfg.ConnectTo),
-		registryclient.WithDialOptions(clientOptions...),
-		registryclient.WithNSEAdditionalFunctionality(
+	// The registry client outlives ctx, so that the forwarder can unregister on shutdown
+	return registration.NewRegistrar(context.WithoutCancel(ctx), &cfg.ConnectTo,
+		&registryapi.NetworkServiceEndpoint{
+			Name: cfg.Name,
+			NetworkServiceLabels: map[string]*registryapi.NetworkServiceLabels{
+				cfg.NSName: {
+					Labels: cfg.Labels,
+				},
+			},
+			NetworkServiceNames: []string{cfg.NSName},
+			Url:                 grpcutils.URLToTarget(listenOn),
+		},
+		registration.WithExpiration(cfg.RegistrationExpiration),
+		registration.WithStateFunc(func(err error) {
+			healthChecker.Set("registration", err)
+		}),
+		registration.WithDialOptions(clientOptions...),
+		registration.WithAdditionalFunctionality(
 			sendfd.NewNetworkServiceEndpointRegistryClient(),
 		),
 	)
-	_, err := registryClient.Register(ctx, &registryapi.NetworkServiceEndpoint{
-		Name: cfg.Name,
-		NetworkServiceLabels: map[string]*registryapi.NetworkServiceLabels{
-			cfg.NSName: {
-				Labels: cfg.Labels,
-			},
-		},
-		NetworkServiceNames: []string{cfg.NSName},
-		Url:                 grpcutils.URLToTarget(listenOn),
-	})
-	if err != nil {
-		log.FromContext(ctx).Fatalf("failed to connect to registry: %+v", err)
-	}
-
-	return err
 }
 
 func exitOnErrCh(ctx context.Context, cancel context.CancelFunc, errCh <-chan error) {